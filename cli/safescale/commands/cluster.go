@@ -65,6 +65,7 @@ var ClusterCommand = cli.Command{
 	Subcommands: []cli.Command{
 		clusterNodeCommand,
 		clusterMasterCommand,
+		clusterUserCommand,
 		clusterListCommand,
 		clusterCreateCommand,
 		clusterDeleteCommand,
@@ -74,14 +75,18 @@ var ClusterCommand = cli.Command{
 		//clusterSshCommand,
 		clusterStartCommand,
 		clusterStopCommand,
+		clusterExtendCommand,
+		clusterRotateKeysCommand,
 		clusterExpandCommand,
 		clusterShrinkCommand,
 		clusterDcosCommand,
 		clusterKubectlCommand,
 		clusterHelmCommand,
 		clusterListFeaturesCommand,
+		clusterFeaturesStatusCommand,
 		clusterCheckFeatureCommand,
 		clusterAddFeatureCommand,
+		clusterUpgradeFeatureCommand,
 		clusterDeleteFeatureCommand,
 	},
 }
@@ -230,6 +235,13 @@ func convertToMap(c api.Cluster) (map[string]interface{}, error) {
 		return nil, err
 	}
 	result["network_id"] = netCfg.NetworkID
+	expiresAt, err := c.GetExpiration(concurrency.RootTask())
+	if err != nil {
+		return nil, err
+	}
+	if !expiresAt.IsZero() {
+		result["expires_at"] = expiresAt.Format(time.RFC3339)
+	}
 	result["cidr"] = netCfg.CIDR
 	result["default_route_ip"] = netCfg.DefaultRouteIP
 	result["gateway_ip"] = netCfg.DefaultRouteIP // legacy ...
@@ -399,6 +411,14 @@ var clusterCreateCommand = cli.Command{
 			Name:  "node-sizing",
 			Usage: `Describe node sizing in format "<component><operator><value>[,...]" (cf. --sizing for details)`,
 		},
+		cli.StringFlag{
+			Name:  "node-userdata-file",
+			Usage: "File containing a cloud-config document (starting with '#cloud-config') or a script (starting with '#!') applied once each node is installed",
+		},
+		cli.StringFlag{
+			Name:  "node-post-install-script",
+			Usage: "File containing a script run once each node is installed, after the content of --node-userdata-file",
+		},
 		cli.UintFlag{
 			Name:  "cpu",
 			Usage: "DEPRECATED! use --sizing and friends instead! Defines the number of cpu of masters and nodes in the cluster",
@@ -411,6 +431,14 @@ var clusterCreateCommand = cli.Command{
 			Name:  "disk",
 			Usage: "DEPRECATED! use --sizing and friends instead! Defines the size of system disk of masters and nodes (in GB)",
 		},
+		cli.StringFlag{
+			Name:  "ttl",
+			Usage: "Time to live of the cluster (ex: 8h, 7d); once expired, the cluster is stopped then deleted by safescaled",
+		},
+		cli.StringFlag{
+			Name:  "expires-at",
+			Usage: "Date of expiration of the cluster, in RFC3339 format (ex: 2020-03-31T18:00:00+02:00)",
+		},
 	},
 
 	Action: func(c *cli.Context) (err error) {
@@ -420,6 +448,11 @@ var clusterCreateCommand = cli.Command{
 			return clitools.FailureResponse(err)
 		}
 
+		expiresAt, err := parseExpiration(c)
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption(err.Error()))
+		}
+
 		complexityStr := c.String("complexity")
 		clusterComplexity, err := complexity.Parse(complexityStr)
 		if err != nil {
@@ -505,6 +538,22 @@ var clusterCreateCommand = cli.Command{
 				mastersDef = gatewaysDef         // ... nor for masters
 			}
 		}
+		if c.IsSet("node-userdata-file") || c.IsSet("node-post-install-script") {
+			if nodesDef == nil {
+				nodesDef, err = constructPBHostDefinitionFromCLI(c, "node-sizing")
+				if err != nil {
+					return err
+				}
+			} else if nodesDef == gatewaysDef || nodesDef == mastersDef {
+				// the customization applies only to nodes
+				def := *nodesDef
+				nodesDef = &def
+			}
+			err = readHostCustomization(c, nodesDef, "node-userdata-file", "node-post-install-script")
+			if err != nil {
+				return err
+			}
+		}
 		clusterInstance, err := cluster.Create(concurrency.RootTask(), control.Request{
 			Name:                    clusterName,
 			Complexity:              clusterComplexity,
@@ -515,6 +564,7 @@ var clusterCreateCommand = cli.Command{
 			MastersDef:              mastersDef,
 			NodesDef:                nodesDef,
 			DisabledDefaultFeatures: disableFeatures,
+			ExpiresAt:               expiresAt,
 		})
 		if err != nil {
 			if clusterInstance != nil {
@@ -623,6 +673,137 @@ var clusterStopCommand = cli.Command{
 	},
 }
 
+var clusterExtendCommand = cli.Command{
+	Name:      "extend",
+	Usage:     "extend CLUSTERNAME",
+	ArgsUsage: "CLUSTERNAME",
+
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "ttl",
+			Usage: "New time to live of the cluster, starting now (ex: 8h, 7d)",
+		},
+		cli.StringFlag{
+			Name:  "expires-at",
+			Usage: "New date of expiration of the cluster, in RFC3339 format (ex: 2020-03-31T18:00:00+02:00)",
+		},
+		cli.BoolFlag{
+			Name:  "never",
+			Usage: "Removes the expiration of the cluster",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", clusterCommandName, c.Command.Name, c.Args())
+		err := extractClusterArgument(c)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+		expiresAt, err := parseExpiration(c)
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption(err.Error()))
+		}
+		if expiresAt.IsZero() != c.Bool("never") {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption("exactly one of --ttl, --expires-at or --never is required"))
+		}
+		err = clusterInstance.Extend(concurrency.RootTask(), expiresAt)
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
+var clusterRotateKeysCommand = cli.Command{
+	Name:      "rotate-keys",
+	Usage:     "replaces the keypairs used to connect to the hosts of the cluster by new ones",
+	ArgsUsage: "CLUSTERNAME",
+
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", clusterCommandName, c.Command.Name, c.Args())
+		err := extractClusterArgument(c)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+		err = clusterInstance.RotateKeys(concurrency.RootTask())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
+var clusterUserCommand = cli.Command{
+	Name:      "user",
+	Usage:     "manage the personal accounts created on all the hosts of a cluster",
+	ArgsUsage: "COMMAND",
+
+	Subcommands: []cli.Command{
+		clusterUserAddCommand,
+		clusterUserRemoveCommand,
+	},
+}
+
+var clusterUserAddCommand = cli.Command{
+	Name:      "add",
+	Usage:     "creates on all the hosts of the cluster, including the nodes added later, a personal account authorized to log in with its own SSH keys",
+	ArgsUsage: "CLUSTERNAME USERNAME",
+
+	Flags: []cli.Flag{
+		pubkeyFlag,
+		cli.BoolFlag{
+			Name:  "sudo",
+			Usage: "Allows the user to use sudo",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", clusterCommandName, c.Command.Name, c.Args())
+		err := extractClusterArgument(c)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+		if c.NArg() != 2 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument USERNAME."))
+		}
+		keys, err := readPublicKeys(c)
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption(err.Error()))
+		}
+		err = clusterInstance.AddUser(concurrency.RootTask(), c.Args().Get(1), keys, c.Bool("sudo"))
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
+var clusterUserRemoveCommand = cli.Command{
+	Name:      "remove",
+	Aliases:   []string{"rm", "delete"},
+	Usage:     "deletes from all the hosts of the cluster a personal account previously added",
+	ArgsUsage: "CLUSTERNAME USERNAME",
+
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", clusterCommandName, c.Command.Name, c.Args())
+		err := extractClusterArgument(c)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+		if c.NArg() != 2 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument USERNAME."))
+		}
+		err = clusterInstance.RemoveUser(concurrency.RootTask(), c.Args().Get(1))
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
 var clusterStartCommand = cli.Command{
 	Name:      "start",
 	Aliases:   []string{"unfreeze"},
@@ -745,6 +926,14 @@ var clusterExpandCommand = cli.Command{
 	<operator> can be =,<,> (except for disk where valid operators are only = or >)
 	<value> can be an integer (for cpu and disk) or a float (for ram) or an including interval "[<lower value>-<upper value>]"`,
 		},
+		cli.StringFlag{
+			Name:  "node-userdata-file",
+			Usage: "File containing a cloud-config document (starting with '#cloud-config') or a script (starting with '#!') applied once each node is installed",
+		},
+		cli.StringFlag{
+			Name:  "node-post-install-script",
+			Usage: "File containing a script run once each node is installed, after the content of --node-userdata-file",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", clusterCommandName, c.Command.Name, c.Args())
@@ -789,6 +978,12 @@ var clusterExpandCommand = cli.Command{
 				}
 			}
 		}
+		if nodesDef != nil {
+			err = readHostCustomization(c, nodesDef, "node-userdata-file", "node-post-install-script")
+			if err != nil {
+				return err
+			}
+		}
 
 		hosts, err := clusterInstance.AddNodes(concurrency.RootTask(), count, nodesDef)
 		if err != nil {
@@ -1158,12 +1353,12 @@ func executeCommand(command string, files *RemoteFilesHandler, outs outputs.Enum
 	return nil
 }
 
-// clusterCheckFeaturesCommand handles 'safescale cluster <cluster name or id> list-features'
+// clusterListFeaturesCommand handles 'safescale cluster list-features [CLUSTERNAME]'
 var clusterListFeaturesCommand = cli.Command{
 	Name:      "list-features",
 	Aliases:   []string{"list-available-features"},
-	Usage:     "list-features",
-	ArgsUsage: "",
+	Usage:     "list-features [CLUSTERNAME]",
+	ArgsUsage: "[CLUSTERNAME]",
 
 	Flags: []cli.Flag{
 		cli.StringSliceFlag{
@@ -1174,6 +1369,23 @@ var clusterListFeaturesCommand = cli.Command{
 
 	Action: func(c *cli.Context) error {
 		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", clusterCommandName, c.Command.Name, c.Args())
+		// With a cluster, lists the features installed on it, telling which ones are outdated
+		if c.NArg() > 0 {
+			err := extractClusterArgument(c)
+			if err != nil {
+				return clitools.FailureResponse(err)
+			}
+			target, err := install.NewClusterTarget(concurrency.RootTask(), clusterInstance)
+			if err != nil {
+				return clitools.FailureResponse(err)
+			}
+			installed, err := install.ListInstalledFeatures(concurrency.RootTask(), target)
+			if err != nil {
+				return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
+			}
+			return clitools.SuccessResponse(installed)
+		}
+
 		features, err := install.ListFeatures("cluster")
 		if err != nil {
 			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
@@ -1182,6 +1394,50 @@ var clusterListFeaturesCommand = cli.Command{
 	},
 }
 
+// clusterFeaturesStatusCommand handles 'safescale cluster features-status CLUSTERNAME'
+var clusterFeaturesStatusCommand = cli.Command{
+	Name:      "features-status",
+	Usage:     "features-status CLUSTERNAME",
+	ArgsUsage: "CLUSTERNAME",
+
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "check",
+			Usage: "Checks the installed features now, instead of displaying the results of the last periodic check",
+		},
+		cli.BoolFlag{
+			Name:  "remediate",
+			Usage: "With --check, adds again the features whose check fails",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", clusterCommandName, c.Command.Name, c.Args())
+		err := extractClusterArgument(c)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+		if c.Bool("remediate") && !c.Bool("check") {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption("--remediate can only be used with --check"))
+		}
+		target, err := install.NewClusterTarget(concurrency.RootTask(), clusterInstance)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+
+		var statuses []*install.FeatureStatus
+		if c.Bool("check") {
+			statuses, err = install.CheckInstalledFeatures(concurrency.RootTask(), target, c.Bool("remediate"))
+		} else {
+			statuses, err = install.ListFeaturesStatus(concurrency.RootTask(), target)
+		}
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
+		}
+		return clitools.SuccessResponse(statuses)
+	},
+}
+
 // clusterAddFeatureCommand handles 'deploy cluster add-feature CLUSTERNAME FEATURENAME'
 var clusterAddFeatureCommand = cli.Command{
 	Name:      "add-feature",
@@ -1198,6 +1454,10 @@ var clusterAddFeatureCommand = cli.Command{
 			Name:  "skip-proxy",
 			Usage: "Disables reverse proxy rules",
 		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Shows the scripts that would be run on each host, in order, without running them",
+		},
 	},
 
 	Action: func(c *cli.Context) error {
@@ -1231,6 +1491,9 @@ var clusterAddFeatureCommand = cli.Command{
 
 		settings := install.Settings{}
 		settings.SkipProxy = c.Bool("skip-proxy")
+		if c.Bool("dry-run") {
+			settings.DryRun = install.NewDryRunReport()
+		}
 
 		target, err := install.NewClusterTarget(concurrency.RootTask(), clusterInstance)
 		if err != nil {
@@ -1248,6 +1511,93 @@ var clusterAddFeatureCommand = cli.Command{
 			}
 			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, msg))
 		}
+		if settings.DryRun != nil {
+			return clitools.SuccessResponse(settings.DryRun)
+		}
+		if outputs := strings.TrimSpace(results.AllOutputs()); outputs != "" {
+			return clitools.SuccessResponse(fmt.Sprintf("Feature '%s' added on cluster '%s': %s", featureName, clusterName, outputs))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
+// clusterUpgradeFeatureCommand handles 'safescale cluster upgrade-feature CLUSTERNAME FEATURENAME'
+var clusterUpgradeFeatureCommand = cli.Command{
+	Name:      "upgrade-feature",
+	Usage:     "upgrade-feature CLUSTERNAME FEATURENAME",
+	ArgsUsage: "CLUSTERNAME FEATURENAME",
+
+	Flags: []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "param, p",
+			Usage: "Allow to define content of feature parameters",
+		},
+		cli.BoolFlag{
+			Name:  "skip-proxy",
+			Usage: "Disables reverse proxy rules",
+		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Shows the scripts that would be run on each host, in order, without running them",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", clusterCommandName, c.Command.Name, c.Args())
+		err := extractClusterArgument(c)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+		err = extractFeatureArgument(c)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+
+		feature, err := install.NewFeature(concurrency.RootTask(), featureName)
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
+		}
+
+		values := install.Variables{}
+		params := c.StringSlice("param")
+		for _, k := range params {
+			res := strings.Split(k, "=")
+			if len(res[0]) > 0 {
+				values[res[0]] = strings.Join(res[1:], "=")
+			}
+		}
+
+		settings := install.Settings{}
+		settings.SkipProxy = c.Bool("skip-proxy")
+		if c.Bool("dry-run") {
+			settings.DryRun = install.NewDryRunReport()
+		}
+
+		target, err := install.NewClusterTarget(concurrency.RootTask(), clusterInstance)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+		results, err := feature.Upgrade(target, values, settings)
+		if err != nil {
+			msg := fmt.Sprintf("error upgrading feature '%s' on cluster '%s': %s\n", featureName, clusterName, err.Error())
+			if _, ok := err.(scerr.ErrNotFound); ok {
+				return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.NotFound, msg))
+			}
+			return clitools.FailureResponse(clitools.ExitOnRPC(msg))
+		}
+		if !results.Successful() {
+			msg := fmt.Sprintf("failed to upgrade feature '%s' on cluster '%s'", featureName, clusterName)
+			if Debug || Verbose {
+				msg += fmt.Sprintf(":\n%s", results.AllErrorMessages())
+			}
+			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, msg))
+		}
+		if settings.DryRun != nil {
+			return clitools.SuccessResponse(settings.DryRun)
+		}
+		if outputs := strings.TrimSpace(results.AllOutputs()); outputs != "" {
+			return clitools.SuccessResponse(fmt.Sprintf("Feature '%s' upgraded on cluster '%s': %s", featureName, clusterName, outputs))
+		}
 		return clitools.SuccessResponse(nil)
 	},
 }
@@ -1313,6 +1663,9 @@ var clusterCheckFeatureCommand = cli.Command{
 			return clitools.FailureResponse(clitools.ExitOnNotFound(msg))
 		}
 		msg := fmt.Sprintf("Feature '%s' found on cluster '%s'", featureName, clusterName)
+		if outputs := strings.TrimSpace(results.AllOutputs()); outputs != "" {
+			msg += fmt.Sprintf(": %s", outputs)
+		}
 		return clitools.SuccessResponse(msg)
 	},
 }
@@ -1328,6 +1681,10 @@ var clusterDeleteFeatureCommand = cli.Command{
 			Name:  "param, p",
 			Usage: "Allow to define content of feature parameters",
 		},
+		cli.BoolFlag{
+			Name:  "cascade",
+			Usage: "Removes first the installed features requiring the feature",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", clusterCommandName, c.Command.Name, c.Args())
@@ -1361,6 +1718,7 @@ var clusterDeleteFeatureCommand = cli.Command{
 		// TODO: Reverse proxy rules are not yet purged when feature is removed, but current code
 		// will try to apply them... Quick fix: Setting SkipProxy to true prevent this
 		settings.SkipProxy = true
+		settings.RemoveDependents = c.Bool("cascade")
 
 		target, err := install.NewClusterTarget(concurrency.RootTask(), clusterInstance)
 		if err != nil {
@@ -1368,6 +1726,10 @@ var clusterDeleteFeatureCommand = cli.Command{
 		}
 		results, err := feature.Remove(target, values, settings)
 		if err != nil {
+			if _, ok := err.(scerr.ErrInvalidRequest); ok {
+				msg := fmt.Sprintf("%s; use --cascade to remove them too", err.Error())
+				return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.NotApplicable, msg))
+			}
 			msg := fmt.Sprintf("error uninstalling feature '%s' on '%s': %s\n", featureName, clusterName, err.Error())
 			return clitools.FailureResponse(clitools.ExitOnRPC(msg))
 		}
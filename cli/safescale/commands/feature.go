@@ -0,0 +1,174 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+
+	"github.com/CS-SI/SafeScale/lib/server/install"
+	"github.com/CS-SI/SafeScale/lib/utils"
+	clitools "github.com/CS-SI/SafeScale/lib/utils/cli"
+	"github.com/CS-SI/SafeScale/lib/utils/cli/enums/exitcode"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+)
+
+var featureCmdName = "feature"
+
+// FeatureCmd command
+var FeatureCmd = cli.Command{
+	Name:  "feature",
+	Usage: "feature COMMAND",
+	Subcommands: []cli.Command{
+		featureValidate,
+		featureSearch,
+		featurePull,
+	},
+}
+
+// featureValidation contains the result of the validation of a feature
+type featureValidation struct {
+	Feature  string   `json:"feature"`
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+var featureValidate = cli.Command{
+	Name:      "validate",
+	Aliases:   []string{"lint"},
+	Usage:     "Validate the specification of features, without installing anything",
+	ArgsUsage: "FILE|FEATURENAME [FILE|FEATURENAME...]",
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", featureCmdName, c.Command.Name, c.Args())
+		if c.NArg() == 0 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument FILE or FEATURENAME."))
+		}
+
+		var (
+			results []featureValidation
+			invalid []string
+		)
+		for _, ref := range c.Args() {
+			var (
+				feature *install.Feature
+				err     error
+			)
+			// A reference to an existing file is a specification file, otherwise a feature name
+			if _, statErr := os.Stat(ref); statErr == nil {
+				feature, err = install.NewFeatureFromFile(concurrency.RootTask(), ref)
+			} else {
+				feature, err = install.NewFeature(concurrency.RootTask(), ref)
+			}
+			result := featureValidation{Feature: ref}
+			if err != nil {
+				result.Errors = []string{err.Error()}
+			} else {
+				warnings, errs := feature.Validate()
+				result.Warnings = warnings
+				for _, e := range errs {
+					result.Errors = append(result.Errors, e.Error())
+				}
+			}
+			result.Valid = len(result.Errors) == 0
+			if !result.Valid {
+				invalid = append(invalid, ref)
+			}
+			results = append(results, result)
+		}
+
+		if len(invalid) > 0 {
+			for _, r := range results {
+				for _, e := range r.Errors {
+					_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", r.Feature, e)
+				}
+			}
+			msg := fmt.Sprintf("invalid feature specification%s: %s", utils.Plural(len(invalid)), strings.Join(invalid, ", "))
+			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.InvalidArgument, msg))
+		}
+		return clitools.SuccessResponse(results)
+	},
+}
+
+var featureSearch = cli.Command{
+	Name:      "search",
+	Usage:     "Search features in the sources declared in feature-sources.yml",
+	ArgsUsage: "[TERM]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "source",
+			Usage: "Searches only in this source",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", featureCmdName, c.Command.Name, c.Args())
+		features, err := install.SearchFeatures(concurrency.RootTask(), c.Args().First(), c.String("source"))
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
+		}
+		return clitools.SuccessResponse(features)
+	},
+}
+
+var featurePull = cli.Command{
+	Name:      "pull",
+	Usage:     "Download a feature from the sources, to use it instead of the embedded or local one",
+	ArgsUsage: "FEATURENAME[@VERSION]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "source",
+			Usage: "Pulls only from this source",
+		},
+		cli.BoolFlag{
+			Name:  "force, f",
+			Usage: "Overwrites the specification file already present",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", featureCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 1 || c.Args().First() == "" {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument FEATURENAME."))
+		}
+		name, version := c.Args().First(), ""
+		if i := strings.Index(name, "@"); i >= 0 {
+			name, version = name[:i], name[i+1:]
+		}
+
+		feature, path, err := install.PullFeature(concurrency.RootTask(), name, version, c.String("source"), c.Bool("force"))
+		if err != nil {
+			switch err.(type) {
+			case scerr.ErrNotFound:
+				return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.NotFound, err.Error()))
+			case scerr.ErrDuplicate:
+				return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Duplicate, err.Error()+", use --force to overwrite it"))
+			default:
+				return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
+			}
+		}
+		return clitools.SuccessResponse(struct {
+			*install.RemoteFeature
+			Path string `json:"path"`
+		}{feature, path})
+	},
+}
@@ -19,8 +19,10 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -33,6 +35,7 @@ import (
 	clitools "github.com/CS-SI/SafeScale/lib/utils/cli"
 	"github.com/CS-SI/SafeScale/lib/utils/cli/enums/exitcode"
 	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
 	"github.com/CS-SI/SafeScale/lib/utils/temporal"
 )
 
@@ -53,10 +56,16 @@ var HostCmd = cli.Command{
 		hostReboot,
 		hostStart,
 		hostStop,
+		hostExtend,
+		hostImport,
+		hostUserCommand,
+		hostRotateKey,
 		hostCheckFeatureCommand,
 		hostAddFeatureCommand,
+		hostUpgradeFeatureCommand,
 		hostDeleteFeatureCommand,
 		hostListFeaturesCommand,
+		hostFeaturesStatusCommand,
 	},
 }
 
@@ -191,10 +200,13 @@ var hostCreate = cli.Command{
 	Usage:     "create a new host",
 	ArgsUsage: "<Host_name>",
 	Flags: []cli.Flag{
-		cli.StringFlag{
+		cli.StringSliceFlag{
 			Name:  "net,network",
-			Value: "",
-			Usage: "network name or network id",
+			Usage: "network name or network id; may be used several times to attach the host to several networks",
+		},
+		cli.StringFlag{
+			Name:  "default-net",
+			Usage: "network providing the default route, among the ones of --net (default: the first one)",
 		},
 		cli.StringFlag{
 			Name:  "os",
@@ -254,6 +266,22 @@ var hostCreate = cli.Command{
 			Name:  "disk",
 			Usage: "DEPRECATED! uses --sizing! Defines the size of system disk of masters and nodes (in GB)",
 		},
+		cli.StringFlag{
+			Name:  "ttl",
+			Usage: "Time to live of the host (ex: 8h, 7d); once expired, the host is stopped then deleted by safescaled",
+		},
+		cli.StringFlag{
+			Name:  "expires-at",
+			Usage: "Date of expiration of the host, in RFC3339 format (ex: 2020-03-31T18:00:00+02:00)",
+		},
+		cli.StringFlag{
+			Name:  "userdata-file",
+			Usage: "File containing a cloud-config document (starting with '#cloud-config') or a script (starting with '#!') applied once the host is installed",
+		},
+		cli.StringFlag{
+			Name:  "post-install-script",
+			Usage: "File containing a script run once the host is installed, after the content of --userdata-file",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", hostCmdName, c.Command.Name, c.Args())
@@ -261,6 +289,10 @@ var hostCreate = cli.Command{
 			_ = cli.ShowSubcommandHelp(c)
 			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Host_name>."))
 		}
+		expiresAt, err := parseExpiration(c)
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument(err.Error()))
+		}
 		askedGpus := int32(c.Int("gpu"))
 		if askedGpus <= -1 {
 			logrus.Debug("No GPU parameters used")
@@ -272,10 +304,26 @@ var hostCreate = cli.Command{
 			}
 		}
 
+		nets, err := orderHostNetworks(c.StringSlice("net"), c.String("default-net"))
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption(err.Error()))
+		}
+
 		def, err := constructPBHostDefinitionFromCLI(c, "sizing")
 		if err != nil {
 			return err
 		}
+		if len(nets) > 0 {
+			def.Network = nets[0]
+			def.Networks = nets[1:]
+		}
+		if !expiresAt.IsZero() {
+			def.ExpiresAt = expiresAt.Format(time.RFC3339)
+		}
+		err = readHostCustomization(c, def, "userdata-file", "post-install-script")
+		if err != nil {
+			return err
+		}
 		resp, err := client.New().Host.Create(*def, temporal.GetExecutionTimeout())
 		if err != nil {
 			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "creation of host", true).Error())))
@@ -284,6 +332,222 @@ var hostCreate = cli.Command{
 	},
 }
 
+var hostExtend = cli.Command{
+	Name:      "extend",
+	Usage:     "changes the expiration date of a host",
+	ArgsUsage: "<Host_name|Host_ID>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "ttl",
+			Usage: "New time to live of the host, starting now (ex: 8h, 7d)",
+		},
+		cli.StringFlag{
+			Name:  "expires-at",
+			Usage: "New date of expiration of the host, in RFC3339 format (ex: 2020-03-31T18:00:00+02:00)",
+		},
+		cli.BoolFlag{
+			Name:  "never",
+			Usage: "Removes the expiration of the host",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", hostCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 1 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Host_name>."))
+		}
+		expiresAt, err := parseExpiration(c)
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument(err.Error()))
+		}
+		if expiresAt.IsZero() != c.Bool("never") {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Exactly one of --ttl, --expires-at or --never is required."))
+		}
+
+		resp, err := client.New().Host.Extend(c.Args().First(), expiresAt, temporal.GetExecutionTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "extension of host", false).Error())))
+		}
+		return clitools.SuccessResponse(resp)
+	},
+}
+
+var hostImport = cli.Command{
+	Name:      "import",
+	Usage:     "registers in SafeScale a host created outside of it",
+	ArgsUsage: "<Provider_host_ID>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "Path of the private key file used to connect to the host (mandatory)",
+		},
+		cli.StringFlag{
+			Name:  "user",
+			Usage: "Existing account the key gives access to (ubuntu, centos, root...), used to create the operator account of SafeScale on the host",
+		},
+		cli.StringFlag{
+			Name:  "net",
+			Usage: "Name or ID of the default network of the host, if it is connected to several networks known by SafeScale",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", hostCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 1 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Provider_host_ID>."))
+		}
+		privateKey, err := readPrivateKey(c)
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption(err.Error()))
+		}
+
+		req := &pb.HostImportRequest{
+			Id:         c.Args().First(),
+			PrivateKey: privateKey,
+			User:       c.String("user"),
+			Network:    c.String("net"),
+		}
+		resp, err := client.New().Host.Import(req, temporal.GetLongOperationTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "import of host", false).Error())))
+		}
+		return clitools.SuccessResponse(resp)
+	},
+}
+
+var hostRotateKey = cli.Command{
+	Name:      "rotate-key",
+	Usage:     "replaces the keypair used to connect to the host by a new one",
+	ArgsUsage: "<Host_name|Host_ID>",
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", hostCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 1 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Host_name>."))
+		}
+		resp, err := client.New().Host.RotateKey(c.Args().First(), temporal.GetExecutionTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "rotation of key", false).Error())))
+		}
+		return clitools.SuccessResponse(resp)
+	},
+}
+
+var hostUserCommand = cli.Command{
+	Name:      "user",
+	Usage:     "manage the personal accounts of a host",
+	ArgsUsage: "COMMAND",
+
+	Subcommands: []cli.Command{
+		hostUserAddCommand,
+		hostUserRemoveCommand,
+		hostUserListCommand,
+	},
+}
+
+// pubkeyFlag is the option giving the files of the SSH public keys of a user
+var pubkeyFlag = cli.StringSliceFlag{
+	Name:  "pubkey",
+	Usage: "File containing SSH public key(s) of the user, in authorized_keys format (mandatory; may be used several times)",
+}
+
+// readPublicKeys reads the SSH public keys from the files given by --pubkey, one key per line
+func readPublicKeys(c *cli.Context) ([]string, error) {
+	var keys []string
+	for _, path := range c.StringSlice("pubkey") {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key file '%s': %s", path, err.Error())
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				keys = append(keys, line)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one public key is required (--pubkey)")
+	}
+	return keys, nil
+}
+
+var hostUserAddCommand = cli.Command{
+	Name:      "add",
+	Usage:     "creates on the host a personal account authorized to log in with its own SSH keys; updates the keys and sudo permission of an account already added",
+	ArgsUsage: "<Host_name|Host_ID> <User_name>",
+	Flags: []cli.Flag{
+		pubkeyFlag,
+		cli.BoolFlag{
+			Name:  "sudo",
+			Usage: "Allows the user to use sudo",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", hostCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 2 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Host_name> or <User_name>."))
+		}
+		keys, err := readPublicKeys(c)
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption(err.Error()))
+		}
+
+		req := &pb.HostUserRequest{
+			Host: &pb.Reference{Name: c.Args().Get(0)},
+			User: &pb.HostUser{
+				Name:       c.Args().Get(1),
+				PublicKeys: keys,
+				Sudo:       c.Bool("sudo"),
+			},
+		}
+		err = client.New().Host.AddUser(req, temporal.GetExecutionTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "addition of user", false).Error())))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
+var hostUserRemoveCommand = cli.Command{
+	Name:      "remove",
+	Aliases:   []string{"rm", "delete"},
+	Usage:     "deletes from the host a personal account previously added, with its home directory",
+	ArgsUsage: "<Host_name|Host_ID> <User_name>",
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", hostCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 2 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Host_name> or <User_name>."))
+		}
+		err := client.New().Host.RemoveUser(c.Args().Get(0), c.Args().Get(1), temporal.GetExecutionTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "removal of user", false).Error())))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
+var hostUserListCommand = cli.Command{
+	Name:      "list",
+	Aliases:   []string{"ls"},
+	Usage:     "lists the personal accounts added on the host",
+	ArgsUsage: "<Host_name|Host_ID>",
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", hostCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 1 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Host_name>."))
+		}
+		resp, err := client.New().Host.ListUsers(c.Args().First(), temporal.GetExecutionTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "list of users", false).Error())))
+		}
+		return clitools.SuccessResponse(resp.GetUsers())
+	},
+}
+
 var hostResize = cli.Command{
 	Name:      "resize",
 	Aliases:   []string{"upgrade"},
@@ -401,6 +665,10 @@ var hostAddFeatureCommand = cli.Command{
 			Name:  "skip-proxy",
 			Usage: "Disable reverse proxy rules",
 		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Shows the scripts that would be run on each host, in order, without running them",
+		},
 	},
 
 	Action: func(c *cli.Context) error {
@@ -434,6 +702,9 @@ var hostAddFeatureCommand = cli.Command{
 
 		settings := install.Settings{}
 		settings.SkipProxy = c.Bool("skip-proxy")
+		if c.Bool("dry-run") {
+			settings.DryRun = install.NewDryRunReport()
+		}
 
 		// Wait for SSH service on remote host first
 		err = client.New().SSH.WaitReady(hostInstance.Id, temporal.GetConnectionTimeout())
@@ -458,16 +729,110 @@ var hostAddFeatureCommand = cli.Command{
 			}
 			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, msg))
 		}
+		if settings.DryRun != nil {
+			return clitools.SuccessResponse(settings.DryRun)
+		}
+		if outputs := strings.TrimSpace(results.AllOutputs()); outputs != "" {
+			return clitools.SuccessResponse(fmt.Sprintf("Feature '%s' added on host '%s': %s", featureName, hostName, outputs))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
+// hostUpgradeFeatureCommand handles 'safescale host upgrade-feature HOSTNAME FEATURENAME'
+var hostUpgradeFeatureCommand = cli.Command{
+	Name:      "upgrade-feature",
+	Usage:     "upgrade-feature HOSTNAME FEATURENAME",
+	ArgsUsage: "HOSTNAME FEATURENAME",
+
+	Flags: []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "param, p",
+			Usage: "Allow to define content of feature parameters",
+		},
+		cli.BoolFlag{
+			Name:  "skip-proxy",
+			Usage: "Disable reverse proxy rules",
+		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Shows the scripts that would be run on each host, in order, without running them",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", hostCmdName, c.Command.Name, c.Args())
+		err := extractHostArgument(c, 0)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+
+		err = extractFeatureArgument(c)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+
+		feature, err := install.NewFeature(concurrency.RootTask(), featureName)
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
+		}
+		values := install.Variables{}
+		params := c.StringSlice("param")
+		for _, k := range params {
+			res := strings.Split(k, "=")
+			if len(res[0]) > 0 {
+				values[res[0]] = strings.Join(res[1:], "=")
+			}
+		}
+
+		settings := install.Settings{}
+		settings.SkipProxy = c.Bool("skip-proxy")
+		if c.Bool("dry-run") {
+			settings.DryRun = install.NewDryRunReport()
+		}
+
+		// Wait for SSH service on remote host first
+		err = client.New().SSH.WaitReady(hostInstance.Id, temporal.GetConnectionTimeout())
+		if err != nil {
+			msg := fmt.Sprintf("failed to reach '%s': %s", hostName, client.DecorateError(err, "waiting ssh on host", false))
+			return clitools.FailureResponse(clitools.ExitOnRPC(msg))
+		}
+
+		target, err := install.NewHostTarget(hostInstance)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+		results, err := feature.Upgrade(target, values, settings)
+		if err != nil {
+			msg := fmt.Sprintf("error upgrading feature '%s' on host '%s': %s", featureName, hostName, err.Error())
+			if _, ok := err.(scerr.ErrNotFound); ok {
+				return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.NotFound, msg))
+			}
+			return clitools.FailureResponse(clitools.ExitOnRPC(msg))
+		}
+		if !results.Successful() {
+			msg := fmt.Sprintf("failed to upgrade feature '%s' on host '%s'", featureName, hostName)
+			if Debug || Verbose {
+				msg += fmt.Sprintf(":\n%s", results.AllErrorMessages())
+			}
+			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, msg))
+		}
+		if settings.DryRun != nil {
+			return clitools.SuccessResponse(settings.DryRun)
+		}
+		if outputs := strings.TrimSpace(results.AllOutputs()); outputs != "" {
+			return clitools.SuccessResponse(fmt.Sprintf("Feature '%s' upgraded on host '%s': %s", featureName, hostName, outputs))
+		}
 		return clitools.SuccessResponse(nil)
 	},
 }
 
-// hostListFeaturesCommand handles 'safescale host list-features'
+// hostListFeaturesCommand handles 'safescale host list-features [HOSTNAME]'
 var hostListFeaturesCommand = cli.Command{
 	Name:      "list-features",
 	Aliases:   []string{"list-available-features"},
-	Usage:     "list-features",
-	ArgsUsage: "",
+	Usage:     "list-features [HOSTNAME]",
+	ArgsUsage: "[HOSTNAME]",
 
 	Flags: []cli.Flag{
 		cli.StringSliceFlag{
@@ -478,6 +843,23 @@ var hostListFeaturesCommand = cli.Command{
 
 	Action: func(c *cli.Context) error {
 		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", hostCmdName, c.Command.Name, c.Args())
+		// With a host, lists the features installed on it, telling which ones are outdated
+		if c.NArg() > 0 {
+			err := extractHostArgument(c, 0)
+			if err != nil {
+				return clitools.FailureResponse(err)
+			}
+			target, err := install.NewHostTarget(hostInstance)
+			if err != nil {
+				return clitools.FailureResponse(err)
+			}
+			installed, err := install.ListInstalledFeatures(concurrency.RootTask(), target)
+			if err != nil {
+				return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
+			}
+			return clitools.SuccessResponse(installed)
+		}
+
 		features, err := install.ListFeatures("host")
 		if err != nil {
 			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
@@ -486,6 +868,50 @@ var hostListFeaturesCommand = cli.Command{
 	},
 }
 
+// hostFeaturesStatusCommand handles 'safescale host features-status HOSTNAME'
+var hostFeaturesStatusCommand = cli.Command{
+	Name:      "features-status",
+	Usage:     "features-status HOSTNAME",
+	ArgsUsage: "HOSTNAME",
+
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "check",
+			Usage: "Checks the installed features now, instead of displaying the results of the last periodic check",
+		},
+		cli.BoolFlag{
+			Name:  "remediate",
+			Usage: "With --check, adds again the features whose check fails",
+		},
+	},
+
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", hostCmdName, c.Command.Name, c.Args())
+		err := extractHostArgument(c, 0)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+		if c.Bool("remediate") && !c.Bool("check") {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption("--remediate can only be used with --check"))
+		}
+		target, err := install.NewHostTarget(hostInstance)
+		if err != nil {
+			return clitools.FailureResponse(err)
+		}
+
+		var statuses []*install.FeatureStatus
+		if c.Bool("check") {
+			statuses, err = install.CheckInstalledFeatures(concurrency.RootTask(), target, c.Bool("remediate"))
+		} else {
+			statuses, err = install.ListFeaturesStatus(concurrency.RootTask(), target)
+		}
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.Run, err.Error()))
+		}
+		return clitools.SuccessResponse(statuses)
+	},
+}
+
 // hostCheckFeatureCommand handles 'deploy host <host name or id> package <pkgname> check'
 var hostCheckFeatureCommand = cli.Command{
 	Name:      "check-feature",
@@ -569,6 +995,10 @@ var hostDeleteFeatureCommand = cli.Command{
 			Name:  "param, p",
 			Usage: "Define value of feature parameter (can be used multiple times)",
 		},
+		cli.BoolFlag{
+			Name:  "cascade",
+			Usage: "Removes first the installed features requiring the feature",
+		},
 	},
 
 	Action: func(c *cli.Context) error {
@@ -612,8 +1042,15 @@ var hostDeleteFeatureCommand = cli.Command{
 		if err != nil {
 			return clitools.FailureResponse(err)
 		}
-		results, err := feature.Remove(target, values, install.Settings{})
+		settings := install.Settings{}
+		settings.RemoveDependents = c.Bool("cascade")
+
+		results, err := feature.Remove(target, values, settings)
 		if err != nil {
+			if _, ok := err.(scerr.ErrInvalidRequest); ok {
+				msg := fmt.Sprintf("%s; use --cascade to remove them too", err.Error())
+				return clitools.FailureResponse(clitools.ExitOnErrorWithMessage(exitcode.NotApplicable, msg))
+			}
 			msg := fmt.Sprintf("error uninstalling feature '%s' on '%s': %s\n", featureName, hostName, err.Error())
 			return clitools.FailureResponse(clitools.ExitOnRPC(msg))
 		}
@@ -628,6 +1065,46 @@ var hostDeleteFeatureCommand = cli.Command{
 	},
 }
 
+// orderHostNetworks puts first the network providing the default route
+func orderHostNetworks(nets []string, defaultNet string) ([]string, error) {
+	if defaultNet == "" {
+		return nets, nil
+	}
+	ordered := []string{defaultNet}
+	found := false
+	for _, n := range nets {
+		if n == defaultNet {
+			found = true
+			continue
+		}
+		ordered = append(ordered, n)
+	}
+	if !found {
+		return nil, fmt.Errorf("network '%s' of --default-net has to be one of the networks of --net", defaultNet)
+	}
+	return ordered, nil
+}
+
+// readHostCustomization sets in def the content of the user data and post-installation script files
+// given by the options userDataKey and scriptKey
+func readHostCustomization(c *cli.Context, def *pb.HostDefinition, userDataKey, scriptKey string) error {
+	if path := c.String(userDataKey); path != "" {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption(fmt.Sprintf("failed to read user data file '%s': %s", path, err.Error())))
+		}
+		def.UserData = string(content)
+	}
+	if path := c.String(scriptKey); path != "" {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption(fmt.Sprintf("failed to read post-installation script '%s': %s", path, err.Error())))
+		}
+		def.PostInstallScript = string(content)
+	}
+	return nil
+}
+
 // constructPBHostDefinitionFromCLI ...
 func constructPBHostDefinitionFromCLI(c *cli.Context, key string) (*pb.HostDefinition, error) {
 	var sizing string
@@ -661,7 +1138,6 @@ func constructPBHostDefinitionFromCLI(c *cli.Context, key string) (*pb.HostDefin
 	def := pb.HostDefinition{
 		Name:    c.Args().First(),
 		ImageId: c.String("os"),
-		Network: c.String("net"),
 		Public:  c.Bool("public"),
 		Force:   c.Bool("force"),
 		Sizing:  &pb.HostSizing{},
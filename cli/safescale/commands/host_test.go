@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderHostNetworks(t *testing.T) {
+	tests := []struct {
+		nets       []string
+		defaultNet string
+		expected   []string
+		fails      bool
+	}{
+		{nil, "", nil, false},
+		{[]string{"net1"}, "", []string{"net1"}, false},
+		{[]string{"net1", "net2", "net3"}, "", []string{"net1", "net2", "net3"}, false},
+		{[]string{"net1", "net2", "net3"}, "net3", []string{"net3", "net1", "net2"}, false},
+		{[]string{"net1", "net2"}, "net1", []string{"net1", "net2"}, false},
+		{[]string{"net1", "net2"}, "net3", nil, true},
+		{nil, "net1", nil, true},
+	}
+	for _, tt := range tests {
+		nets, err := orderHostNetworks(tt.nets, tt.defaultNet)
+		if tt.fails {
+			assert.NotNil(t, err, "%v %s", tt.nets, tt.defaultNet)
+			continue
+		}
+		assert.Nil(t, err, "%v %s", tt.nets, tt.defaultNet)
+		assert.Equal(t, tt.expected, nets, "%v %s", tt.nets, tt.defaultNet)
+	}
+}
@@ -18,6 +18,7 @@ package commands
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -41,6 +42,12 @@ var NetworkCmd = cli.Command{
 		networkDelete,
 		networkInspect,
 		networkList,
+		networkImport,
+		networkPeer,
+		networkForwardCommand,
+		networkGatewayCommand,
+		networkFailoverCommand,
+		networkRotateKeysCommand,
 	},
 }
 
@@ -167,6 +174,14 @@ var networkCreate = cli.Command{
 			Name:  "failover",
 			Usage: "creates 2 gateways for the network with a VIP used as internal default route",
 		},
+		cli.BoolFlag{
+			Name:  "ipv6",
+			Usage: "creates a dual-stack network, where hosts get an IPv6 address besides the IPv4 one",
+		},
+		cli.StringFlag{
+			Name:  "cidr-v6",
+			Usage: "IPv6 cidr of a dual-stack network, made of unique local addresses (default: chosen by SafeScale or the provider)",
+		},
 		cli.StringFlag{
 			Name: "S, sizing",
 			Usage: `Describe sizing of network gateway in format "<component><operator><value>[,...]" where:
@@ -210,6 +225,10 @@ var networkCreate = cli.Command{
 			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <network_name>."))
 		}
 
+		if c.String("cidr-v6") != "" && !c.Bool("ipv6") {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption("--cidr-v6 requires --ipv6."))
+		}
+
 		def, err := constructPBHostDefinitionFromCLI(c, "sizing")
 		if err != nil {
 			return err
@@ -218,6 +237,8 @@ var networkCreate = cli.Command{
 			Cidr:     c.String("cidr"),
 			Name:     c.Args().Get(0),
 			FailOver: c.Bool("failover"),
+			Ipv6:     c.Bool("ipv6"),
+			CidrV6:   c.String("cidr-v6"),
 			Gateway: &pb.GatewayDefinition{
 				ImageId: c.String("os"),
 				Name:    c.String("gwname"),
@@ -231,3 +252,346 @@ var networkCreate = cli.Command{
 		return clitools.SuccessResponse(network)
 	},
 }
+
+var networkImport = cli.Command{
+	Name:      "import",
+	Usage:     "registers in SafeScale a network created outside of it",
+	ArgsUsage: "<Provider_network_ID>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "gateway",
+			Usage: "ID of the host acting as gateway of the network, imported along with the network",
+		},
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "Path of the private key file used to connect to the gateway (mandatory with --gateway)",
+		},
+		cli.StringFlag{
+			Name:  "user",
+			Usage: "Existing account of the gateway the key gives access to (ubuntu, centos, root...), used to create the operator account of SafeScale on it",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", networkCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 1 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Provider_network_ID>."))
+		}
+
+		req := &pb.NetworkImportRequest{
+			Id:      c.Args().First(),
+			Gateway: c.String("gateway"),
+		}
+		if req.Gateway != "" {
+			privateKey, err := readPrivateKey(c)
+			if err != nil {
+				return clitools.FailureResponse(clitools.ExitOnInvalidOption(err.Error()))
+			}
+			req.PrivateKey = privateKey
+			req.User = c.String("user")
+		}
+		network, err := client.New().Network.Import(req, temporal.GetLongOperationTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "import of network", false).Error())))
+		}
+		return clitools.SuccessResponse(network)
+	},
+}
+
+var networkPeer = cli.Command{
+	Name:      "peer",
+	Usage:     "connects two networks, possibly of different tenants, with a VPN between their gateways",
+	ArgsUsage: "<Network_name>[@<Tenant_name>] <Network_name>[@<Tenant_name>]",
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", networkCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 2 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory arguments <Network_name>[@<Tenant_name>] <Network_name>[@<Tenant_name>]."))
+		}
+
+		network, tenant := splitNetworkTenant(c.Args().Get(0))
+		peerNetwork, peerTenant := splitNetworkTenant(c.Args().Get(1))
+		req := &pb.NetworkPeerRequest{
+			Network:     &pb.Reference{Name: network},
+			Tenant:      tenant,
+			PeerNetwork: &pb.Reference{Name: peerNetwork},
+			PeerTenant:  peerTenant,
+		}
+		err := client.New().Network.Peer(req, temporal.GetLongOperationTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "peering of networks", false).Error())))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
+// networkForwardCommand handles 'safescale network forward ...'
+var networkForwardCommand = cli.Command{
+	Name:      "forward",
+	Usage:     "manage port forwarding from the public IP of the gateway(s) of a network to its hosts",
+	ArgsUsage: "COMMAND",
+
+	Subcommands: []cli.Command{
+		networkForwardAddCommand,
+		networkForwardListCommand,
+		networkForwardDeleteCommand,
+	},
+}
+
+var networkForwardAddCommand = cli.Command{
+	Name:      "add",
+	Usage:     "forwards a public port of the gateway(s) of the network to a port of a host",
+	ArgsUsage: "<Network_name>",
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "public-port",
+			Usage: "Port on the public IP of the gateway(s)",
+		},
+		cli.StringFlag{
+			Name:  "host",
+			Usage: "Name or ID of the host receiving the traffic",
+		},
+		cli.IntFlag{
+			Name:  "port",
+			Usage: "Port on the host (default: the public port)",
+		},
+		cli.StringFlag{
+			Name:  "proto",
+			Value: "tcp",
+			Usage: "Protocol, tcp or udp",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", networkCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 1 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Network_name>."))
+		}
+		if c.Int("public-port") <= 0 {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption("Missing mandatory option --public-port."))
+		}
+		if c.String("host") == "" {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption("Missing mandatory option --host."))
+		}
+		port := c.Int("port")
+		if port <= 0 {
+			port = c.Int("public-port")
+		}
+
+		req := &pb.NetworkForwardRequest{
+			Network: &pb.Reference{Name: c.Args().First()},
+			Forward: &pb.NetworkForward{
+				Protocol:   strings.ToLower(c.String("proto")),
+				PublicPort: int32(c.Int("public-port")),
+				Host:       &pb.Reference{Name: c.String("host")},
+				Port:       int32(port),
+			},
+		}
+		forward, err := client.New().Network.AddForward(req, temporal.GetExecutionTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "addition of forwarding rule", false).Error())))
+		}
+		return clitools.SuccessResponse(forward)
+	},
+}
+
+var networkForwardListCommand = cli.Command{
+	Name:      "list",
+	Aliases:   []string{"ls"},
+	Usage:     "lists the forwarding rules of the network",
+	ArgsUsage: "<Network_name>",
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", networkCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 1 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Network_name>."))
+		}
+
+		list, err := client.New().Network.ListForwards(c.Args().First(), temporal.GetExecutionTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "list of forwarding rules", false).Error())))
+		}
+		return clitools.SuccessResponse(list.GetForwards())
+	},
+}
+
+var networkForwardDeleteCommand = cli.Command{
+	Name:      "delete",
+	Aliases:   []string{"rm", "remove"},
+	Usage:     "removes the forwarding rule of the network using a public port",
+	ArgsUsage: "<Network_name>",
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "public-port",
+			Usage: "Port on the public IP of the gateway(s)",
+		},
+		cli.StringFlag{
+			Name:  "proto",
+			Value: "tcp",
+			Usage: "Protocol, tcp or udp",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", networkCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 1 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Network_name>."))
+		}
+		if c.Int("public-port") <= 0 {
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption("Missing mandatory option --public-port."))
+		}
+
+		req := &pb.NetworkForwardRequest{
+			Network: &pb.Reference{Name: c.Args().First()},
+			Forward: &pb.NetworkForward{
+				Protocol:   strings.ToLower(c.String("proto")),
+				PublicPort: int32(c.Int("public-port")),
+			},
+		}
+		err := client.New().Network.DeleteForward(req, temporal.GetExecutionTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "deletion of forwarding rule", false).Error())))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
+// networkGatewayCommand handles 'safescale network gateway ...'
+var networkGatewayCommand = cli.Command{
+	Name:      "gateway",
+	Usage:     "manage the gateway(s) of a network",
+	ArgsUsage: "COMMAND",
+
+	Subcommands: []cli.Command{
+		networkGatewayReplaceCommand,
+	},
+}
+
+var networkGatewayReplaceCommand = cli.Command{
+	Name:      "replace",
+	Usage:     "replaces the gateway(s) of the network by new ones, one after the other in failover mode",
+	ArgsUsage: "<Network_name>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "S, sizing",
+			Usage: "Describe sizing of the new gateway(s), in the same format as for 'network create' (default: the sizing requested for the current gateway(s))",
+		},
+		cli.StringFlag{
+			Name:  "os",
+			Value: "Ubuntu 18.04",
+			Usage: "Image name for the new gateway(s)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", networkCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 1 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Network_name>."))
+		}
+
+		req := &pb.NetworkGatewayReplaceRequest{
+			Network: &pb.Reference{Name: c.Args().First()},
+			ImageId: c.String("os"),
+		}
+		if c.IsSet("sizing") {
+			def, err := constructPBHostDefinitionFromCLI(c, "sizing")
+			if err != nil {
+				return err
+			}
+			req.Sizing = def.Sizing
+		}
+		err := client.New().Network.ReplaceGateway(req, temporal.GetLongOperationTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "replacement of gateway", false).Error())))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
+// networkFailoverCommand handles 'safescale network failover ...'
+var networkFailoverCommand = cli.Command{
+	Name:      "failover",
+	Usage:     "manage the high availability of the default route of a network",
+	ArgsUsage: "COMMAND",
+
+	Subcommands: []cli.Command{
+		networkFailoverEnableCommand,
+		networkFailoverDisableCommand,
+	},
+}
+
+var networkFailoverEnableCommand = cli.Command{
+	Name:      "enable",
+	Usage:     "adds a secondary gateway and a VIP used as default route by the hosts of the network",
+	ArgsUsage: "<Network_name>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "os",
+			Value: "Ubuntu 18.04",
+			Usage: "Image name for the secondary gateway",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", networkCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 1 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Network_name>."))
+		}
+
+		req := &pb.NetworkFailoverRequest{
+			Network: &pb.Reference{Name: c.Args().First()},
+			ImageId: c.String("os"),
+		}
+		err := client.New().Network.EnableFailover(req, temporal.GetLongOperationTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "activation of failover", false).Error())))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
+var networkFailoverDisableCommand = cli.Command{
+	Name:      "disable",
+	Usage:     "removes the secondary gateway and the VIP, the hosts of the network being routed through the primary gateway",
+	ArgsUsage: "<Network_name>",
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", networkCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 1 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Network_name>."))
+		}
+
+		err := client.New().Network.DisableFailover(c.Args().First(), temporal.GetLongOperationTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "deactivation of failover", false).Error())))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
+var networkRotateKeysCommand = cli.Command{
+	Name:      "rotate-keys",
+	Usage:     "replaces the keypairs used to connect to the gateway(s) and hosts of the network by new ones",
+	ArgsUsage: "<Network_name>",
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", networkCmdName, c.Command.Name, c.Args())
+		if c.NArg() != 1 {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Network_name>."))
+		}
+
+		err := client.New().Network.RotateKeys(c.Args().First(), temporal.GetLongOperationTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "rotation of keys", false).Error())))
+		}
+		return clitools.SuccessResponse(nil)
+	},
+}
+
+// splitNetworkTenant splits a reference <network>[@<tenant>]; tenant is empty for the current tenant
+func splitNetworkTenant(ref string) (string, string) {
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
@@ -93,6 +93,14 @@ var shareCreate = cli.Command{
 			Name:  "securityModes",
 			Usage: "{sys(the default--no cryptographic security), krb5(authentication only), krb5i(integrity protection), and krb5p(privacy protection)}",
 		},
+		cli.StringFlag{
+			Name:  "peer",
+			Usage: "Name or ID of a second host exporting the share in high availability (requires --device)",
+		},
+		cli.StringFlag{
+			Name:  "device",
+			Usage: "Block device, present on both hosts and dedicated to the share, replicated between host and peer (ex: /dev/vdb)",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", shareCmdName, c.Command.Name, c.Args())
@@ -100,6 +108,10 @@ var shareCreate = cli.Command{
 			_ = cli.ShowSubcommandHelp(c)
 			return clitools.FailureResponse(clitools.ExitOnInvalidArgument("Missing mandatory argument <Nas_name> and/or <Host_name>."))
 		}
+		if (c.String("peer") == "") != (c.String("device") == "") {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption("--peer and --device must be used together."))
+		}
 
 		shareName := c.Args().Get(0)
 		def := pb.ShareDefinition{
@@ -116,6 +128,10 @@ var shareCreate = cli.Command{
 				SubtreeCheck: c.Bool("subtreecheck"),
 			},
 			SecurityModes: c.StringSlice("securityModes"),
+			Device:        c.String("device"),
+		}
+		if c.String("peer") != "" {
+			def.Peer = &pb.Reference{Name: c.String("peer")}
 		}
 		err := client.New().Share.Create(def, temporal.GetExecutionTimeout())
 		if err != nil {
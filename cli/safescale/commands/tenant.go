@@ -20,6 +20,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 
+	pb "github.com/CS-SI/SafeScale/lib"
 	"github.com/CS-SI/SafeScale/lib/client"
 	"github.com/CS-SI/SafeScale/lib/utils"
 	clitools "github.com/CS-SI/SafeScale/lib/utils/cli"
@@ -36,6 +37,7 @@ var TenantCmd = cli.Command{
 		tenantList,
 		tenantGet,
 		tenantSet,
+		tenantGC,
 		// tenantStorageList,
 		// tenantStorageGet,
 		// tenantStorageSet,
@@ -56,6 +58,71 @@ var tenantList = cli.Command{
 	},
 }
 
+var tenantGC = cli.Command{
+	Name:  "gc",
+	Usage: "Reports (and optionally fixes) drifts between provider resources and SafeScale metadata",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Only reports what would be done (default unless --apply is used)",
+		},
+		cli.BoolFlag{
+			Name:  "apply",
+			Usage: "Really does what --delete-orphans, --import-orphans and --purge-dangling ask for",
+		},
+		cli.BoolFlag{
+			Name:  "delete-orphans",
+			Usage: "Deletes provider resources named after SafeScale conventions but without SafeScale metadata",
+		},
+		cli.BoolFlag{
+			Name:  "import-orphans",
+			Usage: "Registers in SafeScale the orphan hosts and networks, with their gateways, instead of deleting them",
+		},
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "Path of the private key file used to connect to the imported hosts and gateways (mandatory with --import-orphans)",
+		},
+		cli.StringFlag{
+			Name:  "user",
+			Usage: "Existing account of the imported hosts the key gives access to, used to create the operator account of SafeScale on them",
+		},
+		cli.BoolFlag{
+			Name:  "purge-dangling",
+			Usage: "Removes SafeScale metadata of resources that don't exist anymore on provider side",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		logrus.Tracef("SafeScale command: {%s}, {%s} with args {%s}", tenantCmdName, c.Command.Name, c.Args())
+		if c.Bool("dry-run") && c.Bool("apply") {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption("cannot use simultaneously --dry-run and --apply"))
+		}
+		if c.Bool("delete-orphans") && c.Bool("import-orphans") {
+			_ = cli.ShowSubcommandHelp(c)
+			return clitools.FailureResponse(clitools.ExitOnInvalidOption("cannot use simultaneously --delete-orphans and --import-orphans"))
+		}
+		req := &pb.TenantGCRequest{
+			Apply:         c.Bool("apply"),
+			DeleteOrphans: c.Bool("delete-orphans"),
+			ImportOrphans: c.Bool("import-orphans"),
+			PurgeDangling: c.Bool("purge-dangling"),
+		}
+		if req.ImportOrphans {
+			privateKey, err := readPrivateKey(c)
+			if err != nil {
+				return clitools.FailureResponse(clitools.ExitOnInvalidOption(err.Error()))
+			}
+			req.PrivateKey = privateKey
+			req.User = c.String("user")
+		}
+		report, err := client.New().Tenant.GC(req, temporal.GetLongOperationTimeout())
+		if err != nil {
+			return clitools.FailureResponse(clitools.ExitOnRPC(utils.Capitalize(client.DecorateError(err, "garbage collection of tenant", false).Error())))
+		}
+		return clitools.SuccessResponse(report.GetEntries())
+	},
+}
+
 var tenantGet = cli.Command{
 	Name:  "get",
 	Usage: "Get current tenant",
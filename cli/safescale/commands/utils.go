@@ -18,11 +18,15 @@ package commands
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/denisbrodbeck/machineid"
 	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
 
 	"github.com/CS-SI/SafeScale/lib/client"
 	"github.com/CS-SI/SafeScale/lib/utils/cli/enums/outputs"
@@ -133,3 +137,50 @@ func (rfh *RemoteFilesHandler) Cleanup(hostname string) {
 		}
 	}
 }
+
+// parseExpiration returns the expiration date requested with --ttl or --expires-at (zero value if none is set)
+// --ttl accepts Go durations (ex: 90m, 8h) and a number of days suffixed by 'd' (ex: 7d)
+func parseExpiration(c *cli.Context) (time.Time, error) {
+	if c.IsSet("ttl") && c.IsSet("expires-at") {
+		return time.Time{}, fmt.Errorf("cannot use simultaneously --ttl and --expires-at")
+	}
+	if c.IsSet("expires-at") {
+		expiresAt, err := time.Parse(time.RFC3339, c.String("expires-at"))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid value '%s' for --expires-at, expecting RFC3339 date (ex: 2020-03-31T18:00:00+02:00)", c.String("expires-at"))
+		}
+		return expiresAt, nil
+	}
+	if c.IsSet("ttl") {
+		ttl := strings.TrimSpace(c.String("ttl"))
+		var (
+			duration time.Duration
+			err      error
+		)
+		if strings.HasSuffix(ttl, "d") {
+			var days int
+			days, err = strconv.Atoi(strings.TrimSuffix(ttl, "d"))
+			duration = time.Duration(days) * 24 * time.Hour
+		} else {
+			duration, err = time.ParseDuration(ttl)
+		}
+		if err != nil || duration <= 0 {
+			return time.Time{}, fmt.Errorf("invalid value '%s' for --ttl, expecting a positive duration (ex: 8h, 7d)", ttl)
+		}
+		return time.Now().Add(duration), nil
+	}
+	return time.Time{}, nil
+}
+
+// readPrivateKey returns the content of the private key file given with --key
+func readPrivateKey(c *cli.Context) (string, error) {
+	path := c.String("key")
+	if path == "" {
+		return "", fmt.Errorf("missing mandatory option --key")
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read private key file '%s': %s", path, err.Error())
+	}
+	return string(content), nil
+}
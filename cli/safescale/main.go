@@ -160,6 +160,9 @@ func main() {
 	app.Commands = append(app.Commands, commands.ClusterCommand)
 	sort.Sort(cli.CommandsByName(commands.ClusterCommand.Subcommands))
 
+	app.Commands = append(app.Commands, commands.FeatureCmd)
+	sort.Sort(cli.CommandsByName(commands.FeatureCmd.Subcommands))
+
 	// app.Commands = append(app.Commands, commands.PerformCommand)
 	// sort.Sort(cli.CommandsByName(commands.PerformCommand.Subcommands))
 
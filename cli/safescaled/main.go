@@ -34,8 +34,10 @@ import (
 	"google.golang.org/grpc/reflection"
 
 	pb "github.com/CS-SI/SafeScale/lib"
+	"github.com/CS-SI/SafeScale/lib/server/drift"
 	"github.com/CS-SI/SafeScale/lib/server/iaas"
 	"github.com/CS-SI/SafeScale/lib/server/listeners"
+	"github.com/CS-SI/SafeScale/lib/server/reaper"
 	"github.com/CS-SI/SafeScale/lib/server/utils"
 
 	_ "github.com/CS-SI/SafeScale/lib/server"
@@ -46,11 +48,11 @@ func cleanup() {
 }
 
 // *** MAIN ***
-func work() {
-	c := make(chan os.Signal)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+func work(c *cli.Context) {
+	sigCh := make(chan os.Signal)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 	go func() {
-		<-c
+		<-sigCh
 		cleanup()
 		exit.Exit(1)
 	}()
@@ -111,6 +113,14 @@ func work() {
 	// Register reflection service on gRPC server.
 	reflection.Register(s)
 
+	logrus.Infoln("Starting reaper of expired resources")
+	go reaper.Run()
+
+	if interval := c.Duration("features-check-interval"); interval > 0 {
+		logrus.Infof("Starting drift detection of installed features, every %s", interval)
+		go drift.Run(interval, c.Bool("features-remediate"))
+	}
+
 	version := Version + ", build " + Revision + " (" + BuildDate + ")"
 	fmt.Printf("Safescaled version: %s\nReady to serve :-)\n", version)
 	if err := s.Serve(lis); err != nil {
@@ -146,6 +156,15 @@ func main() {
 			Name:  "debug, d",
 			Usage: "Show debug information",
 		},
+		cli.DurationFlag{
+			Name:  "features-check-interval",
+			Usage: "Delay between two checks of the features installed on hosts and clusters (ex: 30m, 2h); 0 disables the checks",
+			Value: drift.DefaultInterval,
+		},
+		cli.BoolFlag{
+			Name:  "features-remediate",
+			Usage: "Add again the installed features whose check fails",
+		},
 		// cli.IntFlag{
 		// 	Name:  "port, p",
 		// 	Usage: "Bind to specified port `PORT`",
@@ -177,7 +196,7 @@ func main() {
 	}
 
 	app.Action = func(c *cli.Context) error {
-		work()
+		work(c)
 		return nil
 	}
 
@@ -402,7 +402,7 @@ func analyzeTenant(group *sync.WaitGroup, theTenant string) (err error) {
 			hostName := "scanhost-" + template.Name
 			hostHandler := handlers.NewHostHandler(serviceProvider)
 
-			host, err := hostHandler.Create(context.Background(), hostName, network.Name, "Ubuntu 18.04", true, template.Name, false)
+			host, err := hostHandler.Create(context.Background(), hostName, []string{network.Name}, "Ubuntu 18.04", true, template.Name, false, "", "", time.Time{})
 			if err != nil {
 				logrus.Warnf("template [%s] host '%s': error creation: %v\n", template.Name, hostName, err.Error())
 				return err
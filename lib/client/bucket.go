@@ -112,6 +112,20 @@ func (c *bucket) Inspect(name string, timeout time.Duration) (*pb.BucketMounting
 	return service.Inspect(ctx, &pb.Bucket{Name: name})
 }
 
+// ReadFolder returns the content of the objects stored in a folder of the bucket, keeping only the ones whose name
+// ends with suffix if it is not empty
+func (c *bucket) ReadFolder(bucketName, path, suffix string, timeout time.Duration) (*pb.BucketObjectList, error) {
+	c.session.Connect()
+	defer c.session.Disconnect()
+	service := pb.NewBucketServiceClient(c.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.ReadFolder(ctx, &pb.BucketFolder{Bucket: bucketName, Path: path, Suffix: suffix})
+}
+
 // Mount ...
 func (c *bucket) Mount(bucketName, hostName, mountPoint string, timeout time.Duration) error {
 	c.session.Connect()
@@ -132,6 +132,117 @@ func (h *host) Create(def pb.HostDefinition, timeout time.Duration) (*pb.Host, e
 	return service.Create(ctx, &def)
 }
 
+// Extend sets the expiration date of the host (zero value removes it)
+func (h *host) Extend(name string, expiresAt time.Time, timeout time.Duration) (*pb.Host, error) {
+	h.session.Connect()
+	defer h.session.Disconnect()
+	service := pb.NewHostServiceClient(h.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &pb.HostExtendRequest{Host: &pb.Reference{Name: name}}
+	if !expiresAt.IsZero() {
+		req.ExpiresAt = expiresAt.Format(time.RFC3339)
+	}
+	return service.Extend(ctx, req)
+}
+
+// Import registers in SafeScale the host identified by id on provider side
+func (h *host) Import(req *pb.HostImportRequest, timeout time.Duration) (*pb.Host, error) {
+	h.session.Connect()
+	defer h.session.Disconnect()
+	service := pb.NewHostServiceClient(h.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.Import(ctx, req)
+}
+
+// AddUser creates on the host the personal account described in req
+func (h *host) AddUser(req *pb.HostUserRequest, timeout time.Duration) error {
+	h.session.Connect()
+	defer h.session.Disconnect()
+	service := pb.NewHostServiceClient(h.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return err
+	}
+
+	_, err = service.AddUser(ctx, req)
+	return err
+}
+
+// RemoveUser deletes the personal account name from the host
+func (h *host) RemoveUser(hostRef string, name string, timeout time.Duration) error {
+	h.session.Connect()
+	defer h.session.Disconnect()
+	service := pb.NewHostServiceClient(h.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return err
+	}
+
+	_, err = service.RemoveUser(ctx, &pb.HostUserRequest{Host: &pb.Reference{Name: hostRef}, User: &pb.HostUser{Name: name}})
+	return err
+}
+
+// ListUsers lists the personal accounts added on the host
+func (h *host) ListUsers(hostRef string, timeout time.Duration) (*pb.HostUserList, error) {
+	h.session.Connect()
+	defer h.session.Disconnect()
+	service := pb.NewHostServiceClient(h.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.ListUsers(ctx, &pb.Reference{Name: hostRef})
+}
+
+// RotateKey replaces the keypair used to connect to the host by a new one
+func (h *host) RotateKey(name string, timeout time.Duration) (*pb.Host, error) {
+	h.session.Connect()
+	defer h.session.Disconnect()
+	service := pb.NewHostServiceClient(h.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.RotateKey(ctx, &pb.Reference{Name: name})
+}
+
+// GetFeatures returns the features recorded as installed on the host
+func (h *host) GetFeatures(hostRef string, timeout time.Duration) (*pb.HostFeatures, error) {
+	h.session.Connect()
+	defer h.session.Disconnect()
+	service := pb.NewHostServiceClient(h.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.GetFeatures(ctx, &pb.Reference{Name: hostRef})
+}
+
+// SetFeatures replaces the features recorded as installed on the host
+func (h *host) SetFeatures(features *pb.HostFeatures, timeout time.Duration) error {
+	h.session.Connect()
+	defer h.session.Disconnect()
+	service := pb.NewHostServiceClient(h.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return err
+	}
+
+	_, err = service.SetFeatures(ctx, features)
+	return err
+}
+
 // Delete deletes several hosts at the same time in goroutines
 func (h *host) Delete(names []string, timeout time.Duration) error {
 	h.session.Connect()
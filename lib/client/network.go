@@ -102,6 +102,129 @@ func (n *network) Inspect(name string, timeout time.Duration) (*pb.Network, erro
 
 }
 
+// Import registers in SafeScale the network identified by id on provider side
+func (n *network) Import(req *pb.NetworkImportRequest, timeout time.Duration) (*pb.Network, error) {
+	n.session.Connect()
+	defer n.session.Disconnect()
+	service := pb.NewNetworkServiceClient(n.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.Import(ctx, req)
+}
+
+// Peer sets up a VPN between two networks, possibly of different tenants
+func (n *network) Peer(req *pb.NetworkPeerRequest, timeout time.Duration) error {
+	n.session.Connect()
+	defer n.session.Disconnect()
+	service := pb.NewNetworkServiceClient(n.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return err
+	}
+
+	_, err = service.Peer(ctx, req)
+	return err
+}
+
+// AddForward forwards a public port of the gateway(s) of a network to a port of one of its hosts
+func (n *network) AddForward(req *pb.NetworkForwardRequest, timeout time.Duration) (*pb.NetworkForward, error) {
+	n.session.Connect()
+	defer n.session.Disconnect()
+	service := pb.NewNetworkServiceClient(n.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.AddForward(ctx, req)
+}
+
+// ListForwards lists the forwarding rules of a network
+func (n *network) ListForwards(networkRef string, timeout time.Duration) (*pb.NetworkForwardList, error) {
+	n.session.Connect()
+	defer n.session.Disconnect()
+	service := pb.NewNetworkServiceClient(n.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.ListForwards(ctx, &pb.Reference{Name: networkRef})
+}
+
+// DeleteForward removes a forwarding rule of a network
+func (n *network) DeleteForward(req *pb.NetworkForwardRequest, timeout time.Duration) error {
+	n.session.Connect()
+	defer n.session.Disconnect()
+	service := pb.NewNetworkServiceClient(n.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return err
+	}
+
+	_, err = service.DeleteForward(ctx, req)
+	return err
+}
+
+// ReplaceGateway replaces the gateway(s) of a network by new ones
+func (n *network) ReplaceGateway(req *pb.NetworkGatewayReplaceRequest, timeout time.Duration) error {
+	n.session.Connect()
+	defer n.session.Disconnect()
+	service := pb.NewNetworkServiceClient(n.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return err
+	}
+
+	_, err = service.ReplaceGateway(ctx, req)
+	return err
+}
+
+// EnableFailover adds a secondary gateway and a VIP to a network
+func (n *network) EnableFailover(req *pb.NetworkFailoverRequest, timeout time.Duration) error {
+	n.session.Connect()
+	defer n.session.Disconnect()
+	service := pb.NewNetworkServiceClient(n.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return err
+	}
+
+	_, err = service.EnableFailover(ctx, req)
+	return err
+}
+
+// DisableFailover removes the secondary gateway and the VIP of a network
+func (n *network) DisableFailover(ref string, timeout time.Duration) error {
+	n.session.Connect()
+	defer n.session.Disconnect()
+	service := pb.NewNetworkServiceClient(n.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return err
+	}
+
+	_, err = service.DisableFailover(ctx, &pb.Reference{Name: ref})
+	return err
+}
+
+// RotateKeys rotates the keypairs of the gateway(s) and hosts of the network
+func (n *network) RotateKeys(ref string, timeout time.Duration) error {
+	n.session.Connect()
+	defer n.session.Disconnect()
+	service := pb.NewNetworkServiceClient(n.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return err
+	}
+
+	_, err = service.RotateKeys(ctx, &pb.Reference{Name: ref})
+	return err
+}
+
 // Create ...
 func (n *network) Create(def pb.NetworkDefinition, timeout time.Duration) (*pb.Network, error) {
 	n.session.Connect()
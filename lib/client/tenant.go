@@ -71,6 +71,60 @@ func (t *tenant) Set(name string, timeout time.Duration) error {
 	return err
 }
 
+// GC reports the drifts between provider resources and metadata of the current tenant, fixing them if asked
+func (t *tenant) GC(req *pb.TenantGCRequest, timeout time.Duration) (*pb.TenantGCReport, error) {
+	t.session.Connect()
+	defer t.session.Disconnect()
+	service := pb.NewTenantServiceClient(t.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.GC(ctx, req)
+}
+
+// ReadFeatureSecrets returns the secrets stored for a feature installed on a host or a cluster
+func (t *tenant) ReadFeatureSecrets(ref *pb.FeatureSecretsRef, timeout time.Duration) (*pb.FeatureSecrets, error) {
+	t.session.Connect()
+	defer t.session.Disconnect()
+	service := pb.NewTenantServiceClient(t.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.ReadFeatureSecrets(ctx, ref)
+}
+
+// WriteFeatureSecrets stores the secrets of a feature installed on a host or a cluster
+func (t *tenant) WriteFeatureSecrets(secrets *pb.FeatureSecrets, timeout time.Duration) error {
+	t.session.Connect()
+	defer t.session.Disconnect()
+	service := pb.NewTenantServiceClient(t.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return err
+	}
+
+	_, err = service.WriteFeatureSecrets(ctx, secrets)
+	return err
+}
+
+// DeleteFeatureSecrets removes the secrets stored for a feature installed on a host or a cluster
+func (t *tenant) DeleteFeatureSecrets(ref *pb.FeatureSecretsRef, timeout time.Duration) error {
+	t.session.Connect()
+	defer t.session.Disconnect()
+	service := pb.NewTenantServiceClient(t.session.connection)
+	ctx, err := utils.GetContext(true)
+	if err != nil {
+		return err
+	}
+
+	_, err = service.DeleteFeatureSecrets(ctx, ref)
+	return err
+}
+
 // StorageList ...
 func (t *tenant) StorageList(timeout time.Duration) (*pb.TenantList, error) {
 	t.session.Connect()
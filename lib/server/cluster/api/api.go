@@ -17,6 +17,8 @@
 package api
 
 import (
+	"time"
+
 	pb "github.com/CS-SI/SafeScale/lib"
 	propsv1 "github.com/CS-SI/SafeScale/lib/server/cluster/control/properties/v1"
 	propsv2 "github.com/CS-SI/SafeScale/lib/server/cluster/control/properties/v2"
@@ -39,6 +41,8 @@ type Cluster interface {
 	GetNetworkConfig(concurrency.Task) (propsv2.Network, error)
 	// GetProperties returns the extension of the cluster
 	GetProperties(concurrency.Task) *serialize.JSONProperties
+	// UpdateMetadata reloads the metadata of the cluster, applies the changes made by the callback, then saves them
+	UpdateMetadata(concurrency.Task, func() error) error
 
 	// Start starts the cluster
 	Start(concurrency.Task) error
@@ -81,6 +85,18 @@ type Cluster interface {
 	// CountNodes counts the nodes of the cluster
 	CountNodes(concurrency.Task) (uint, error)
 
+	// GetExpiration returns the date after which the cluster is reclaimed (zero value if never)
+	GetExpiration(concurrency.Task) (time.Time, error)
+	// Extend changes the date after which the cluster is reclaimed (zero value removes expiration)
+	Extend(concurrency.Task, time.Time) error
+
+	// AddUser creates a personal account on all the hosts of the cluster, including the ones added later
+	AddUser(concurrency.Task, string, []string, bool) error
+	// RemoveUser deletes a personal account from all the hosts of the cluster
+	RemoveUser(concurrency.Task, string) error
+	// RotateKeys replaces the keypairs used to connect to the hosts of the cluster
+	RotateKeys(concurrency.Task) error
+
 	// Delete allows to destroy infrastructure of cluster
 	Delete(concurrency.Task) error
 }
@@ -778,6 +778,12 @@ func (c *Controller) AddNodes(task concurrency.Task, count int, req *pb.HostDefi
 		return nil, err
 	}
 
+	// Gives the new nodes the personal accounts of the cluster; the nodes are part of the cluster by now, so a failure
+	// here doesn't undo the expansion ('cluster user add' can be run again to complete the accounts)
+	if uerr := c.addUsersToNewHosts(task, hosts); uerr != nil {
+		log.Warnf("nodes %s added to cluster '%s' but personal accounts not all created: %v", strings.Join(hosts, ", "), c.Name, uerr)
+	}
+
 	return hosts, nil
 }
 
@@ -1112,6 +1118,49 @@ func (c *Controller) Delete(task concurrency.Task) (err error) {
 	return c.foreman.destruct(task)
 }
 
+// GetExpiration returns the date after which the cluster is reclaimed (zero value if it never expires)
+func (c *Controller) GetExpiration(task concurrency.Task) (expiresAt time.Time, err error) {
+	if c == nil {
+		return expiresAt, scerr.InvalidInstanceError()
+	}
+	if task == nil {
+		task = concurrency.RootTask()
+	}
+
+	if !c.GetProperties(task).Lookup(property.LifecycleV1) {
+		return expiresAt, nil
+	}
+	err = c.GetProperties(task).LockForRead(property.LifecycleV1).ThenUse(func(clonable data.Clonable) error {
+		expiresAt = clonable.(*clusterpropsv1.Lifecycle).ExpiresAt
+		return nil
+	})
+	return expiresAt, err
+}
+
+// Extend sets the date after which the cluster is reclaimed; a zero value removes the expiration
+func (c *Controller) Extend(task concurrency.Task, expiresAt time.Time) (err error) {
+	if c == nil {
+		return scerr.InvalidInstanceError()
+	}
+	if task == nil {
+		task = concurrency.RootTask()
+	}
+	if !expiresAt.IsZero() && expiresAt.Before(time.Now()) {
+		return scerr.InvalidParameterError("expiresAt", "cannot be in the past")
+	}
+
+	tracer := concurrency.NewTracer(task, fmt.Sprintf("(%s)", expiresAt.Format(time.RFC3339)), true).GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	return c.UpdateMetadata(task, func() error {
+		return c.GetProperties(task).LockForWrite(property.LifecycleV1).ThenUse(func(clonable data.Clonable) error {
+			clonable.(*clusterpropsv1.Lifecycle).ExpiresAt = expiresAt
+			return nil
+		})
+	})
+}
+
 // Stop stops the Cluster is its current state is compatible
 func (c *Controller) Stop(task concurrency.Task) (err error) {
 	if c == nil {
@@ -408,6 +408,16 @@ func (b *foreman) construct(task concurrency.Task, req Request) (err error) {
 			return err
 		}
 
+		if !req.ExpiresAt.IsZero() {
+			err = b.cluster.GetProperties(task).LockForWrite(property.LifecycleV1).ThenUse(func(clonable data.Clonable) error {
+				clonable.(*clusterpropsv1.Lifecycle).ExpiresAt = req.ExpiresAt
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
 		return b.cluster.GetProperties(task).LockForWrite(property.NetworkV2).ThenUse(func(clonable data.Clonable) error {
 			networkV2 := clonable.(*clusterpropsv2.Network)
 			networkV2.NetworkID = req.NetworkID
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/CS-SI/SafeScale/lib/client"
+	"github.com/CS-SI/SafeScale/lib/utils"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+	"github.com/CS-SI/SafeScale/lib/utils/temporal"
+)
+
+// RotateKeys replaces the keypairs used to connect to the gateways, masters and nodes of the cluster by new ones
+func (c *Controller) RotateKeys(task concurrency.Task) (err error) {
+	if c == nil {
+		return scerr.InvalidInstanceError()
+	}
+	if task == nil {
+		task = concurrency.RootTask()
+	}
+
+	tracer := concurrency.NewTracer(task, "", true).GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	hosts, err := c.listHostIDs(task)
+	if err != nil {
+		return err
+	}
+	hostClt := client.New().Host
+	var errors []string
+	for _, h := range hosts {
+		_, err := hostClt.RotateKey(h, temporal.GetExecutionTimeout())
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %s", h, client.DecorateError(err, "rotation of key", false).Error()))
+		}
+	}
+	if len(errors) > 0 {
+		return fmt.Errorf("failed to rotate the key of %d host%s: %s", len(errors), utils.Plural(len(errors)), strings.Join(errors, "\n"))
+	}
+	return nil
+}
@@ -17,18 +17,71 @@
 package propertiesv1
 
 import (
+	"time"
+
 	"github.com/CS-SI/SafeScale/lib/server/cluster/enums/property"
 	"github.com/CS-SI/SafeScale/lib/utils/data"
 	"github.com/CS-SI/SafeScale/lib/utils/serialize"
 )
 
+// InstalledFeature describes a feature installed on the cluster
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with updated/additional fields
+type InstalledFeature struct {
+	Version    string   `json:"version,omitempty"`     // version of the feature, as declared in its specification file
+	Requires   []string `json:"requires,omitempty"`    // features required by this one
+	RequiredBy []string `json:"required_by,omitempty"` // installed features requiring this one
+	// CheckedAt tells when the feature has been checked by the drift detection for the last time
+	CheckedAt    time.Time `json:"checked_at,omitempty"`
+	CheckStatus  string    `json:"check_status,omitempty"`  // result of the last check ("ok", "drifted", "remediated" or "failed")
+	CheckMessage string    `json:"check_message,omitempty"` // details about the result of the last check
+	RemediatedAt time.Time `json:"remediated_at,omitempty"` // tells when the feature has been added again after a drift
+}
+
+// NewInstalledFeature ...
+func NewInstalledFeature() *InstalledFeature {
+	return &InstalledFeature{
+		Requires:   []string{},
+		RequiredBy: []string{},
+	}
+}
+
+// Content ...
+// satisfies interface data.Clonable
+func (f *InstalledFeature) Content() data.Clonable {
+	return f
+}
+
+// Clone ...
+// satisfies interface data.Clonable
+func (f *InstalledFeature) Clone() data.Clonable {
+	return NewInstalledFeature().Replace(f)
+}
+
+// Replace ...
+// satisfies interface data.Clonable
+func (f *InstalledFeature) Replace(p data.Clonable) data.Clonable {
+	src := p.(*InstalledFeature)
+	f.Version = src.Version
+	f.Requires = make([]string, len(src.Requires))
+	copy(f.Requires, src.Requires)
+	f.RequiredBy = make([]string, len(src.RequiredBy))
+	copy(f.RequiredBy, src.RequiredBy)
+	f.CheckedAt = src.CheckedAt
+	f.CheckStatus = src.CheckStatus
+	f.CheckMessage = src.CheckMessage
+	f.RemediatedAt = src.RemediatedAt
+	return f
+}
+
 // Features ...
 // not FROZEN yet
 // Note: if tagged as FROZEN, must not be changed ever.
 //       Create a new version instead with updated/additional fields
 type Features struct {
-	// Installed ...
-	Installed map[string]string `json:"installed"`
+	// Installed keeps track of the features installed on the cluster, indexed on feature name
+	Installed map[string]*InstalledFeature `json:"installed"`
 	// Disabled keeps track of features normally automatically added with cluster creation,
 	// but explicitly disabled; if a disabled feature is added, must be removed from this property
 	Disabled map[string]struct{} `json:"disabled"`
@@ -36,7 +89,7 @@ type Features struct {
 
 func newFeatures() *Features {
 	return &Features{
-		Installed: map[string]string{},
+		Installed: map[string]*InstalledFeature{},
 		Disabled:  map[string]struct{}{},
 	}
 }
@@ -57,9 +110,9 @@ func (f *Features) Clone() data.Clonable {
 // satisfies interface data.Clonable
 func (f *Features) Replace(p data.Clonable) data.Clonable {
 	src := p.(*Features)
-	f.Installed = make(map[string]string, len(src.Installed))
+	f.Installed = make(map[string]*InstalledFeature, len(src.Installed))
 	for k, v := range src.Installed {
-		f.Installed[k] = v
+		f.Installed[k] = v.Clone().(*InstalledFeature)
 	}
 	f.Disabled = make(map[string]struct{}, len(src.Installed))
 	for k, v := range src.Disabled {
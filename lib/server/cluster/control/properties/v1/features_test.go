@@ -4,11 +4,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestFeatures_Clone(t *testing.T) {
 	ct := newFeatures()
-	ct.Installed["fair"] = "something"
+	ct.Installed["fair"] = &InstalledFeature{Version: "something", Requires: []string{}, RequiredBy: []string{}}
 	ct.Disabled["kind"] = struct{}{}
 
 	clonedCt, ok := ct.Clone().(*Features)
@@ -17,7 +18,31 @@ func TestFeatures_Clone(t *testing.T) {
 	}
 
 	assert.Equal(t, ct, clonedCt)
-	clonedCt.Installed["fair"] = "commitment"
+	clonedCt.Installed["fair"].Version = "commitment"
+
+	areEqual := reflect.DeepEqual(ct, clonedCt)
+	if areEqual {
+		t.Error("It's a shallow clone !")
+		t.Fail()
+	}
+}
+
+func TestInstalledFeature_Clone(t *testing.T) {
+	ct := NewInstalledFeature()
+	ct.Version = "1.2.0"
+	ct.Requires = append(ct.Requires, "docker")
+	ct.RequiredBy = append(ct.RequiredBy, "helm")
+	ct.CheckedAt = time.Now()
+	ct.CheckStatus = "drifted"
+	ct.CheckMessage = "failure: retcode=1"
+
+	clonedCt, ok := ct.Clone().(*InstalledFeature)
+	if !ok {
+		t.Fail()
+	}
+
+	assert.Equal(t, ct, clonedCt)
+	clonedCt.Requires[0] = "kubernetes"
 
 	areEqual := reflect.DeepEqual(ct, clonedCt)
 	if areEqual {
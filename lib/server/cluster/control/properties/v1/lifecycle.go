@@ -0,0 +1,60 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package propertiesv1
+
+import (
+	"time"
+
+	"github.com/CS-SI/SafeScale/lib/server/cluster/enums/property"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+	"github.com/CS-SI/SafeScale/lib/utils/serialize"
+)
+
+// Lifecycle contains information about the planned end of life of the cluster
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with needed supplemental fields
+type Lifecycle struct {
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // tells when the cluster will be reclaimed; zero value means never
+}
+
+func newLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Content ...
+// satisfies interface data.Clonable
+func (l *Lifecycle) Content() data.Clonable {
+	return l
+}
+
+// Clone ...
+// satisfies interface data.Clonable
+func (l *Lifecycle) Clone() data.Clonable {
+	return newLifecycle().Replace(l)
+}
+
+// Replace ...
+// satisfies interface data.Clonable
+func (l *Lifecycle) Replace(p data.Clonable) data.Clonable {
+	*l = *p.(*Lifecycle)
+	return l
+}
+
+func init() {
+	serialize.PropertyTypeRegistry.Register("clusters", property.LifecycleV1, &Lifecycle{})
+}
@@ -0,0 +1,27 @@
+package propertiesv1
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLifecycle_Clone(t *testing.T) {
+	ct := newLifecycle()
+	ct.ExpiresAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	clonedCt, ok := ct.Clone().(*Lifecycle)
+	if !ok {
+		t.Fail()
+	}
+
+	assert.Equal(t, ct, clonedCt)
+	clonedCt.ExpiresAt = clonedCt.ExpiresAt.Add(time.Hour)
+
+	areEqual := reflect.DeepEqual(ct, clonedCt)
+	if areEqual {
+		t.Error("It's a shallow clone !")
+		t.Fail()
+	}
+}
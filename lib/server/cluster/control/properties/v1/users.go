@@ -0,0 +1,77 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package propertiesv1
+
+import (
+	"github.com/CS-SI/SafeScale/lib/server/cluster/enums/property"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+	"github.com/CS-SI/SafeScale/lib/utils/serialize"
+)
+
+// User describes a personal account created on all the hosts of the cluster
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with needed supplemental fields
+type User struct {
+	Name       string   `json:"name"`                  // login of the user
+	PublicKeys []string `json:"public_keys,omitempty"` // SSH public keys authorized to log in as the user
+	Sudo       bool     `json:"sudo,omitempty"`        // tells if the user is allowed to use sudo
+}
+
+// Users contains the personal accounts to create on every host of the cluster, including the ones added later
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with needed supplemental fields
+type Users struct {
+	ByName map[string]*User `json:"by_name"`
+}
+
+func newUsers() *Users {
+	return &Users{
+		ByName: map[string]*User{},
+	}
+}
+
+// Content ...
+// satisfies interface data.Clonable
+func (u *Users) Content() data.Clonable {
+	return u
+}
+
+// Clone ...
+// satisfies interface data.Clonable
+func (u *Users) Clone() data.Clonable {
+	return newUsers().Replace(u)
+}
+
+// Replace ...
+// satisfies interface data.Clonable
+func (u *Users) Replace(p data.Clonable) data.Clonable {
+	src := p.(*Users)
+	u.ByName = make(map[string]*User, len(src.ByName))
+	for k, v := range src.ByName {
+		user := *v
+		user.PublicKeys = make([]string, len(v.PublicKeys))
+		copy(user.PublicKeys, v.PublicKeys)
+		u.ByName[k] = &user
+	}
+	return u
+}
+
+func init() {
+	serialize.PropertyTypeRegistry.Register("clusters", property.UsersV1, newUsers())
+}
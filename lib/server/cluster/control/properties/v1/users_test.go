@@ -0,0 +1,30 @@
+package propertiesv1
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+func TestUsers_Clone(t *testing.T) {
+	ct := newUsers()
+	ct.ByName["jdoe"] = &User{
+		Name:       "jdoe",
+		PublicKeys: []string{"ssh-ed25519 AAAA jdoe@laptop"},
+		Sudo:       true,
+	}
+
+	clonedCt, ok := ct.Clone().(*Users)
+	if !ok {
+		t.Fail()
+	}
+
+	assert.Equal(t, ct, clonedCt)
+	clonedCt.ByName["jdoe"].PublicKeys[0] = "ssh-rsa AAAA jdoe@desktop"
+
+	areEqual := reflect.DeepEqual(ct, clonedCt)
+	if areEqual {
+		t.Error("It's a shallow clone !")
+		t.Fail()
+	}
+}
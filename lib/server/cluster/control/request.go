@@ -17,6 +17,8 @@
 package control
 
 import (
+	"time"
+
 	pb "github.com/CS-SI/SafeScale/lib"
 	"github.com/CS-SI/SafeScale/lib/server/cluster/enums/complexity"
 	"github.com/CS-SI/SafeScale/lib/server/cluster/enums/flavor"
@@ -46,4 +48,6 @@ type Request struct {
 	NodesDef *pb.HostDefinition
 	// DisabledDefaultFeatures contains the list of features that should be installed by default but we don't want actually
 	DisabledDefaultFeatures map[string]struct{}
+	// ExpiresAt tells when the cluster will be reclaimed by safescaled (zero value means never)
+	ExpiresAt time.Time
 }
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package control
+
+import (
+	"fmt"
+	"strings"
+
+	pb "github.com/CS-SI/SafeScale/lib"
+	"github.com/CS-SI/SafeScale/lib/client"
+	clusterpropsv1 "github.com/CS-SI/SafeScale/lib/server/cluster/control/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/cluster/enums/property"
+	"github.com/CS-SI/SafeScale/lib/utils"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+	"github.com/CS-SI/SafeScale/lib/utils/temporal"
+)
+
+// listHostIDs returns the IDs of all the hosts of the cluster: gateways, masters and nodes
+func (c *Controller) listHostIDs(task concurrency.Task) ([]string, error) {
+	netCfg, err := c.GetNetworkConfig(task)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{netCfg.GatewayID}
+	if netCfg.SecondaryGatewayID != "" {
+		ids = append(ids, netCfg.SecondaryGatewayID)
+	}
+	ids = append(ids, c.ListMasterIDs(task)...)
+	ids = append(ids, c.ListNodeIDs(task)...)
+	return ids, nil
+}
+
+// addUserToHosts creates the account of user on the hosts, returning how many hosts succeeded
+func addUserToHosts(user *clusterpropsv1.User, hosts []string) (int, error) {
+	hostClt := client.New().Host
+	var errors []string
+	for _, h := range hosts {
+		req := &pb.HostUserRequest{
+			Host: &pb.Reference{Name: h},
+			User: &pb.HostUser{Name: user.Name, PublicKeys: user.PublicKeys, Sudo: user.Sudo},
+		}
+		err := hostClt.AddUser(req, temporal.GetExecutionTimeout())
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %s", h, client.DecorateError(err, "addition of user", false).Error()))
+		}
+	}
+	if len(errors) > 0 {
+		return len(hosts) - len(errors), fmt.Errorf("failed to add user '%s' on %d host%s: %s", user.Name, len(errors), utils.Plural(len(errors)), strings.Join(errors, "\n"))
+	}
+	return len(hosts), nil
+}
+
+// AddUser creates on every host of the cluster a personal account authorized to log in with publicKeys,
+// allowed to use sudo if sudo is true; the user is recorded so that the nodes added later get the account too
+func (c *Controller) AddUser(task concurrency.Task, name string, publicKeys []string, sudo bool) (err error) {
+	if c == nil {
+		return scerr.InvalidInstanceError()
+	}
+	if name == "" {
+		return scerr.InvalidParameterError("name", "cannot be empty string")
+	}
+	if task == nil {
+		task = concurrency.RootTask()
+	}
+
+	tracer := concurrency.NewTracer(task, fmt.Sprintf("('%s', %v)", name, sudo), true).GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	hosts, err := c.listHostIDs(task)
+	if err != nil {
+		return err
+	}
+	user := &clusterpropsv1.User{Name: name, PublicKeys: publicKeys, Sudo: sudo}
+	done, err := addUserToHosts(user, hosts)
+	if done == 0 {
+		return err
+	}
+
+	// Records the user as soon as one host has the account, for the removal to revoke it everywhere
+	uerr := c.UpdateMetadata(task, func() error {
+		return c.GetProperties(task).LockForWrite(property.UsersV1).ThenUse(func(clonable data.Clonable) error {
+			clonable.(*clusterpropsv1.Users).ByName[name] = user
+			return nil
+		})
+	})
+	if uerr != nil {
+		if err != nil {
+			return scerr.AddConsequence(err, uerr)
+		}
+		return uerr
+	}
+	return err
+}
+
+// RemoveUser deletes from every host of the cluster the personal account name previously added
+func (c *Controller) RemoveUser(task concurrency.Task, name string) (err error) {
+	if c == nil {
+		return scerr.InvalidInstanceError()
+	}
+	if name == "" {
+		return scerr.InvalidParameterError("name", "cannot be empty string")
+	}
+	if task == nil {
+		task = concurrency.RootTask()
+	}
+
+	tracer := concurrency.NewTracer(task, fmt.Sprintf("('%s')", name), true).GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	var found bool
+	err = c.GetProperties(task).LockForRead(property.UsersV1).ThenUse(func(clonable data.Clonable) error {
+		_, found = clonable.(*clusterpropsv1.Users).ByName[name]
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return scerr.NotFoundError(fmt.Sprintf("no user '%s' in cluster '%s'", name, c.Name))
+	}
+
+	hosts, err := c.listHostIDs(task)
+	if err != nil {
+		return err
+	}
+	hostClt := client.New().Host
+	var errors []string
+	for _, h := range hosts {
+		// The account may be missing on hosts where its addition failed
+		list, err := hostClt.ListUsers(h, temporal.GetExecutionTimeout())
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %s", h, client.DecorateError(err, "list of users", false).Error()))
+			continue
+		}
+		for _, u := range list.GetUsers() {
+			if u.GetName() != name {
+				continue
+			}
+			err = hostClt.RemoveUser(h, name, temporal.GetExecutionTimeout())
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("%s: %s", h, client.DecorateError(err, "removal of user", false).Error()))
+			}
+			break
+		}
+	}
+	if len(errors) > 0 {
+		// The user stays recorded, so the removal can be retried
+		return fmt.Errorf("failed to remove user '%s' from %d host%s: %s", name, len(errors), utils.Plural(len(errors)), strings.Join(errors, "\n"))
+	}
+
+	return c.UpdateMetadata(task, func() error {
+		return c.GetProperties(task).LockForWrite(property.UsersV1).ThenUse(func(clonable data.Clonable) error {
+			delete(clonable.(*clusterpropsv1.Users).ByName, name)
+			return nil
+		})
+	})
+}
+
+// addUsersToNewHosts creates on the hosts the personal accounts recorded in the cluster
+func (c *Controller) addUsersToNewHosts(task concurrency.Task, hosts []string) error {
+	var users []*clusterpropsv1.User
+	err := c.GetProperties(task).LockForRead(property.UsersV1).ThenUse(func(clonable data.Clonable) error {
+		for _, u := range clonable.(*clusterpropsv1.Users).ByName {
+			users = append(users, u)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if _, err = addUserToHosts(u, hosts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
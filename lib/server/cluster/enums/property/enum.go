@@ -40,4 +40,8 @@ const (
 	NetworkV2 = "10"
 	// ControlPlaneV1 contains optional additional info about Control Plane of the cluster
 	ControlPlaneV1 = "11"
+	// LifecycleV1 contains optional additional info about the expiration of the cluster
+	LifecycleV1 = "12"
+	// UsersV1 contains optional additional info about the personal accounts created on all the hosts of the cluster
+	UsersV1 = "13"
 )
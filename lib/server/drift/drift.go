@@ -0,0 +1,170 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package drift
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/CS-SI/SafeScale/lib/server/cluster"
+	"github.com/CS-SI/SafeScale/lib/server/cluster/control"
+	"github.com/CS-SI/SafeScale/lib/server/cluster/enums/clusterstate"
+	"github.com/CS-SI/SafeScale/lib/server/handlers"
+	"github.com/CS-SI/SafeScale/lib/server/iaas"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/hostproperty"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/hoststate"
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/install"
+	"github.com/CS-SI/SafeScale/lib/server/listeners"
+	srvutils "github.com/CS-SI/SafeScale/lib/server/utils"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+)
+
+// DefaultInterval is the default delay between two checks of the installed features
+const DefaultInterval = time.Hour
+
+// Run checks periodically the features installed on the hosts and the clusters of the current tenant, recording
+// the results in their metadata; if 'remediate' is true, the features that drifted are added again.
+// An interval lower or equal to 0 disables the checks.
+// Run never returns (unless disabled) and is intended to be started as a goroutine
+func Run(interval time.Duration, remediate bool) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		tenant := listeners.GetCurrentTenant()
+		if tenant == nil {
+			continue
+		}
+		checkHosts(tenant.Service, remediate)
+		checkClusters(tenant.Service, remediate)
+	}
+}
+
+// checkHosts checks the features installed on the started hosts
+func checkHosts(svc iaas.Service, remediate bool) {
+	ctx := context.Background()
+	handler := handlers.NewHostHandler(svc)
+	hosts, err := handler.List(ctx, false)
+	if err != nil {
+		log.Errorf("drift: failed to list hosts: %v", err)
+		return
+	}
+
+	for _, host := range hosts {
+		if !host.Properties.Lookup(hostproperty.FeaturesV1) {
+			continue
+		}
+		empty := true
+		err = host.Properties.LockForRead(hostproperty.FeaturesV1).ThenUse(func(clonable data.Clonable) error {
+			empty = len(clonable.(*propsv1.HostFeatures).Installed) == 0
+			return nil
+		})
+		if err != nil {
+			log.Errorf("drift: failed to read features of host '%s': %v", host.Name, err)
+			continue
+		}
+		if empty {
+			continue
+		}
+
+		inspected, err := handler.Inspect(ctx, host.ID)
+		if err != nil {
+			log.Errorf("drift: failed to inspect host '%s': %v", host.Name, err)
+			continue
+		}
+		// a stopped host can't be checked, its features didn't drift for all that
+		if inspected.LastState != hoststate.STARTED {
+			continue
+		}
+		target, err := install.NewHostTarget(srvutils.ToPBHost(inspected))
+		if err != nil {
+			log.Errorf("drift: failed to check features of host '%s': %v", host.Name, err)
+			continue
+		}
+		report(target, remediate)
+	}
+}
+
+// checkClusters checks the features installed on the running clusters
+func checkClusters(svc iaas.Service, remediate bool) {
+	task := concurrency.RootTask()
+	m, err := control.NewMetadata(svc)
+	if err != nil {
+		log.Errorf("drift: failed to access cluster metadata: %v", err)
+		return
+	}
+
+	var names []string
+	err = m.Browse(func(controller *control.Controller) error {
+		if controller.Identity.OK() {
+			names = append(names, controller.Identity.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("drift: failed to browse clusters: %v", err)
+		return
+	}
+
+	for _, name := range names {
+		instance, err := cluster.Load(task, name)
+		if err != nil {
+			log.Errorf("drift: failed to load cluster '%s': %v", name, err)
+			continue
+		}
+		state, err := instance.GetState(task)
+		if err != nil {
+			log.Errorf("drift: failed to get state of cluster '%s': %v", name, err)
+			continue
+		}
+		if state != clusterstate.Nominal && state != clusterstate.Degraded {
+			continue
+		}
+		target, err := install.NewClusterTarget(task, instance)
+		if err != nil {
+			log.Errorf("drift: failed to check features of cluster '%s': %v", name, err)
+			continue
+		}
+		report(target, remediate)
+	}
+}
+
+// report checks the features installed on the target and logs the features that drifted
+func report(target install.Target, remediate bool) {
+	statuses, err := install.CheckInstalledFeatures(concurrency.RootTask(), target, remediate)
+	if err != nil {
+		log.Errorf("drift: failed to check features of %s '%s': %v", target.Type(), target.Name(), err)
+		return
+	}
+	for _, s := range statuses {
+		switch s.Status {
+		case install.CheckStatusDrifted:
+			log.Warnf("drift: feature '%s' drifted on %s '%s': %s", s.Feature, target.Type(), target.Name(), s.Message)
+		case install.CheckStatusRemediated:
+			log.Warnf("drift: feature '%s' drifted on %s '%s' and has been added again", s.Feature, target.Type(), target.Name())
+		case install.CheckStatusFailed:
+			log.Errorf("drift: failed to check feature '%s' on %s '%s': %s", s.Feature, target.Type(), target.Name(), s.Message)
+		}
+	}
+}
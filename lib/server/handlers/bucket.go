@@ -17,14 +17,23 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
 
 	"github.com/CS-SI/SafeScale/lib/server/iaas"
 	"github.com/CS-SI/SafeScale/lib/server/iaas/objectstorage"
 	"github.com/CS-SI/SafeScale/lib/server/iaas/resources"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/hostproperty"
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/metadata"
+	"github.com/CS-SI/SafeScale/lib/system"
+	"github.com/CS-SI/SafeScale/lib/utils"
 	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
 	"github.com/CS-SI/SafeScale/lib/utils/scerr"
 )
 
@@ -36,6 +45,7 @@ type BucketAPI interface {
 	Create(context.Context, string) error
 	Delete(context.Context, string) error
 	Inspect(context.Context, string) (*resources.Bucket, error)
+	ReadFolder(context.Context, string, string, string) (map[string][]byte, error)
 	Mount(context.Context, string, string, string) error
 	Unmount(context.Context, string, string) error
 }
@@ -125,8 +135,48 @@ func (handler *BucketHandler) Inspect(ctx context.Context, name string) (mb *res
 	return mb, nil
 }
 
+// ReadFolder returns the content of the objects stored in the folder 'path' of the bucket, indexed by object name;
+// if 'suffix' is not empty, only the objects whose name ends with it are read
+func (handler *BucketHandler) ReadFolder(ctx context.Context, bucketName, path, suffix string) (objects map[string][]byte, err error) {
+	if handler == nil {
+		return nil, scerr.InvalidInstanceError()
+	}
+	if bucketName == "" {
+		return nil, scerr.InvalidParameterError("bucketName", "cannot be empty string")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s', '%s')", bucketName, path, suffix), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	names, err := handler.service.ListObjects(bucketName, path, objectstorage.NoPrefix)
+	if err != nil {
+		return nil, err
+	}
+	objects = map[string][]byte{}
+	for _, name := range names {
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		var buffer bytes.Buffer
+		err = handler.service.ReadObject(bucketName, name, &buffer, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		objects[name] = buffer.Bytes()
+	}
+	return objects, nil
+}
+
 // Mount a bucket on an host on the given mount point
 func (handler *BucketHandler) Mount(ctx context.Context, bucketName, hostName, path string) (err error) {
+	if handler == nil {
+		return scerr.InvalidInstanceError()
+	}
+	if bucketName == "" {
+		return scerr.InvalidParameterError("bucketName", "cannot be empty string")
+	}
+
 	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s', '%s')", bucketName, hostName, path), true).WithStopwatch().GoingIn()
 	defer tracer.OnExitTrace()()
 	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
@@ -149,51 +199,115 @@ func (handler *BucketHandler) Mount(ctx context.Context, bucketName, hostName, p
 	if path == resources.DefaultBucketMountPoint {
 		mountPoint = resources.DefaultBucketMountPoint + bucketName
 	}
+	mountPoint, err = sanitize(mountPoint)
+	if err != nil {
+		return err
+	}
+
+	cfg := handler.service.GetConfig()
+	mounter, err := newBucketMounter(cfg)
+	if err != nil {
+		return err
+	}
+	export := bucketExport(cfg.Type, bucketName)
+
+	// Check the bucket isn't already mounted and the mount point is free
+	err = host.Properties.LockForRead(hostproperty.MountsV1).ThenUse(func(clonable data.Clonable) error {
+		hostMountsV1 := clonable.(*propsv1.HostMounts)
+		if p, ok := hostMountsV1.RemoteMountsByExport[export]; ok {
+			return fmt.Errorf("bucket '%s' is already mounted in '%s:%s'", bucketName, host.Name, p)
+		}
+		if _, ok := hostMountsV1.LocalMountsByPath[mountPoint]; ok {
+			return fmt.Errorf("there is already a volume mounted in '%s:%s'", host.Name, mountPoint)
+		}
+		if _, ok := hostMountsV1.RemoteMountsByPath[mountPoint]; ok {
+			return fmt.Errorf("there is already something mounted in '%s:%s'", host.Name, mountPoint)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Pushes the credentials in a file readable only by the remote user, to keep them out of command lines and scripts
+	credentials, err := mounter.Credentials()
+	if err != nil {
+		return err
+	}
+	f, err := system.CreateTempFileFromString(credentials, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %s", err.Error())
+	}
+	defer func() {
+		if derr := utils.LazyRemove(f.Name()); derr != nil {
+			logrus.Warnf("failed to remove temporary file '%s': %v", f.Name(), derr)
+		}
+	}()
+	sshHandler := NewSSHHandler(handler.service)
+	sshConfig, err := sshHandler.GetConfig(ctx, host)
+	if err != nil {
+		return err
+	}
+	uploaded := fmt.Sprintf("/tmp/bucket-%s.credentials", bucketName)
+	retcode, _, stderr, err := sshConfig.Copy(uploaded, f.Name(), true)
+	if err != nil {
+		return fmt.Errorf("failed to upload credentials to host '%s': %s", host.Name, err.Error())
+	}
+	if retcode != 0 {
+		return fmt.Errorf("failed to upload credentials to host '%s': %s", host.Name, stderr)
+	}
 
-	authOpts, _ := handler.service.GetAuthenticationOptions()
-	authurlCfg, _ := authOpts.Config("AuthUrl")
-	authurl := authurlCfg.(string)
-	authurl = regexp.MustCompile("https?:/+(.*)/.*").FindStringSubmatch(authurl)[1]
-	tenantCfg, _ := authOpts.Config("TenantName")
-	tenant := tenantCfg.(string)
-	loginCfg, _ := authOpts.Config("Login")
-	login := loginCfg.(string)
-	passwordCfg, _ := authOpts.Config("Password")
-	password := passwordCfg.(string)
-	regionCfg, _ := authOpts.Config("Region")
-	region := regionCfg.(string)
-
-	objStorageProtocol := handler.service.GetType()
-	if objStorageProtocol == "swift" {
-		objStorageProtocol = "swiftks"
-	}
-
-	data := struct {
-		Bucket     string
-		Tenant     string
-		Login      string
-		Password   string
-		AuthURL    string
-		Region     string
-		MountPoint string
-		Protocol   string
-	}{
-		Bucket:     bucketName,
-		Tenant:     tenant,
-		Login:      login,
-		Password:   password,
-		AuthURL:    authurl,
-		Region:     region,
-		MountPoint: mountPoint,
-		Protocol:   objStorageProtocol,
-	}
-
-	rerr := exec(ctx, "mount_object_storage.sh", data, host.ID, handler.service)
-	return rerr
+	bashLibrary, err := system.GetBashLibrary()
+	if err != nil {
+		return err
+	}
+	credentialsFile := bucketCredentialsFolder + "/" + bucketName + ".conf"
+	params := map[string]interface{}{
+		"reserved_BashLibrary": bashLibrary,
+		"Bucket":               bucketName,
+		"MountPoint":           mountPoint,
+		"Tool":                 mounter.Tool(),
+		"Command":              mounter.Command(bucketName, mountPoint, credentialsFile),
+		"Unit":                 bucketUnit(bucketName),
+		"UploadedCredentials":  uploaded,
+		"CredentialsFile":      credentialsFile,
+	}
+	err = exec(ctx, "mount_object_storage.sh", params, host.ID, handler.service)
+	if err != nil {
+		return err
+	}
+
+	// Records the mount in host metadata
+	err = host.Properties.LockForWrite(hostproperty.MountsV1).ThenUse(func(clonable data.Clonable) error {
+		hostMountsV1 := clonable.(*propsv1.HostMounts)
+		// Make sure the HostMounts is correctly init if there are no mount yet
+		if !host.Properties.Lookup(hostproperty.MountsV1) {
+			hostMountsV1.Reset()
+		}
+		mount := propsv1.NewHostRemoteMount()
+		mount.Export = export
+		mount.Path = mountPoint
+		mount.FileSystem = mounter.FileSystem()
+		hostMountsV1.RemoteMountsByPath[mount.Path] = mount
+		hostMountsV1.RemoteMountsByExport[mount.Export] = mount.Path
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = metadata.SaveHost(handler.service, host)
+	return err
 }
 
 // Unmount a bucket
 func (handler *BucketHandler) Unmount(ctx context.Context, bucketName, hostName string) (err error) {
+	if handler == nil {
+		return scerr.InvalidInstanceError()
+	}
+	if bucketName == "" {
+		return scerr.InvalidParameterError("bucketName", "cannot be empty string")
+	}
+
 	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s')", bucketName, hostName), true).WithStopwatch().GoingIn()
 	defer tracer.OnExitTrace()()
 	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
@@ -217,12 +331,52 @@ func (handler *BucketHandler) Unmount(ctx context.Context, bucketName, hostName
 		return err
 	}
 
-	data := struct {
-		Bucket string
-	}{
-		Bucket: bucketName,
+	export := bucketExport(handler.service.GetType(), bucketName)
+	var mountPoint string
+	err = host.Properties.LockForRead(hostproperty.MountsV1).ThenUse(func(clonable data.Clonable) error {
+		var ok bool
+		mountPoint, ok = clonable.(*propsv1.HostMounts).RemoteMountsByExport[export]
+		if !ok {
+			return fmt.Errorf("bucket '%s' is not mounted on host '%s'", bucketName, host.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{
+		"MountPoint":      mountPoint,
+		"Unit":            bucketUnit(bucketName),
+		"CredentialsFile": bucketCredentialsFolder + "/" + bucketName + ".conf",
+	}
+	err = exec(ctx, "umount_object_storage.sh", params, host.ID, handler.service)
+	if err != nil {
+		return err
+	}
+
+	err = host.Properties.LockForWrite(hostproperty.MountsV1).ThenUse(func(clonable data.Clonable) error {
+		hostMountsV1 := clonable.(*propsv1.HostMounts)
+		delete(hostMountsV1.RemoteMountsByPath, mountPoint)
+		delete(hostMountsV1.RemoteMountsByExport, export)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	_, err = metadata.SaveHost(handler.service, host)
+	return err
+}
+
+// bucketCredentialsFolder is the folder on hosts where the credentials to mount buckets are stored
+const bucketCredentialsFolder = "/etc/safescale/buckets"
+
+// bucketExport returns the identifier of a bucket in host mounts
+func bucketExport(storageType, bucketName string) string {
+	return storageType + "://" + bucketName
+}
 
-	rerr := exec(ctx, "umount_object_storage.sh", data, host.ID, handler.service)
-	return rerr
+// bucketUnit returns the name of the systemd unit handling the mount of the bucket
+func bucketUnit(bucketName string) string {
+	return "safescale-bucket-" + bucketName + ".service"
 }
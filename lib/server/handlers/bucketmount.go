@@ -0,0 +1,158 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/CS-SI/SafeScale/lib/server/iaas/objectstorage"
+)
+
+// bucketMounter describes how a tool mounts a bucket of an Object Storage as a filesystem
+type bucketMounter interface {
+	// FileSystem returns the filesystem type as recorded in host mounts
+	FileSystem() string
+	// Tool returns the name of the tool, as known by mount_object_storage.sh to install it
+	Tool() string
+	// Credentials returns the content of the file holding the credentials needed to mount the bucket
+	Credentials() (string, error)
+	// Command returns the command line mounting the bucket in foreground, reading credentials from credentialsFile
+	Command(bucketName, mountPoint, credentialsFile string) string
+}
+
+// newBucketMounter returns the bucketMounter to use for the Object Storage described by cfg
+// rclone supports all the Object Storage types; s3fs is preferred for S3, being lighter
+func newBucketMounter(cfg objectstorage.Config) (bucketMounter, error) {
+	switch cfg.Type {
+	case "s3":
+		return &s3fsMounter{cfg: cfg}, nil
+	case "swift", "google":
+		return &rcloneMounter{cfg: cfg}, nil
+	default:
+		return nil, fmt.Errorf("mount of buckets of Object Storage type '%s' is not supported", cfg.Type)
+	}
+}
+
+// rcloneRemote is the name of the rclone remote defined in the credentials file
+const rcloneRemote = "safescale"
+
+// rcloneMounter mounts buckets using rclone
+type rcloneMounter struct {
+	cfg objectstorage.Config
+}
+
+// FileSystem ...
+func (m *rcloneMounter) FileSystem() string {
+	return "fuse.rclone"
+}
+
+// Tool ...
+func (m *rcloneMounter) Tool() string {
+	return "rclone"
+}
+
+// Credentials returns a rclone configuration file defining the remote
+func (m *rcloneMounter) Credentials() (string, error) {
+	lines := []string{"[" + rcloneRemote + "]"}
+	switch m.cfg.Type {
+	case "swift":
+		lines = append(lines,
+			"type = swift",
+			"user = "+m.cfg.User,
+			"key = "+m.cfg.SecretKey,
+			"auth = "+m.cfg.AuthURL,
+			"tenant = "+m.cfg.Tenant,
+			"domain = "+m.cfg.Domain,
+			"tenant_domain = "+m.cfg.TenantDomain,
+			"region = "+m.cfg.Region,
+		)
+		if m.cfg.AuthVersion > 0 {
+			lines = append(lines, fmt.Sprintf("auth_version = %d", m.cfg.AuthVersion))
+		}
+	case "s3":
+		lines = append(lines,
+			"type = s3",
+			"provider = Other",
+			"access_key_id = "+m.cfg.User,
+			"secret_access_key = "+m.cfg.SecretKey,
+			"endpoint = "+m.cfg.Endpoint,
+			"region = "+m.cfg.Region,
+		)
+	case "google":
+		// rclone expects the service account credentials on a single line
+		var compact bytes.Buffer
+		err := json.Compact(&compact, []byte(m.cfg.Credentials))
+		if err != nil {
+			return "", fmt.Errorf("invalid Google credentials: %s", err.Error())
+		}
+		lines = append(lines,
+			"type = google cloud storage",
+			"project_number = "+m.cfg.ProjectID,
+			"service_account_credentials = "+compact.String(),
+		)
+	default:
+		return "", fmt.Errorf("rclone: unsupported Object Storage type '%s'", m.cfg.Type)
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// Command ...
+func (m *rcloneMounter) Command(bucketName, mountPoint, credentialsFile string) string {
+	return fmt.Sprintf("/usr/bin/rclone mount --config %s --allow-other --vfs-cache-mode writes %s:%s %s", credentialsFile, rcloneRemote, bucketName, mountPoint)
+}
+
+// s3fsMounter mounts S3 buckets using s3fs-fuse
+type s3fsMounter struct {
+	cfg objectstorage.Config
+}
+
+// FileSystem ...
+func (m *s3fsMounter) FileSystem() string {
+	return "fuse.s3fs"
+}
+
+// Tool ...
+func (m *s3fsMounter) Tool() string {
+	return "s3fs"
+}
+
+// Credentials returns a s3fs password file
+func (m *s3fsMounter) Credentials() (string, error) {
+	if m.cfg.User == "" || m.cfg.SecretKey == "" {
+		return "", fmt.Errorf("s3fs: missing access key or secret key in Object Storage configuration")
+	}
+	return m.cfg.User + ":" + m.cfg.SecretKey + "\n", nil
+}
+
+// Command ...
+func (m *s3fsMounter) Command(bucketName, mountPoint, credentialsFile string) string {
+	cmd := fmt.Sprintf("/usr/bin/s3fs %s %s -f -o passwd_file=%s -o allow_other", bucketName, mountPoint, credentialsFile)
+	if m.cfg.Endpoint != "" {
+		url := m.cfg.Endpoint
+		if !strings.Contains(url, "://") {
+			url = "https://" + url
+		}
+		cmd += " -o use_path_request_style -o url=" + url
+	}
+	if m.cfg.Region != "" {
+		cmd += " -o endpoint=" + m.cfg.Region
+	}
+	return cmd
+}
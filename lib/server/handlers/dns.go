@@ -0,0 +1,232 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/CS-SI/SafeScale/lib/server/iaas"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/networkproperty"
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/metadata"
+	"github.com/CS-SI/SafeScale/lib/system"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+)
+
+// dnsRootDomain is the domain under which each network gets its zone, <network>.safescale
+const dnsRootDomain = "safescale"
+
+// networkMetadataMutex serializes the updates of the metadata of networks made on behalf of their hosts; the copy of
+// the network a caller holds may have been loaded long before (at the beginning of a host creation for instance),
+// concurrent updates starting from such copies would overwrite each other
+var networkMetadataMutex sync.Mutex
+
+// updateNetworkMetadata applies fn to the network identified by networkID as currently recorded in metadata, then
+// saves and returns it; networkMetadataMutex has to be held by the caller
+func updateNetworkMetadata(svc iaas.Service, networkID string, fn func(*resources.Network) error) (*resources.Network, error) {
+	mn, err := metadata.LoadNetwork(svc, networkID)
+	if err != nil {
+		return nil, err
+	}
+	network, err := mn.Get()
+	if err != nil {
+		return nil, err
+	}
+	err = fn(network)
+	if err != nil {
+		return nil, err
+	}
+	err = mn.Write()
+	if err != nil {
+		return nil, err
+	}
+	return network, nil
+}
+
+// dnsLabel converts the name of a resource to a valid DNS label
+func dnsLabel(name string) string {
+	label := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '-'
+		}
+	}, name)
+	if len(label) > 63 {
+		label = label[:63]
+	}
+	return strings.Trim(label, "-")
+}
+
+// networkDomain returns the domain of the zone of the network
+func networkDomain(network *resources.Network) string {
+	return dnsLabel(network.Name) + "." + dnsRootDomain
+}
+
+// networkDNSDomain returns the domain of the zone served by the gateways of the network, empty if they serve none
+func networkDNSDomain(network *resources.Network) (domain string) {
+	_ = network.Properties.LockForRead(networkproperty.DNSV1).ThenUse(func(clonable data.Clonable) error {
+		domain = clonable.(*propsv1.NetworkDNS).Domain
+		return nil
+	})
+	return domain
+}
+
+// setUpDNS installs the DNS server of the network on the gateways and adds them to the records already in the zone
+func (handler *NetworkHandler) setUpDNS(ctx context.Context, network *resources.Network, gateways []*resources.Host) error {
+	var upstream []string
+	cfg, err := handler.service.GetConfigurationOptions()
+	if err == nil {
+		upstream = cfg.GetSliceOfStrings("DNSList")
+	}
+	if len(upstream) == 0 {
+		upstream = []string{"1.1.1.1"}
+	}
+	bashLibrary, err := system.GetBashLibrary()
+	if err != nil {
+		return err
+	}
+
+	domain := networkDomain(network)
+	records := map[string]string{}
+	for _, gw := range gateways {
+		listen := gw.GetPrivateIP()
+		if network.VIP != nil && network.VIP.PrivateIP != "" {
+			listen += "," + network.VIP.PrivateIP
+		}
+		params := map[string]interface{}{
+			"reserved_BashLibrary": bashLibrary,
+			"Domain":               domain,
+			"CIDR":                 network.CIDR,
+			"ListenAddresses":      listen,
+			"Upstream":             upstream,
+		}
+		err = exec(ctx, "dns_server_setup.sh", params, gw.ID, handler.service)
+		if err != nil {
+			return fmt.Errorf("failed to set up DNS server on gateway '%s': %s", gw.Name, err.Error())
+		}
+		records[dnsLabel(gw.Name)] = gw.GetPrivateIP()
+	}
+
+	err = network.Properties.LockForWrite(networkproperty.DNSV1).ThenUse(func(clonable data.Clonable) error {
+		networkDNSV1 := clonable.(*propsv1.NetworkDNS)
+		networkDNSV1.Domain = domain
+		if networkDNSV1.Records == nil {
+			networkDNSV1.Records = map[string]string{}
+		}
+		for name, ip := range records {
+			networkDNSV1.Records[name] = ip
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = metadata.SaveNetwork(handler.service, network)
+	if err != nil {
+		return err
+	}
+	return pushDNSZone(ctx, handler.service, network)
+}
+
+// registerDNSRecord adds the record of a host to the zone of the network, or removes it if ip is empty, then pushes the
+// zone to the gateways; nothing is done if the gateways of the network serve no zone
+// The record is changed in the zone currently recorded, the records registered since network has been loaded are kept,
+// and the zone is pushed before any other change so the gateways end up with the last version.
+func registerDNSRecord(ctx context.Context, svc iaas.Service, network *resources.Network, hostName, ip string) error {
+	networkMetadataMutex.Lock()
+	defer networkMetadataMutex.Unlock()
+
+	enabled := false
+	update := func(n *resources.Network) error {
+		return n.Properties.LockForWrite(networkproperty.DNSV1).ThenUse(func(clonable data.Clonable) error {
+			networkDNSV1 := clonable.(*propsv1.NetworkDNS)
+			if networkDNSV1.Domain == "" {
+				return nil
+			}
+			enabled = true
+			if networkDNSV1.Records == nil {
+				networkDNSV1.Records = map[string]string{}
+			}
+			if ip == "" {
+				delete(networkDNSV1.Records, dnsLabel(hostName))
+			} else {
+				networkDNSV1.Records[dnsLabel(hostName)] = ip
+			}
+			return nil
+		})
+	}
+	// The copy of the caller is kept in line with what is recorded
+	err := update(network)
+	if err != nil || !enabled {
+		return err
+	}
+	current, err := updateNetworkMetadata(svc, network.ID, update)
+	if err != nil {
+		return err
+	}
+	return pushDNSZone(ctx, svc, current)
+}
+
+// pushDNSZone writes the whole zone of the network on each of its gateways
+func pushDNSZone(ctx context.Context, svc iaas.Service, network *resources.Network) error {
+	var hosts string
+	err := network.Properties.LockForRead(networkproperty.DNSV1).ThenUse(func(clonable data.Clonable) error {
+		hosts = dnsHostsContent(clonable.(*propsv1.NetworkDNS))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, id := range []string{network.GatewayID, network.SecondaryGatewayID} {
+		if id == "" {
+			continue
+		}
+		err = exec(ctx, "dns_zone_update.sh", map[string]interface{}{"Hosts": hosts}, id, svc)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("gateway '%s': %s", id, err.Error()))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to update the zone of network '%s': %s", network.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// dnsHostsContent returns the records of the zone in the format of /etc/hosts, sorted by name
+func dnsHostsContent(zone *propsv1.NetworkDNS) string {
+	names := make([]string, 0, len(zone.Records))
+	for name := range zone.Records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s %s.%s %s", zone.Records[name], name, zone.Domain, name))
+	}
+	return strings.Join(lines, "\n")
+}
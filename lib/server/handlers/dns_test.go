@@ -0,0 +1,42 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+)
+
+func TestDNSLabel(t *testing.T) {
+	assert.Equal(t, "gw-net-1", dnsLabel("gw-net-1"))
+	assert.Equal(t, "my-network", dnsLabel("My_Network"))
+	assert.Equal(t, "host", dnsLabel("_host."))
+	assert.Equal(t, 63, len(dnsLabel(strings.Repeat("a", 80))))
+}
+
+func TestDNSHostsContent(t *testing.T) {
+	zone := propsv1.NewNetworkDNS()
+	zone.Domain = "net.safescale"
+	zone.Records["host-2"] = "192.168.0.12"
+	zone.Records["gw-net"] = "192.168.0.1"
+	assert.Equal(t, "192.168.0.1 gw-net.net.safescale gw-net\n192.168.0.12 host-2.net.safescale host-2", dnsHostsContent(zone))
+	assert.Empty(t, dnsHostsContent(propsv1.NewNetworkDNS()))
+}
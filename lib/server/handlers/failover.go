@@ -0,0 +1,282 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources"
+	"github.com/CS-SI/SafeScale/lib/server/metadata"
+	"github.com/CS-SI/SafeScale/lib/system"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+)
+
+// loadNetworkForFailover loads the network and its primary gateway
+func (handler *NetworkHandler) loadNetworkForFailover(ref string) (*resources.Network, *resources.Host, error) {
+	mn, err := metadata.LoadNetwork(handler.service, ref)
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotFound); ok {
+			return nil, nil, resources.ResourceNotFoundError("network", ref)
+		}
+		return nil, nil, err
+	}
+	network, err := mn.Get()
+	if err != nil {
+		return nil, nil, err
+	}
+	if network.GatewayID == "" {
+		return nil, nil, scerr.InvalidRequestError(fmt.Sprintf("network '%s' has no gateway", network.Name))
+	}
+	mh, err := metadata.LoadHost(handler.service, network.GatewayID)
+	if err != nil {
+		return nil, nil, err
+	}
+	gw, err := mh.Get()
+	if err != nil {
+		return nil, nil, err
+	}
+	return network, gw, nil
+}
+
+// EnableFailover makes the default route of a network created with a single gateway highly available: a VIP is
+// created, a secondary gateway sized like the primary one is added, both gateways hold the VIP with keepalived, and
+// the hosts of the network route their traffic through the VIP
+func (handler *NetworkHandler) EnableFailover(ctx context.Context, ref string, theos string) (err error) {
+	if handler == nil {
+		return scerr.InvalidInstanceError()
+	}
+	if ref == "" {
+		return scerr.InvalidParameterError("ref", "cannot be empty string")
+	}
+	if theos == "" {
+		return scerr.InvalidParameterError("theos", "cannot be empty string")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s')", ref, theos), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	network, primaryGateway, err := handler.loadNetworkForFailover(ref)
+	if err != nil {
+		return err
+	}
+	if network.SecondaryGatewayID != "" || network.VIP != nil {
+		return scerr.InvalidRequestError(fmt.Sprintf("failover is already enabled on network '%s'", network.Name))
+	}
+	if !handler.service.GetCapabilities().PrivateVirtualIP {
+		return scerr.InvalidRequestError("provider doesn't support private Virtual IP, cannot set up high availability of network default route")
+	}
+	sizing, err := requestedGatewaySizing(primaryGateway)
+	if err != nil {
+		return err
+	}
+	bashLibrary, err := system.GetBashLibrary()
+	if err != nil {
+		return err
+	}
+
+	vip, err := handler.service.CreateVIP(network.ID, fmt.Sprintf("for gateways of network %s", network.Name))
+	if err != nil {
+		return err
+	}
+
+	// Starting from here, deletes the VIP and restores the metadata of the network if exiting with error before
+	// failover is in place
+	enabled := false
+	defer func() {
+		if err != nil && !enabled {
+			derr := handler.service.UnbindHostFromVIP(vip, primaryGateway.ID)
+			if derr != nil {
+				logrus.Errorf("failed to unbind primary gateway from VIP: %v", derr)
+			}
+			derr = handler.service.DeleteVIP(vip)
+			if derr != nil {
+				logrus.Errorf("failed to delete VIP: %+v", derr)
+				err = scerr.AddConsequence(err, derr)
+			}
+			network.VIP = nil
+			_, derr = metadata.SaveNetwork(handler.service, network)
+			if derr != nil {
+				err = scerr.AddConsequence(err, derr)
+			}
+		}
+	}()
+
+	err = handler.service.AddPublicIPToVIP(vip)
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotImplemented); !ok {
+			return err
+		}
+		logrus.Debugf("provider cannot add a public IP to the VIP, the peers of the network will reach it through the public IP of its primary gateway")
+	}
+	err = handler.service.BindHostToVIP(vip, primaryGateway.ID)
+	if err != nil {
+		return err
+	}
+	network.VIP = vip
+	_, err = metadata.SaveNetwork(handler.service, network)
+	if err != nil {
+		return err
+	}
+
+	// The secondary gateway gets keepalived from phase 2 of its userdata, the primary one is configured afterwards
+	secondaryGateway, err := handler.addGateway(ctx, network, "gw2-"+network.Name, sizing, theos, false, primaryGateway)
+	if err != nil {
+		return err
+	}
+	params := map[string]interface{}{
+		"reserved_BashLibrary": bashLibrary,
+		"Primary":              true,
+		"SourceIP":             primaryGateway.GetPrivateIP(),
+		"PeerIP":               secondaryGateway.GetPrivateIP(),
+		"VIP":                  vip.PrivateIP,
+		"CIDR":                 network.CIDR,
+	}
+	err = exec(ctx, "keepalived_setup.sh", params, primaryGateway.ID, handler.service)
+	if err != nil {
+		err = fmt.Errorf("failed to set up keepalived on gateway '%s': %s", primaryGateway.Name, err.Error())
+		derr := handler.dropGateway(network, secondaryGateway)
+		if derr != nil {
+			err = scerr.AddConsequence(err, derr)
+		}
+		return err
+	}
+
+	// Recording the metadata of the secondary gateway made the network point to it, the whole network is written back
+	network.SecondaryGatewayID = secondaryGateway.ID
+	_, err = metadata.SaveNetwork(handler.service, network)
+	if err != nil {
+		network.SecondaryGatewayID = ""
+		derr := handler.dropGateway(network, secondaryGateway)
+		if derr != nil {
+			err = scerr.AddConsequence(err, derr)
+		}
+		return err
+	}
+	enabled = true
+	logrus.Infof("Failover enabled on network '%s', with secondary gateway '%s'", network.Name, secondaryGateway.Name)
+
+	// From here, failover is in place; failures are reported once everything has been tried
+	var errs []string
+	from := defaultRoute{gatewayID: primaryGateway.ID, ip: primaryGateway.GetPrivateIP()}
+	to := defaultRoute{gatewayID: primaryGateway.ID, ip: vip.PrivateIP}
+	if err := handler.rerouteHosts(ctx, network, from, to); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if networkDNSDomain(network) != "" {
+		if err := handler.setUpDNS(ctx, network, []*resources.Host{primaryGateway, secondaryGateway}); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if err := applyForwards(ctx, handler.service, network); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := handler.renewPeerings(ctx, network); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failover enabled on network '%s' with errors: %s", network.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// DisableFailover brings a network with failover back to a single gateway: the hosts of the network route their
+// traffic through the primary gateway, then the secondary gateway and the VIP are deleted
+func (handler *NetworkHandler) DisableFailover(ctx context.Context, ref string) (err error) {
+	if handler == nil {
+		return scerr.InvalidInstanceError()
+	}
+	if ref == "" {
+		return scerr.InvalidParameterError("ref", "cannot be empty string")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	network, primaryGateway, err := handler.loadNetworkForFailover(ref)
+	if err != nil {
+		return err
+	}
+	if network.SecondaryGatewayID == "" || network.VIP == nil {
+		return scerr.InvalidRequestError(fmt.Sprintf("failover is not enabled on network '%s'", network.Name))
+	}
+	mh, err := metadata.LoadHost(handler.service, network.SecondaryGatewayID)
+	if err != nil {
+		return err
+	}
+	secondaryGateway, err := mh.Get()
+	if err != nil {
+		return err
+	}
+
+	// The hosts are moved first, for the primary gateway to keep routing their traffic once the VIP is gone
+	vip := network.VIP
+	from := defaultRoute{gatewayID: primaryGateway.ID, ip: vip.PrivateIP}
+	to := defaultRoute{gatewayID: primaryGateway.ID, ip: primaryGateway.GetPrivateIP()}
+	err = handler.rerouteHosts(ctx, network, from, to)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	if err := exec(ctx, "keepalived_remove.sh", nil, secondaryGateway.ID, handler.service); err != nil {
+		logrus.Warnf("failed to stop keepalived on gateway '%s': %v", secondaryGateway.Name, err)
+	}
+	if err := exec(ctx, "keepalived_remove.sh", nil, primaryGateway.ID, handler.service); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to stop keepalived on gateway '%s': %s", primaryGateway.Name, err.Error()))
+	}
+
+	network.SecondaryGatewayID = ""
+	network.VIP = nil
+	_, err = metadata.SaveNetwork(handler.service, network)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Failover disabled on network '%s'", network.Name)
+
+	if err := handler.destroyGateway(vip, secondaryGateway, mh); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := handler.service.UnbindHostFromVIP(vip, primaryGateway.ID); err != nil {
+		logrus.Warnf("failed to unbind primary gateway from VIP: %v", err)
+	}
+	if err := handler.service.DeleteVIP(vip); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to delete VIP: %s", err.Error()))
+	}
+	if networkDNSDomain(network) != "" {
+		err = handler.setUpDNS(ctx, network, []*resources.Host{primaryGateway})
+		if err == nil {
+			err = registerDNSRecord(ctx, handler.service, network, secondaryGateway.Name, "")
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if err := handler.renewPeerings(ctx, network); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failover disabled on network '%s' with errors: %s", network.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/CS-SI/SafeScale/lib/server/iaas"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/hostproperty"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/networkproperty"
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/metadata"
+	"github.com/CS-SI/SafeScale/lib/system"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+)
+
+// forwardKey returns the index of a forwarding rule in the metadata of the network
+func forwardKey(protocol string, publicPort int) string {
+	return fmt.Sprintf("%s/%d", protocol, publicPort)
+}
+
+// validateForward checks the protocol and the ports of a forwarding rule
+func validateForward(protocol string, publicPort, port int) error {
+	switch protocol {
+	case "tcp", "udp":
+	default:
+		return scerr.InvalidParameterError("protocol", fmt.Sprintf("must be 'tcp' or 'udp', not '%s'", protocol))
+	}
+	if publicPort < 1 || publicPort > 65535 {
+		return scerr.InvalidParameterError("publicPort", "must be between 1 and 65535")
+	}
+	if port < 1 || port > 65535 {
+		return scerr.InvalidParameterError("port", "must be between 1 and 65535")
+	}
+	// SSH of the gateway(s) must remain reachable
+	if protocol == "tcp" && publicPort == 22 {
+		return scerr.InvalidRequestError("public port 22/tcp is reserved to SSH access to the gateway")
+	}
+	return nil
+}
+
+// loadNetworkForForward loads the network and checks it has a gateway to carry forwarding rules
+func (handler *NetworkHandler) loadNetworkForForward(ref string) (*resources.Network, error) {
+	mn, err := metadata.LoadNetwork(handler.service, ref)
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotFound); ok {
+			return nil, resources.ResourceNotFoundError("network", ref)
+		}
+		return nil, err
+	}
+	network, err := mn.Get()
+	if err != nil {
+		return nil, err
+	}
+	if network.GatewayID == "" {
+		return nil, scerr.InvalidRequestError(fmt.Sprintf("network '%s' has no gateway", network.Name))
+	}
+	return network, nil
+}
+
+// AddForward forwards the traffic received by the gateway(s) of the network on a public port to a port of a host of the network
+func (handler *NetworkHandler) AddForward(ctx context.Context, ref, protocol string, publicPort int, hostRef string, port int) (forward *propsv1.NetworkForward, err error) {
+	if handler == nil {
+		return nil, scerr.InvalidInstanceError()
+	}
+	if ref == "" {
+		return nil, scerr.InvalidParameterError("ref", "cannot be empty string")
+	}
+	if hostRef == "" {
+		return nil, scerr.InvalidParameterError("hostRef", "cannot be empty string")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', %s/%d, '%s', %d)", ref, protocol, publicPort, hostRef, port), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	err = validateForward(protocol, publicPort, port)
+	if err != nil {
+		return nil, err
+	}
+	network, err := handler.loadNetworkForForward(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	mh, err := metadata.LoadHost(handler.service, hostRef)
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotFound); ok {
+			return nil, resources.ResourceNotFoundError("host", hostRef)
+		}
+		return nil, err
+	}
+	host, err := mh.Get()
+	if err != nil {
+		return nil, err
+	}
+	forward = &propsv1.NetworkForward{
+		Protocol:   protocol,
+		PublicPort: publicPort,
+		HostID:     host.ID,
+		HostName:   host.Name,
+		Port:       port,
+	}
+	err = host.Properties.LockForRead(hostproperty.NetworkV1).ThenUse(func(clonable data.Clonable) error {
+		hostNetworkV1 := clonable.(*propsv1.HostNetwork)
+		if hostNetworkV1.IsGateway {
+			return scerr.InvalidRequestError(fmt.Sprintf("cannot forward traffic to gateway '%s'", host.Name))
+		}
+		forward.HostIP = hostNetworkV1.IPv4Addresses[network.ID]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if forward.HostIP == "" {
+		return nil, scerr.InvalidRequestError(fmt.Sprintf("host '%s' is not attached to network '%s'", host.Name, network.Name))
+	}
+
+	key := forwardKey(protocol, publicPort)
+	err = network.Properties.LockForWrite(networkproperty.ForwardsV1).ThenUse(func(clonable data.Clonable) error {
+		networkForwardsV1 := clonable.(*propsv1.NetworkForwards)
+		if _, ok := networkForwardsV1.ByPublicPort[key]; ok {
+			return resources.ResourceDuplicateError("forwarding rule", key)
+		}
+		networkForwardsV1.ByPublicPort[key] = forward
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = metadata.SaveNetwork(handler.service, network)
+	if err != nil {
+		return nil, err
+	}
+	return forward, applyForwards(ctx, handler.service, network)
+}
+
+// ListForwards returns the forwarding rules of the network, sorted by protocol and public port
+func (handler *NetworkHandler) ListForwards(ctx context.Context, ref string) (list []*propsv1.NetworkForward, err error) {
+	if handler == nil {
+		return nil, scerr.InvalidInstanceError()
+	}
+	if ref == "" {
+		return nil, scerr.InvalidParameterError("ref", "cannot be empty string")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	network, err := handler.loadNetworkForForward(ref)
+	if err != nil {
+		return nil, err
+	}
+	return networkForwards(network)
+}
+
+// DeleteForward removes the forwarding rule of the network using a public port
+func (handler *NetworkHandler) DeleteForward(ctx context.Context, ref, protocol string, publicPort int) (err error) {
+	if handler == nil {
+		return scerr.InvalidInstanceError()
+	}
+	if ref == "" {
+		return scerr.InvalidParameterError("ref", "cannot be empty string")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', %s/%d)", ref, protocol, publicPort), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	network, err := handler.loadNetworkForForward(ref)
+	if err != nil {
+		return err
+	}
+	key := forwardKey(protocol, publicPort)
+	err = network.Properties.LockForWrite(networkproperty.ForwardsV1).ThenUse(func(clonable data.Clonable) error {
+		networkForwardsV1 := clonable.(*propsv1.NetworkForwards)
+		if _, ok := networkForwardsV1.ByPublicPort[key]; !ok {
+			return resources.ResourceNotFoundError("forwarding rule", key)
+		}
+		delete(networkForwardsV1.ByPublicPort, key)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = metadata.SaveNetwork(handler.service, network)
+	if err != nil {
+		return err
+	}
+	return applyForwards(ctx, handler.service, network)
+}
+
+// removeHostForwards drops the forwarding rules of the network targeting a host, then updates the gateways if needed
+func removeHostForwards(ctx context.Context, svc iaas.Service, network *resources.Network, hostID string) error {
+	changed := false
+	err := network.Properties.LockForWrite(networkproperty.ForwardsV1).ThenUse(func(clonable data.Clonable) error {
+		networkForwardsV1 := clonable.(*propsv1.NetworkForwards)
+		for k, v := range networkForwardsV1.ByPublicPort {
+			if v.HostID == hostID {
+				delete(networkForwardsV1.ByPublicPort, k)
+				changed = true
+			}
+		}
+		return nil
+	})
+	if err != nil || !changed {
+		return err
+	}
+	_, err = metadata.SaveNetwork(svc, network)
+	if err != nil {
+		return err
+	}
+	return applyForwards(ctx, svc, network)
+}
+
+// networkForwards returns the forwarding rules recorded in the metadata of the network, sorted by protocol and public port
+func networkForwards(network *resources.Network) ([]*propsv1.NetworkForward, error) {
+	var list []*propsv1.NetworkForward
+	err := network.Properties.LockForRead(networkproperty.ForwardsV1).ThenUse(func(clonable data.Clonable) error {
+		for _, v := range clonable.(*propsv1.NetworkForwards).ByPublicPort {
+			forward := *v
+			list = append(list, &forward)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Protocol != list[j].Protocol {
+			return list[i].Protocol < list[j].Protocol
+		}
+		return list[i].PublicPort < list[j].PublicPort
+	})
+	return list, nil
+}
+
+// applyForwards sets on each gateway of the network the whole set of its forwarding rules
+func applyForwards(ctx context.Context, svc iaas.Service, network *resources.Network) error {
+	forwards, err := networkForwards(network)
+	if err != nil {
+		return err
+	}
+	bashLibrary, err := system.GetBashLibrary()
+	if err != nil {
+		return err
+	}
+	params := map[string]interface{}{
+		"reserved_BashLibrary": bashLibrary,
+		"Forwards":             forwards,
+	}
+
+	var errs []string
+	for _, id := range []string{network.GatewayID, network.SecondaryGatewayID} {
+		if id == "" {
+			continue
+		}
+		err = exec(ctx, "forward_sync.sh", params, id, svc)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("gateway '%s': %s", id, err.Error()))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to apply forwarding rules of network '%s': %s", network.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
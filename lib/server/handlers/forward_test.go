@@ -0,0 +1,33 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateForward(t *testing.T) {
+	assert.NoError(t, validateForward("tcp", 8080, 80))
+	assert.NoError(t, validateForward("udp", 22, 22))
+	assert.Error(t, validateForward("icmp", 8080, 80))
+	assert.Error(t, validateForward("tcp", 0, 80))
+	assert.Error(t, validateForward("tcp", 8080, 65536))
+	assert.Error(t, validateForward("tcp", 22, 2222))
+	assert.Equal(t, "udp/53", forwardKey("udp", 53))
+}
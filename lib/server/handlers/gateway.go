@@ -0,0 +1,490 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/hostproperty"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/networkproperty"
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/userdata"
+	"github.com/CS-SI/SafeScale/lib/server/metadata"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+)
+
+// replacementSuffix matches the suffix added to the name of a gateway each time it is replaced
+var replacementSuffix = regexp.MustCompile(`-r([0-9]+)$`)
+
+// replacementGatewayName returns the name of the gateway replacing the one named name: gw-net becomes gw-net-r1,
+// gw-net-r1 becomes gw-net-r2, and so on
+func replacementGatewayName(name string) string {
+	if m := replacementSuffix.FindStringSubmatchIndex(name); m != nil {
+		generation, err := strconv.Atoi(name[m[2]:m[3]])
+		if err == nil {
+			return name[:m[0]] + "-r" + strconv.Itoa(generation+1)
+		}
+	}
+	return name + "-r1"
+}
+
+// ReplaceGateway replaces the gateway(s) of a network by new ones, sized after sizing or, if nil, after the sizing
+// requested for the current gateway(s); in a network with failover, the secondary gateway is replaced first, then
+// the primary one, the remaining gateway holding the VIP meanwhile
+func (handler *NetworkHandler) ReplaceGateway(ctx context.Context, ref string, sizing *resources.SizingRequirements, theos string) (err error) {
+	if handler == nil {
+		return scerr.InvalidInstanceError()
+	}
+	if ref == "" {
+		return scerr.InvalidParameterError("ref", "cannot be empty string")
+	}
+	if theos == "" {
+		return scerr.InvalidParameterError("theos", "cannot be empty string")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', <sizing>, '%s')", ref, theos), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	mn, err := metadata.LoadNetwork(handler.service, ref)
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotFound); ok {
+			return resources.ResourceNotFoundError("network", ref)
+		}
+		return err
+	}
+	network, err := mn.Get()
+	if err != nil {
+		return err
+	}
+	if network.GatewayID == "" {
+		return scerr.InvalidRequestError(fmt.Sprintf("network '%s' has no gateway", network.Name))
+	}
+
+	roles := []bool{true}
+	if network.SecondaryGatewayID != "" {
+		roles = []bool{false, true}
+	}
+	for _, primary := range roles {
+		err = handler.replaceGateway(ctx, network, primary, sizing, theos)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The forwarding rules and the WireGuard tunnels only exist on the former gateways
+	err = applyForwards(ctx, handler.service, network)
+	if err != nil {
+		return err
+	}
+	return handler.renewPeerings(ctx, network)
+}
+
+// replaceGateway replaces the primary or the secondary gateway of the network, then moves to the new one what used
+// the former one before deleting it
+func (handler *NetworkHandler) replaceGateway(
+	ctx context.Context, network *resources.Network, primary bool, sizing *resources.SizingRequirements, theos string,
+) (err error) {
+
+	oldID, otherID := network.GatewayID, network.SecondaryGatewayID
+	if !primary {
+		oldID, otherID = otherID, oldID
+	}
+	mh, err := metadata.LoadHost(handler.service, oldID)
+	if err != nil {
+		return err
+	}
+	oldGateway, err := mh.Get()
+	if err != nil {
+		return err
+	}
+	var otherGateway *resources.Host
+	if otherID != "" {
+		mo, err := metadata.LoadHost(handler.service, otherID)
+		if err != nil {
+			return err
+		}
+		otherGateway, err = mo.Get()
+		if err != nil {
+			return err
+		}
+	}
+
+	newGateway, err := handler.createReplacementGateway(ctx, network, oldGateway, otherGateway, primary, sizing, theos)
+	if err != nil {
+		return err
+	}
+
+	// The routes of the provider designating the primary gateway by its name (GCP) now lead to the new one
+	if primary {
+		err = handler.service.UpdateGatewayRoutes(network.ID, newGateway.Name)
+		if err != nil {
+			err = fmt.Errorf("failed to route the traffic of network '%s' to gateway '%s': %s", network.Name, newGateway.Name, err.Error())
+			if derr := handler.service.UpdateGatewayRoutes(network.ID, oldGateway.Name); derr != nil {
+				err = scerr.AddConsequence(err, derr)
+			}
+			if derr := handler.dropGateway(network, newGateway); derr != nil {
+				err = scerr.AddConsequence(err, derr)
+			}
+			return err
+		}
+	}
+
+	sshHandler := NewSSHHandler(handler.service)
+	if network.VIP != nil {
+		// Makes sure the VIP is held by the remaining gateway
+		retcode, _, stderr, err := sshHandler.Run(ctx, oldGateway.Name, "sudo systemctl stop keepalived")
+		if err != nil {
+			logrus.Warnf("failed to stop keepalived on former gateway '%s': %v", oldGateway.Name, err)
+		} else if retcode != 0 {
+			logrus.Warnf("failed to stop keepalived on former gateway '%s': %s", oldGateway.Name, stderr)
+		}
+	}
+
+	if primary {
+		network.GatewayID = newGateway.ID
+	} else {
+		network.SecondaryGatewayID = newGateway.ID
+	}
+	_, err = metadata.SaveNetwork(handler.service, network)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Gateway '%s' of network '%s' replaced by '%s'", oldGateway.Name, network.Name, newGateway.Name)
+
+	// From here, the new gateway is in use; failures are reported once the former gateway is deleted
+	var errs []string
+	from := defaultRoute{gatewayID: oldGateway.ID, ip: oldGateway.GetPrivateIP(), ipv6: oldGateway.GetPrivateIPv6()}
+	to := defaultRoute{gatewayID: newGateway.ID, ip: newGateway.GetPrivateIP(), ipv6: newGateway.GetPrivateIPv6()}
+	if network.VIP != nil {
+		// The hosts keep routing their IPv4 traffic through the VIP
+		from.ip, to.ip = network.VIP.PrivateIP, network.VIP.PrivateIP
+	}
+	err = handler.rerouteHosts(ctx, network, from, to)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	if networkDNSDomain(network) != "" {
+		err = handler.setUpDNS(ctx, network, []*resources.Host{newGateway})
+		if err == nil {
+			err = registerDNSRecord(ctx, handler.service, network, oldGateway.Name, "")
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	err = handler.destroyGateway(network.VIP, oldGateway, mh)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("gateway '%s' of network '%s' replaced by '%s' with errors: %s", oldGateway.Name, network.Name, newGateway.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// destroyGateway deletes a former gateway of a network, bound to vip if not nil, and its metadata mh
+func (handler *NetworkHandler) destroyGateway(vip *resources.VirtualIP, gw *resources.Host, mh *metadata.Host) error {
+	if vip != nil {
+		err := handler.service.UnbindHostFromVIP(vip, gw.ID)
+		if err != nil {
+			logrus.Warnf("failed to unbind former gateway '%s' from VIP: %v", gw.Name, err)
+		}
+	}
+	err := handler.service.DeleteGateway(gw.ID)
+	if _, ok := err.(scerr.ErrNotFound); ok {
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete former gateway '%s': %s", gw.Name, err.Error())
+	}
+	err = mh.Delete()
+	if err != nil {
+		return fmt.Errorf("failed to delete metadata of former gateway '%s': %s", gw.Name, err.Error())
+	}
+	return nil
+}
+
+// createReplacementGateway creates and configures the gateway replacing oldGateway, the one of the network it works in
+// failover with being otherGateway if not nil; on failure, the new gateway is deleted
+func (handler *NetworkHandler) createReplacementGateway(
+	ctx context.Context, network *resources.Network, oldGateway, otherGateway *resources.Host,
+	primary bool, sizing *resources.SizingRequirements, theos string,
+) (newGateway *resources.Host, err error) {
+
+	// The sizing requested for the former gateway is kept if none is given
+	var gwSizing resources.SizingRequirements
+	if sizing != nil {
+		gwSizing = *sizing
+	} else {
+		gwSizing, err = requestedGatewaySizing(oldGateway)
+		if err != nil {
+			return nil, err
+		}
+	}
+	newGateway, err = handler.addGateway(ctx, network, replacementGatewayName(oldGateway.Name), gwSizing, theos, primary, otherGateway)
+	if err != nil {
+		return nil, err
+	}
+
+	// The remaining gateway now exchanges with the new one instead of the former one
+	if otherGateway != nil {
+		params := map[string]interface{}{
+			"OldIP": oldGateway.GetPrivateIP(),
+			"NewIP": newGateway.GetPrivateIP(),
+		}
+		err = exec(ctx, "keepalived_peer_update.sh", params, otherGateway.ID, handler.service)
+		if err != nil {
+			err = fmt.Errorf("failed to update keepalived on gateway '%s': %s", otherGateway.Name, err.Error())
+			derr := handler.dropGateway(network, newGateway)
+			if derr != nil {
+				err = scerr.AddConsequence(err, derr)
+			}
+			return nil, err
+		}
+	}
+	return newGateway, nil
+}
+
+// requestedGatewaySizing returns the sizing requested when the gateway has been created
+func requestedGatewaySizing(gw *resources.Host) (sizing resources.SizingRequirements, err error) {
+	err = gw.Properties.LockForRead(hostproperty.SizingV1).ThenUse(func(clonable data.Clonable) error {
+		if requested := clonable.(*propsv1.HostSizing).RequestedSize; requested != nil {
+			sizing = resources.SizingRequirements{
+				MinCores:    requested.Cores,
+				MinRAMSize:  requested.RAMSize,
+				MinDiskSize: requested.DiskSize,
+				MinGPU:      requested.GPUNumber,
+				MinFreq:     requested.CPUFreq,
+			}
+		}
+		return nil
+	})
+	return sizing, err
+}
+
+// addGateway creates a new gateway in the network, named after name, and configures it with phases 1 and 2 of the
+// userdata; the gateway it works in failover with is otherGateway if not nil. On failure, the new gateway is deleted.
+func (handler *NetworkHandler) addGateway(
+	ctx context.Context, network *resources.Network, name string, sizing resources.SizingRequirements, theos string,
+	primary bool, otherGateway *resources.Host,
+) (gw *resources.Host, err error) {
+
+	tpls, err := handler.service.SelectTemplatesBySize(sizing, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(tpls) == 0 {
+		return nil, fmt.Errorf("no host template matching requirements for gateway")
+	}
+	img, err := handler.service.SearchImage(theos)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, lerr := metadata.LoadHost(handler.service, name); lerr != nil {
+			break
+		}
+		name = replacementGatewayName(name)
+	}
+	keypair, err := handler.service.CreateKeyPair("kp_" + name)
+	if err != nil {
+		return nil, err
+	}
+	request := resources.GatewayRequest{
+		Name:       name,
+		ImageID:    img.ID,
+		Network:    network,
+		KeyPair:    keypair,
+		TemplateID: tpls[0].ID,
+		CIDR:       network.CIDR,
+	}
+
+	task, err := concurrency.NewTaskWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	task, err = task.Start(handler.createGateway, data.Map{
+		"request": request,
+		"sizing":  sizing,
+		"primary": primary,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result, err := task.Wait()
+	if err != nil {
+		return nil, err
+	}
+	gw = result.(data.Map)["host"].(*resources.Host)
+	userData := result.(data.Map)["userdata"].(*userdata.Content)
+
+	// Starting from here, deletes the new gateway if exiting with error
+	defer func() {
+		if err != nil {
+			derr := handler.dropGateway(network, gw)
+			if derr != nil {
+				err = scerr.AddConsequence(err, derr)
+			}
+		}
+	}()
+
+	task, err = concurrency.NewTaskWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = task.Run(handler.waitForInstallPhase1OnGateway, gw)
+	if err != nil {
+		return nil, err
+	}
+
+	// Complements userdata with the IPs of the gateways the network will have
+	primaryGateway, secondaryGateway := gw, otherGateway
+	if !primary {
+		primaryGateway, secondaryGateway = otherGateway, gw
+	}
+	userData.PrimaryGatewayPrivateIP = primaryGateway.GetPrivateIP()
+	userData.PrimaryGatewayPublicIP = primaryGateway.GetPublicIP()
+	if secondaryGateway != nil {
+		userData.SecondaryGatewayPrivateIP = secondaryGateway.GetPrivateIP()
+		userData.SecondaryGatewayPublicIP = secondaryGateway.GetPublicIP()
+	}
+
+	task, err = concurrency.NewTaskWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = task.Run(handler.installPhase2OnGateway, data.Map{
+		"host":     gw,
+		"userdata": userData,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gw, nil
+}
+
+// dropGateway deletes a gateway being added to the network; the metadata of the network is written back since
+// recording the gateway metadata made the network point to the gateway
+func (handler *NetworkHandler) dropGateway(network *resources.Network, gw *resources.Host) (err error) {
+	err = handler.deleteGateway(gw)
+	if network.VIP != nil {
+		if derr := handler.unbindHostFromVIP(network.VIP, gw); derr != nil && err == nil {
+			err = derr
+		}
+	}
+	if derr := metadata.RemoveHost(handler.service, gw); derr != nil && err == nil {
+		err = derr
+	}
+	if _, derr := metadata.SaveNetwork(handler.service, network); derr != nil && err == nil {
+		err = derr
+	}
+	return err
+}
+
+// defaultRoute describes the default gateway of the hosts of a network: the gateway their SSH connections go through
+// and the IPs their traffic is routed to
+type defaultRoute struct {
+	gatewayID string
+	ip        string
+	ipv6      string
+}
+
+// rerouteHosts moves the hosts of the network whose default gateway is from to the default gateway to; their default
+// route and DNS server are changed on the hosts if the IPs differ
+func (handler *NetworkHandler) rerouteHosts(ctx context.Context, network *resources.Network, from, to defaultRoute) error {
+	var hostIDs []string
+	err := network.Properties.LockForRead(networkproperty.HostsV1).ThenUse(func(clonable data.Clonable) error {
+		for id := range clonable.(*propsv1.NetworkHosts).ByID {
+			hostIDs = append(hostIDs, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, id := range hostIDs {
+		err = handler.rerouteHost(ctx, id, from, to)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("host '%s': %s", id, err.Error()))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to change the default gateway of hosts of network '%s': %s", network.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (handler *NetworkHandler) rerouteHost(ctx context.Context, hostID string, from, to defaultRoute) error {
+	mh, err := metadata.LoadHost(handler.service, hostID)
+	if err != nil {
+		return err
+	}
+	host, err := mh.Get()
+	if err != nil {
+		return err
+	}
+	moved := false
+	err = host.Properties.LockForWrite(hostproperty.NetworkV1).ThenUse(func(clonable data.Clonable) error {
+		hostNetworkV1 := clonable.(*propsv1.HostNetwork)
+		if hostNetworkV1.DefaultGatewayID != from.gatewayID {
+			return nil
+		}
+		moved = true
+		hostNetworkV1.DefaultGatewayID = to.gatewayID
+		if hostNetworkV1.DefaultGatewayPrivateIP == from.ip {
+			hostNetworkV1.DefaultGatewayPrivateIP = to.ip
+		}
+		return nil
+	})
+	if err != nil || !moved {
+		return err
+	}
+	// The host metadata is updated first, the SSH connection to the host going through its default gateway
+	_, err = metadata.SaveHost(handler.service, host)
+	if err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{
+		"OldIP":   from.ip,
+		"NewIP":   to.ip,
+		"OldIPv6": "",
+		"NewIPv6": "",
+	}
+	if from.ipv6 != "" && to.ipv6 != "" && from.ipv6 != to.ipv6 {
+		params["OldIPv6"] = from.ipv6
+		params["NewIPv6"] = to.ipv6
+	} else if from.ip == to.ip {
+		return nil
+	}
+	return exec(ctx, "gateway_route_update.sh", params, host.ID, handler.service)
+}
@@ -0,0 +1,30 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplacementGatewayName(t *testing.T) {
+	assert.Equal(t, "gw-net-r1", replacementGatewayName("gw-net"))
+	assert.Equal(t, "gw-net-r2", replacementGatewayName("gw-net-r1"))
+	assert.Equal(t, "gw2-net-r10", replacementGatewayName("gw2-net-r9"))
+	assert.Equal(t, "gw-r-r1", replacementGatewayName("gw-r"))
+}
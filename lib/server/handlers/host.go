@@ -55,7 +55,7 @@ import (
 
 // HostAPI defines API to manipulate hosts
 type HostAPI interface {
-	Create(ctx context.Context, name string, net string, os string, public bool, sizingParam interface{}, force bool) (*resources.Host, error)
+	Create(ctx context.Context, name string, nets []string, os string, public bool, sizingParam interface{}, force bool, customUserData string, postInstallScript string, expiresAt time.Time) (*resources.Host, error)
 	List(ctx context.Context, all bool) ([]*resources.Host, error)
 	ForceInspect(ctx context.Context, ref string) (*resources.Host, error)
 	Inspect(ctx context.Context, ref string) (*resources.Host, error)
@@ -65,6 +65,14 @@ type HostAPI interface {
 	Resize(ctx context.Context, name string, cpu int, ram float32, disk int, gpuNumber int, freq float32) (*resources.Host, error)
 	Start(ctx context.Context, ref string) error
 	Stop(ctx context.Context, ref string) error
+	Extend(ctx context.Context, ref string, expiresAt time.Time) (*resources.Host, error)
+	Import(ctx context.Context, id string, privateKey string, account string, net string) (*resources.Host, error)
+	AddUser(ctx context.Context, ref string, name string, publicKeys []string, sudo bool) error
+	RemoveUser(ctx context.Context, ref string, name string) error
+	ListUsers(ctx context.Context, ref string) ([]*propsv1.HostUser, error)
+	RotateKey(ctx context.Context, ref string) (*resources.Host, error)
+	GetFeatures(ctx context.Context, ref string) (*propsv1.HostFeatures, error)
+	SetFeatures(ctx context.Context, ref string, features *propsv1.HostFeatures) error
 }
 
 // HostHandler host service
@@ -290,14 +298,55 @@ func (handler *HostHandler) Resize(ctx context.Context, ref string, cpu int, ram
 	return newHost, err
 }
 
+// Extend sets the date after which the host is reclaimed by safescaled
+// A zero expiresAt removes the expiration of the host
+func (handler *HostHandler) Extend(ctx context.Context, ref string, expiresAt time.Time) (host *resources.Host, err error) {
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s')", ref, expiresAt.Format(time.RFC3339)), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	if !expiresAt.IsZero() && expiresAt.Before(time.Now()) {
+		return nil, scerr.InvalidParameterError("expiresAt", "cannot be in the past")
+	}
+
+	mh, err := metadata.LoadHost(handler.service, ref)
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotFound); ok {
+			return nil, resources.ResourceNotFoundError("host", ref)
+		}
+		return nil, err
+	}
+	host, err = mh.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	err = host.Properties.LockForWrite(hostproperty.LifecycleV1).ThenUse(func(clonable data.Clonable) error {
+		clonable.(*propsv1.HostLifecycle).ExpiresAt = expiresAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = mh.Write()
+	if err != nil {
+		return nil, err
+	}
+	return host, nil
+}
+
 // Create creates a host
+// The host is attached to the networks of nets; the first one provides the default route.
+// customUserData (cloud-config or script) and postInstallScript, if not empty, are applied in a phase3 run after phase2.
+// A non-zero expiresAt is recorded with the first metadata of the host, so the reaper reclaims it even if creation is interrupted.
 // func (handler *HostHandler) Create(
 // 	ctx context.Context,
 // 	name string, net string, cpu int, ram float32, disk int, los string, public bool, gpuNumber int, freq float32,
 // 	force bool,
 func (handler *HostHandler) Create(
 	ctx context.Context,
-	name string, net string, los string, public bool, sizingParam interface{}, force bool,
+	name string, nets []string, los string, public bool, sizingParam interface{}, force bool,
+	customUserData string, postInstallScript string, expiresAt time.Time,
 ) (newHost *resources.Host, err error) {
 
 	if handler == nil {
@@ -309,8 +358,16 @@ func (handler *HostHandler) Create(
 	if name == "" {
 		return nil, scerr.InvalidParameterError("name", "cannot be empty string")
 	}
+	if !expiresAt.IsZero() && expiresAt.Before(time.Now()) {
+		return nil, scerr.InvalidParameterError("expiresAt", "cannot be in the past")
+	}
+
+	net := ""
+	if len(nets) > 0 {
+		net = nets[0]
+	}
 
-	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s', '%s', %v, <sizingParam>, %v)", name, net, los, public, force), true).WithStopwatch().GoingIn()
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', %v, '%s', %v, <sizingParam>, %v)", name, nets, los, public, force), true).WithStopwatch().GoingIn()
 	defer tracer.OnExitTrace()()
 	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
 
@@ -384,6 +441,24 @@ func (handler *HostHandler) Create(
 		}
 		networks = append(networks, net)
 	}
+	if len(nets) > 1 {
+		networkHandler := NewNetworkHandler(handler.service)
+		for _, ref := range nets[1:] {
+			network, err := networkHandler.Inspect(ctx, ref)
+			if err != nil {
+				return nil, err
+			}
+			if network == nil {
+				return nil, fmt.Errorf("failed to find network '%s'", ref)
+			}
+			for _, attached := range networks {
+				if attached.ID == network.ID {
+					return nil, scerr.InvalidRequestError(fmt.Sprintf("network '%s' is requested several times", network.Name))
+				}
+			}
+			networks = append(networks, network)
+		}
+	}
 
 	var template *resources.HostTemplate
 	if sizing != nil {
@@ -490,6 +565,15 @@ func (handler *HostHandler) Create(
 	if host.Properties == nil {
 		return nil, fmt.Errorf("error populating host properties: host.Properties is nil")
 	}
+	if !expiresAt.IsZero() {
+		err = host.Properties.LockForWrite(hostproperty.LifecycleV1).ThenUse(func(clonable data.Clonable) error {
+			clonable.(*propsv1.HostLifecycle).ExpiresAt = expiresAt
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// Updates host metadata
 	mh, err := metadata.NewHost(handler.service)
@@ -551,19 +635,7 @@ func (handler *HostHandler) Create(
 	}
 
 	// Sets host extension DescriptionV1
-	creator := ""
-	hostname, _ := os.Hostname()
-	if curUser, err := user.Current(); err == nil {
-		creator = curUser.Username
-		if hostname != "" {
-			creator += "@" + hostname
-		}
-		if curUser.Name != "" {
-			creator += " (" + curUser.Name + ")"
-		}
-	} else {
-		creator = "unknown@" + hostname
-	}
+	creator := getCreator()
 	err = host.Properties.LockForWrite(hostproperty.DescriptionV1).ThenUse(func(clonable data.Clonable) error {
 		hostDescriptionV1 := clonable.(*propsv1.HostDescription)
 		hostDescriptionV1.Created = time.Now()
@@ -597,15 +669,7 @@ func (handler *HostHandler) Create(
 		}
 		hostNetworkV1.DefaultGatewayID = gatewayID
 
-		if net != "" {
-			mn, err := metadata.LoadNetwork(handler.service, net)
-			if err != nil {
-				return err
-			}
-			network, err := mn.Get()
-			if err != nil {
-				return err
-			}
+		for _, network := range networks {
 			hostNetworkV1.NetworksByID[network.ID] = network.Name
 			hostNetworkV1.NetworksByName[network.Name] = network.ID
 		}
@@ -648,21 +712,47 @@ func (handler *HostHandler) Create(
 		return nil, scerr.Wrap(derr, fmt.Sprintf("failed to wait host '%s' to become ready", host.Name))
 	}
 
-	// Updates host link with networks
+	hostIPs := map[string]string{}
+	err = host.Properties.LockForRead(hostproperty.NetworkV1).ThenUse(func(clonable data.Clonable) error {
+		for k, v := range clonable.(*propsv1.HostNetwork).IPv4Addresses {
+			hostIPs[k] = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Updates host link with networks, as currently recorded: other hosts may have been created in the meantime
 	for _, i := range networks {
-		err = i.Properties.LockForWrite(networkproperty.HostsV1).ThenUse(func(clonable data.Clonable) error {
-			networkHostsV1 := clonable.(*propsv1.NetworkHosts)
-			networkHostsV1.ByName[host.Name] = host.ID
-			networkHostsV1.ByID[host.ID] = host.Name
-			return nil
+		networkMetadataMutex.Lock()
+		_, err = updateNetworkMetadata(handler.service, i.ID, func(network *resources.Network) error {
+			return network.Properties.LockForWrite(networkproperty.HostsV1).ThenUse(func(clonable data.Clonable) error {
+				networkHostsV1 := clonable.(*propsv1.NetworkHosts)
+				networkHostsV1.ByName[host.Name] = host.ID
+				networkHostsV1.ByID[host.ID] = host.Name
+				return nil
+			})
 		})
+		networkMetadataMutex.Unlock()
 		if err != nil {
 			logrus.Errorf(err.Error())
 			continue
 		}
-		_, err = metadata.SaveNetwork(handler.service, i)
-		if err != nil {
-			logrus.Errorf(err.Error())
+		// Registers the host in the zone served by the gateway(s) of the network
+		if ip := hostIPs[i.ID]; ip != "" {
+			err = registerDNSRecord(ctx, handler.service, i, host.Name, ip)
+			if err != nil {
+				logrus.Warnf("failed to register host '%s' in DNS: %v", host.Name, err)
+			}
+		}
+	}
+
+	// The host resolves the names of the zone of its default network through the gateway(s)
+	if defaultNetwork != nil && defaultRouteIP != "" {
+		if domain := networkDNSDomain(defaultNetwork); domain != "" {
+			userData.DNSServers = append([]string{defaultRouteIP}, userData.DNSServers...)
+			userData.DNSDomain = domain
 		}
 	}
 
@@ -709,11 +799,11 @@ func (handler *HostHandler) Create(
 		},
 	)
 	if retryErr != nil {
-		retrieveForensicsData(ctx, sshHandler, host)
+		retrieveForensicsData(ctx, sshHandler, host, "phase2")
 		return nil, err
 	}
 	if retcode != 0 {
-		retrieveForensicsData(ctx, sshHandler, host)
+		retrieveForensicsData(ctx, sshHandler, host, "phase2")
 
 		// Setting err will trigger defers
 		err = fmt.Errorf("failed to finalize host '%s' installation: stdout[%s], stderr[%s]", host.Name, stdout, stderr)
@@ -724,6 +814,14 @@ func (handler *HostHandler) Create(
 		return nil, err
 	}
 
+	// Routes the traffic to the networks peered with the ones of the host
+	for _, i := range networks {
+		err = applyPeeringRoutes(ctx, handler.service, i, host.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Reboot host
 	command = "sudo systemctl reboot"
 	retcode, _, _, err = sshHandler.Run(ctx, host.Name, command)
@@ -751,6 +849,14 @@ func (handler *HostHandler) Create(
 	}
 	logrus.Infof("SSH service started on host '%s'.", host.Name)
 
+	// Applies the customization supplied by the user
+	if customUserData != "" || postInstallScript != "" {
+		err = runPhase3(ctx, sshHandler, host, userData, customUserData, postInstallScript)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	select {
 	case <-ctx.Done():
 		err = fmt.Errorf("host creation cancelled by safescale")
@@ -762,12 +868,44 @@ func (handler *HostHandler) Create(
 	return host, nil
 }
 
-func getPhaseWarningsAndErrors(ctx context.Context, sshHandler *SSHHandler, host *resources.Host) ([]string, []string) {
+// runPhase3 executes userdata phase3 script on host, applying the user data and post-installation script
+func runPhase3(
+	ctx context.Context, sshHandler *SSHHandler, host *resources.Host, content *userdata.Content, userData string, postInstallScript string,
+) error {
+	content.UserData = userData
+	content.PostInstallScript = postInstallScript
+	userDataPhase3, err := content.Generate("phase3")
+	if err != nil {
+		return err
+	}
+
+	filepath := utils.TempFolder + "/user_data.phase3.sh"
+	err = install.UploadStringToRemoteFile(string(userDataPhase3), srvutils.ToPBHost(host), filepath, "", "", "")
+	if err != nil {
+		return err
+	}
+
+	retcode, _, _, err := sshHandler.Run(ctx, host.Name, fmt.Sprintf("sudo bash %s; exit $?", filepath))
+	if err != nil {
+		return err
+	}
+	warnings, errs := getPhaseWarningsAndErrors(ctx, sshHandler, host, "phase3")
+	if retcode != 0 {
+		retrieveForensicsData(ctx, sshHandler, host, "phase3")
+		return fmt.Errorf("failed to apply user customization on host '%s' (retcode=%d): warnings %v, errors %v", host.Name, retcode, warnings, errs)
+	}
+	for _, w := range warnings {
+		logrus.Warnf("user customization of host '%s': %s", host.Name, w)
+	}
+	return nil
+}
+
+func getPhaseWarningsAndErrors(ctx context.Context, sshHandler *SSHHandler, host *resources.Host, phase string) ([]string, []string) {
 	if sshHandler == nil || host == nil {
 		return []string{}, []string{}
 	}
 
-	recoverCode, recoverStdOut, _, recoverErr := sshHandler.Run(ctx, host.Name, fmt.Sprintf("cat %s/user_data.phase2.log; exit $?", utils.LogFolder))
+	recoverCode, recoverStdOut, _, recoverErr := sshHandler.Run(ctx, host.Name, fmt.Sprintf("cat %s/user_data.%s.log; exit $?", utils.LogFolder, phase))
 	var warnings []string
 	var errs []string
 
@@ -786,15 +924,15 @@ func getPhaseWarningsAndErrors(ctx context.Context, sshHandler *SSHHandler, host
 	return warnings, errs
 }
 
-func retrieveForensicsData(ctx context.Context, sshHandler *SSHHandler, host *resources.Host) {
+func retrieveForensicsData(ctx context.Context, sshHandler *SSHHandler, host *resources.Host, phase string) {
 	if sshHandler == nil || host == nil {
 		return
 	}
 	if forensics := os.Getenv("SAFESCALE_FORENSICS"); forensics != "" {
 		_ = os.MkdirAll(utils.AbsPathify(fmt.Sprintf("$HOME/.safescale/forensics/%s", host.Name)), 0777)
-		dumpName := utils.AbsPathify(fmt.Sprintf("$HOME/.safescale/forensics/%s/userdata-%s.", host.Name, "phase2"))
-		_, _, _, _ = sshHandler.Copy(ctx, host.Name+":"+utils.TempFolder+"/user_data.phase2.sh", dumpName+"sh")
-		_, _, _, _ = sshHandler.Copy(ctx, host.Name+":"+utils.LogFolder+"/user_data.phase2.log", dumpName+"log")
+		dumpName := utils.AbsPathify(fmt.Sprintf("$HOME/.safescale/forensics/%s/userdata-%s.", host.Name, phase))
+		_, _, _, _ = sshHandler.Copy(ctx, host.Name+":"+utils.TempFolder+"/user_data."+phase+".sh", dumpName+"sh")
+		_, _, _, _ = sshHandler.Copy(ctx, host.Name+":"+utils.LogFolder+"/user_data."+phase+".log", dumpName+"log")
 	}
 }
 
@@ -861,6 +999,283 @@ func (handler *HostHandler) List(ctx context.Context, all bool) (hosts []*resour
 	return hosts, nil
 }
 
+// Import registers in metadata a host created outside SafeScale, identified by its ID (or name) on provider side
+// account, if not empty, is the existing account privateKey gives access to; it is used to create the operator account
+// and the folders SafeScale works in before the host is recorded
+// net, if not empty, designates the default network of the host; it must already be known by SafeScale
+func (handler *HostHandler) Import(ctx context.Context, id string, privateKey string, account string, net string) (host *resources.Host, err error) {
+	if handler == nil {
+		return nil, scerr.InvalidInstanceError()
+	}
+	if id == "" {
+		return nil, scerr.InvalidParameterError("id", "cannot be empty string")
+	}
+	if privateKey == "" {
+		return nil, scerr.InvalidParameterError("privateKey", "cannot be empty string")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', <privateKey>, '%s', '%s')", id, account, net), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	host, err = buildImportedHost(ctx, handler.service, id, privateKey, account, net, false)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = metadata.SaveHost(handler.service, host)
+	if err != nil {
+		return nil, err
+	}
+
+	// Registers the host in the metadata of the networks SafeScale knows about
+	var networkIDs []string
+	err = host.Properties.LockForRead(hostproperty.NetworkV1).ThenUse(func(clonable data.Clonable) error {
+		for netID := range clonable.(*propsv1.HostNetwork).NetworksByID {
+			networkIDs = append(networkIDs, netID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, netID := range networkIDs {
+		mn, err := metadata.LoadNetwork(handler.service, netID)
+		if err != nil {
+			if _, ok := err.(scerr.ErrNotFound); ok {
+				continue
+			}
+			return nil, err
+		}
+		err = mn.AttachHost(host)
+		if err != nil {
+			return nil, err
+		}
+		err = mn.Write()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logrus.Infof("Host '%s' (%s) imported", host.Name, host.ID)
+	return host, nil
+}
+
+// buildImportedHost inspects on provider side the host identified by ref and completes its properties with
+// what SafeScale usually records at creation time, prepares the host with account if not empty, then checks
+// the host is reachable by the operator using privateKey
+func buildImportedHost(ctx context.Context, svc iaas.Service, ref string, privateKey string, account string, defaultNet string, isGateway bool) (*resources.Host, error) {
+	if mh, err := metadata.LoadHost(svc, ref); err == nil {
+		if known, err := mh.Get(); err == nil {
+			return nil, resources.ResourceDuplicateError("host", known.Name)
+		}
+		return nil, resources.ResourceDuplicateError("host", ref)
+	} else if _, ok := err.(scerr.ErrNotFound); !ok {
+		return nil, err
+	}
+
+	host, err := svc.InspectHost(ref)
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotFound); ok {
+			return nil, resources.ResourceNotFoundError("host", ref)
+		}
+		return nil, err
+	}
+	if mh, err := metadata.LoadHost(svc, host.ID); err == nil {
+		if _, err = mh.Get(); err == nil {
+			return nil, resources.ResourceDuplicateError("host", host.Name)
+		}
+	}
+	host.PrivateKey = privateKey
+
+	var defaultNetwork *resources.Network
+	if defaultNet != "" {
+		mn, err := metadata.LoadNetwork(svc, defaultNet)
+		if err != nil {
+			if _, ok := err.(scerr.ErrNotFound); ok {
+				return nil, resources.ResourceNotFoundError("network", defaultNet)
+			}
+			return nil, err
+		}
+		defaultNetwork, err = mn.Get()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = host.Properties.LockForWrite(hostproperty.NetworkV1).ThenUse(func(clonable data.Clonable) error {
+		hostNetworkV1 := clonable.(*propsv1.HostNetwork)
+		if defaultNetwork != nil {
+			if _, ok := hostNetworkV1.NetworksByID[defaultNetwork.ID]; !ok {
+				return fmt.Errorf("host '%s' isn't connected to network '%s'", host.Name, defaultNetwork.Name)
+			}
+		}
+		hostNetworkV1.NetworksByName = map[string]string{}
+		for netID := range hostNetworkV1.NetworksByID {
+			var netName string
+			if mn, err := metadata.LoadNetwork(svc, netID); err == nil {
+				if network, err := mn.Get(); err == nil {
+					netName = network.Name
+					if defaultNetwork == nil {
+						defaultNetwork = network
+					}
+				}
+			} else if network, err := svc.GetNetwork(netID); err == nil {
+				netName = network.Name
+			}
+			if netName == "" {
+				netName = netID
+			}
+			hostNetworkV1.NetworksByID[netID] = netName
+			hostNetworkV1.NetworksByName[netName] = netID
+		}
+		hostNetworkV1.IsGateway = isGateway
+		if defaultNetwork != nil {
+			hostNetworkV1.DefaultNetworkID = defaultNetwork.ID
+			if !isGateway && defaultNetwork.GatewayID != "" && host.GetPublicIP() == "" {
+				mgw, err := metadata.LoadHost(svc, defaultNetwork.GatewayID)
+				if err != nil {
+					return err
+				}
+				gw, err := mgw.Get()
+				if err != nil {
+					return err
+				}
+				hostNetworkV1.DefaultGatewayID = gw.ID
+				hostNetworkV1.DefaultGatewayPrivateIP = gw.GetPrivateIP()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = host.Properties.LockForWrite(hostproperty.DescriptionV1).ThenUse(func(clonable data.Clonable) error {
+		hostDescriptionV1 := clonable.(*propsv1.HostDescription)
+		if hostDescriptionV1.Created.IsZero() {
+			hostDescriptionV1.Created = time.Now()
+		}
+		hostDescriptionV1.Updated = time.Now()
+		hostDescriptionV1.Creator = getCreator()
+		hostDescriptionV1.Purpose = "imported"
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Validates SSH access with the key supplied before recording anything
+	sshHandler := NewSSHHandler(svc)
+	sshCfg, err := sshHandler.GetConfig(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if account != "" && account != sshCfg.User {
+		err = prepareImportedHost(sshCfg, account, privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare host '%s' with account '%s': %s", host.Name, account, err.Error())
+		}
+	}
+	retcode, _, stderr, err := sshHandler.runWithTimeout(sshCfg, "true", temporal.GetConnectSSHTimeout())
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach host '%s' with the key supplied: %s", host.Name, err.Error())
+	}
+	if retcode != 0 {
+		return nil, fmt.Errorf("failed to reach host '%s' with the key supplied: %s", host.Name, stderr)
+	}
+
+	return host, nil
+}
+
+// prepareImportedHost connects to the host with account to create the operator account of sshCfg and the folders
+// SafeScale works in, as the userdata does on the hosts created by SafeScale
+func prepareImportedHost(sshCfg *system.SSHConfig, account string, privateKey string) error {
+	script, err := importPreparationScript(sshCfg.User, privateKey)
+	if err != nil {
+		return err
+	}
+	accountCfg := *sshCfg
+	accountCfg.User = account
+	cmd, err := accountCfg.SudoCommand(script, false)
+	if err != nil {
+		return err
+	}
+	retcode, _, stderr, err := cmd.RunWithTimeout(nil, outputs.COLLECT, temporal.GetExecutionTimeout())
+	if err != nil {
+		return err
+	}
+	if retcode != 0 {
+		return fmt.Errorf("script failed with code %d: %s", retcode, stderr)
+	}
+	return nil
+}
+
+// importPreparationScript renders the script creating operator with privateKey on an imported host
+func importPreparationScript(operator string, privateKey string) (string, error) {
+	publicKey, err := authorizedKeyOf(privateKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key: %s", err.Error())
+	}
+	params := map[string]interface{}{
+		"User":       operator,
+		"PublicKey":  publicKey,
+		"PrivateKey": strings.TrimSpace(privateKey),
+	}
+	return getBoxContent("host_import_prepare.sh", params)
+}
+
+// detachHostFromNetworks removes the references to host from the metadata of its networks, along with its DNS record
+// and the port forwards of their gateways; failures on a network are logged and don't stop the others
+func detachHostFromNetworks(ctx context.Context, svc iaas.Service, host *resources.Host) error {
+	return host.Properties.LockForRead(hostproperty.NetworkV1).ThenUse(func(clonable data.Clonable) error {
+		hostNetworkV1 := clonable.(*propsv1.HostNetwork)
+		for k := range hostNetworkV1.NetworksByID {
+			networkMetadataMutex.Lock()
+			network, err := updateNetworkMetadata(svc, k, func(network *resources.Network) error {
+				return network.Properties.LockForWrite(networkproperty.HostsV1).ThenUse(func(clonable data.Clonable) error {
+					networkHostsV1 := clonable.(*propsv1.NetworkHosts)
+					delete(networkHostsV1.ByID, host.ID)
+					delete(networkHostsV1.ByName, host.Name)
+					return nil
+				})
+			})
+			networkMetadataMutex.Unlock()
+			if err != nil {
+				logrus.Errorf(err.Error())
+				continue
+			}
+			err = registerDNSRecord(ctx, svc, network, host.Name, "")
+			if err != nil {
+				logrus.Errorf(err.Error())
+			}
+			err = removeHostForwards(ctx, svc, network, host.ID)
+			if err != nil {
+				logrus.Errorf(err.Error())
+			}
+		}
+		return nil
+	})
+}
+
+// getCreator returns the identity of the user running SafeScale, as recorded in host description
+func getCreator() string {
+	creator := ""
+	hostname, _ := os.Hostname()
+	if curUser, err := user.Current(); err == nil {
+		creator = curUser.Username
+		if hostname != "" {
+			creator += "@" + hostname
+		}
+		if curUser.Name != "" {
+			creator += " (" + curUser.Name + ")"
+		}
+	} else {
+		creator = "unknown@" + hostname
+	}
+	return creator
+}
+
 // ForceInspect ...
 // If not found, return (nil, err)
 func (handler *HostHandler) ForceInspect(ctx context.Context, ref string) (host *resources.Host, err error) {
@@ -947,6 +1362,18 @@ func (handler *HostHandler) Delete(ctx context.Context, ref string) (err error)
 		return err
 	}
 
+	// Don't remove a host exporting shares in high availability with another host
+	err = host.Properties.LockForRead(hostproperty.ShareReplicasV1).ThenUse(func(clonable data.Clonable) error {
+		count := len(clonable.(*propsv1.HostShareReplicas).ByShareID)
+		if count > 0 {
+			return fmt.Errorf("cannot delete host, takes part in %d highly available share%s", count, utils.Plural(count))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
 	// Don't remove a host with volumes attached
 	err = host.Properties.LockForRead(hostproperty.VolumesV1).ThenUse(func(clonable data.Clonable) error {
 		nAttached := len(clonable.(*propsv1.HostVolumes).VolumesByID)
@@ -976,6 +1403,10 @@ func (handler *HostHandler) Delete(ctx context.Context, ref string) (err error)
 	err = host.Properties.LockForRead(hostproperty.MountsV1).ThenUse(func(clonable data.Clonable) error {
 		hostMountsV1 := clonable.(*propsv1.HostMounts)
 		for _, i := range hostMountsV1.RemoteMountsByPath {
+			// Mounted buckets don't need cleanup elsewhere, they go away with the host
+			if i.ShareID == "" {
+				continue
+			}
 			// Gets share data
 			_, share, _, err := shareHandler.Inspect(ctx, i.ShareID)
 			if err != nil {
@@ -1011,31 +1442,7 @@ func (handler *HostHandler) Delete(ctx context.Context, ref string) (err error)
 	}
 
 	// Update networks property prosv1.NetworkHosts to remove the reference to the host
-	netHandler := NewNetworkHandler(handler.service)
-	err = host.Properties.LockForRead(hostproperty.NetworkV1).ThenUse(func(clonable data.Clonable) error {
-		hostNetworkV1 := clonable.(*propsv1.HostNetwork)
-		for k := range hostNetworkV1.NetworksByID {
-			network, err := netHandler.Inspect(ctx, k)
-			if err != nil {
-				logrus.Errorf(err.Error())
-				continue
-			}
-			err = network.Properties.LockForWrite(networkproperty.HostsV1).ThenUse(func(clonable data.Clonable) error {
-				networkHostsV1 := clonable.(*propsv1.NetworkHosts)
-				delete(networkHostsV1.ByID, host.ID)
-				delete(networkHostsV1.ByName, host.Name)
-				return nil
-			})
-			if err != nil {
-				logrus.Errorf(err.Error())
-			}
-			_, err = metadata.SaveNetwork(handler.service, network)
-			if err != nil {
-				logrus.Errorf(err.Error())
-			}
-		}
-		return nil
-	})
+	err = detachHostFromNetworks(ctx, handler.service, host)
 	if err != nil {
 		return err
 	}
@@ -1097,7 +1504,13 @@ func (handler *HostHandler) Delete(ctx context.Context, ref string) (err error)
 					MaxRAMSize:  hostSizingV1.AllocatedSize.RAMSize,
 					MinDiskSize: hostSizingV1.AllocatedSize.DiskSize,
 				}
-				hostBis, err3 = handler.Create(context.Background(), host.Name, hostNetworkV1.DefaultNetworkID, "ubuntu 18.04", (len(hostNetworkV1.PublicIPv4)+len(hostNetworkV1.PublicIPv6)) != 0, &sizing, true)
+				nets := []string{hostNetworkV1.DefaultNetworkID}
+				for netID := range hostNetworkV1.NetworksByID {
+					if netID != hostNetworkV1.DefaultNetworkID {
+						nets = append(nets, netID)
+					}
+				}
+				hostBis, err3 = handler.Create(context.Background(), host.Name, nets, "ubuntu 18.04", (len(hostNetworkV1.PublicIPv4)+len(hostNetworkV1.PublicIPv6)) != 0, &sizing, true, "", "", time.Time{})
 				if err3 != nil {
 					return fmt.Errorf("failed to stop host deletion : %s", err3.Error())
 				}
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/hostproperty"
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/metadata"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+)
+
+// GetFeatures returns the features recorded as installed on the host
+func (handler *HostHandler) GetFeatures(ctx context.Context, ref string) (features *propsv1.HostFeatures, err error) {
+	if handler == nil {
+		return nil, scerr.InvalidInstanceError()
+	}
+	if ref == "" {
+		return nil, scerr.InvalidParameterError("ref", "cannot be empty string")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	mh, err := metadata.LoadHost(handler.service, ref)
+	if err != nil {
+		return nil, err
+	}
+	host, err := mh.Get()
+	if err != nil {
+		return nil, err
+	}
+	features = propsv1.NewHostFeatures()
+	err = host.Properties.LockForRead(hostproperty.FeaturesV1).ThenUse(func(clonable data.Clonable) error {
+		for k, v := range clonable.(*propsv1.HostFeatures).Installed {
+			features.Installed[k] = v.Clone().(*propsv1.HostInstalledFeature)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return features, nil
+}
+
+// SetFeatures replaces the features recorded as installed on the host
+func (handler *HostHandler) SetFeatures(ctx context.Context, ref string, features *propsv1.HostFeatures) (err error) {
+	if handler == nil {
+		return scerr.InvalidInstanceError()
+	}
+	if ref == "" {
+		return scerr.InvalidParameterError("ref", "cannot be empty string")
+	}
+	if features == nil {
+		return scerr.InvalidParameterError("features", "cannot be nil")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	mh, err := metadata.LoadHost(handler.service, ref)
+	if err != nil {
+		return err
+	}
+	host, err := mh.Get()
+	if err != nil {
+		return err
+	}
+	err = host.Properties.LockForWrite(hostproperty.FeaturesV1).ThenUse(func(clonable data.Clonable) error {
+		featuresV1 := clonable.(*propsv1.HostFeatures)
+		featuresV1.Reset()
+		for k, v := range features.Installed {
+			featuresV1.Installed[k] = v.Clone().(*propsv1.HostInstalledFeature)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = metadata.SaveHost(handler.service, host)
+	return err
+}
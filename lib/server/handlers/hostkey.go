@@ -0,0 +1,218 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/CS-SI/SafeScale/lib/server/iaas"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/networkproperty"
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/metadata"
+	"github.com/CS-SI/SafeScale/lib/system"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+	"github.com/CS-SI/SafeScale/lib/utils/temporal"
+)
+
+// authorizedKeyOf returns the public key, in authorized_keys format, of the PEM encoded private key
+func authorizedKeyOf(privateKey string) (string, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))), nil
+}
+
+// RotateKey replaces the keypair used by SafeScale to connect to the host by a newly generated one
+// The new key is authorized besides the current one and checked before being recorded in metadata; only then
+// the current key is revoked
+func (handler *HostHandler) RotateKey(ctx context.Context, ref string) (host *resources.Host, err error) {
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	mh, err := metadata.LoadHost(handler.service, ref)
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotFound); ok {
+			return nil, resources.ResourceNotFoundError("host", ref)
+		}
+		return nil, err
+	}
+	host, err = mh.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	oldPublicKey, err := authorizedKeyOf(host.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the current key of host '%s': %s", host.Name, err.Error())
+	}
+	pub, priv, err := system.CreateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	newPublicKey := strings.TrimSpace(string(pub))
+	newPrivateKey := string(priv)
+	user, err := getOperatorUsername(handler.service)
+	if err != nil {
+		return nil, err
+	}
+
+	// Authorizes the new key, connecting with the current one
+	params := map[string]interface{}{
+		"User":      user,
+		"PublicKey": newPublicKey,
+	}
+	err = exec(ctx, "host_key_add.sh", params, host.ID, handler.service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to install new key on host '%s': %s", host.Name, err.Error())
+	}
+
+	// Revokes the new key if the rotation cannot be completed; metadata still contain the current key
+	committed := false
+	defer func() {
+		if err != nil && !committed {
+			params := map[string]interface{}{
+				"User":       user,
+				"PublicKey":  newPublicKey,
+				"PrivateKey": "",
+			}
+			derr := exec(ctx, "host_key_remove.sh", params, host.ID, handler.service)
+			if derr != nil {
+				logrus.Errorf("failed to revoke new key on host '%s' after failed rotation: %v", host.Name, derr)
+				err = scerr.AddConsequence(err, derr)
+			}
+		}
+	}()
+
+	// Checks the new key gives access to the host (through its gateway if it has one)
+	candidate := *host
+	candidate.PrivateKey = newPrivateKey
+	sshHandler := NewSSHHandler(handler.service)
+	sshCfg, err := sshHandler.GetConfig(ctx, &candidate)
+	if err != nil {
+		return nil, err
+	}
+	retcode, _, _, err := sshHandler.runWithTimeout(sshCfg, "true", temporal.GetConnectSSHTimeout())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to host '%s' with new key: %s", host.Name, err.Error())
+	}
+	if retcode != 0 {
+		err = fmt.Errorf("failed to connect to host '%s' with new key: retcode=%d", host.Name, retcode)
+		return nil, err
+	}
+
+	host.PrivateKey = newPrivateKey
+	err = mh.Write()
+	if err != nil {
+		return nil, err
+	}
+	committed = true
+
+	// Revokes the previous key, connecting with the new one
+	params = map[string]interface{}{
+		"User":       user,
+		"PublicKey":  oldPublicKey,
+		"PrivateKey": newPrivateKey,
+	}
+	err = exec(ctx, "host_key_remove.sh", params, host.ID, handler.service)
+	if err != nil {
+		return nil, fmt.Errorf("new key of host '%s' is in use, but failed to revoke the previous one: %s", host.Name, err.Error())
+	}
+
+	// The keypair registered on provider side at creation holds the previous public key
+	err = deleteProviderKeyPair(handler.service, "kp_"+host.Name)
+	if err != nil {
+		return nil, fmt.Errorf("new key of host '%s' is in use, but failed to delete the provider keypair of the previous one: %s", host.Name, err.Error())
+	}
+	return host, nil
+}
+
+// deleteProviderKeyPair deletes the keypair named name on provider side, if the provider knows about keypairs and
+// such a keypair exists
+func deleteProviderKeyPair(svc iaas.Service, name string) error {
+	keypairs, err := svc.ListKeyPairs()
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotImplemented); ok {
+			return nil
+		}
+		return err
+	}
+	for _, kp := range keypairs {
+		if kp.Name == name {
+			return svc.DeleteKeyPair(kp.ID)
+		}
+	}
+	return nil
+}
+
+// RotateKeys rotates the keypairs of the gateway(s) of the network, then of its hosts
+func (handler *NetworkHandler) RotateKeys(ctx context.Context, ref string) (err error) {
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	mn, err := metadata.LoadNetwork(handler.service, ref)
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotFound); ok {
+			return resources.ResourceNotFoundError("network", ref)
+		}
+		return err
+	}
+	network, err := mn.Get()
+	if err != nil {
+		return err
+	}
+
+	var hostIDs []string
+	if network.GatewayID != "" {
+		hostIDs = append(hostIDs, network.GatewayID)
+	}
+	if network.SecondaryGatewayID != "" {
+		hostIDs = append(hostIDs, network.SecondaryGatewayID)
+	}
+	err = network.Properties.LockForRead(networkproperty.HostsV1).ThenUse(func(clonable data.Clonable) error {
+		for id := range clonable.(*propsv1.NetworkHosts).ByID {
+			hostIDs = append(hostIDs, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	hostHandler := NewHostHandler(handler.service)
+	var errs []string
+	for _, id := range hostIDs {
+		_, err = hostHandler.RotateKey(ctx, id)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("host '%s': %s", id, err.Error()))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to rotate the keys of hosts of network '%s': %s", network.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/CS-SI/SafeScale/lib/system"
+)
+
+func TestAuthorizedKeyOf(t *testing.T) {
+	pub, priv, err := system.CreateKeyPair()
+	assert.Nil(t, err)
+
+	key, err := authorizedKeyOf(string(priv))
+	assert.Nil(t, err)
+	assert.Equal(t, strings.TrimSpace(string(pub)), key)
+
+	_, err = authorizedKeyOf("not a key")
+	assert.NotNil(t, err)
+}
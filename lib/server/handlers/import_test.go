@@ -0,0 +1,70 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/CS-SI/SafeScale/lib/system"
+)
+
+func TestImportPreparationScript(t *testing.T) {
+	pub, priv, err := system.CreateKeyPair()
+	assert.Nil(t, err)
+
+	script, err := importPreparationScript("safescale", string(priv)+"\n")
+	assert.Nil(t, err)
+	assert.Contains(t, script, "USER_NAME=safescale\n")
+	assert.Contains(t, script, "echo '"+strings.TrimSpace(string(pub))+"' >>")
+	assert.Contains(t, script, "\n"+strings.TrimSpace(string(priv))+"\nEOT\n")
+	for _, folder := range []string{"log", "run", "state", "tmp"} {
+		assert.Contains(t, script, "/opt/safescale/var/"+folder)
+	}
+	assert.NotContains(t, script, "<no value>")
+
+	_, err = importPreparationScript("safescale", "not a key")
+	assert.NotNil(t, err)
+}
+
+func TestHostImportParameters(t *testing.T) {
+	var nilHandler *HostHandler
+	_, err := nilHandler.Import(context.Background(), "id", "key", "", "")
+	assert.NotNil(t, err)
+
+	handler := &HostHandler{}
+	_, err = handler.Import(context.Background(), "", "key", "ubuntu", "")
+	assert.NotNil(t, err)
+	_, err = handler.Import(context.Background(), "id", "", "ubuntu", "")
+	assert.NotNil(t, err)
+}
+
+func TestNetworkImportParameters(t *testing.T) {
+	var nilHandler *NetworkHandler
+	_, err := nilHandler.Import(context.Background(), "id", "", "", "")
+	assert.NotNil(t, err)
+
+	handler := &NetworkHandler{}
+	_, err = handler.Import(context.Background(), "", "", "", "")
+	assert.NotNil(t, err)
+	_, err = handler.Import(context.Background(), "id", "gw", "", "ubuntu")
+	assert.NotNil(t, err)
+}
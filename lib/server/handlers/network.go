@@ -51,10 +51,19 @@ import (
 
 // NetworkAPI defines API to manage networks
 type NetworkAPI interface {
-	Create(context.Context, string, string, ipversion.Enum, resources.SizingRequirements, string, string, bool) (*resources.Network, error)
+	Create(context.Context, string, string, ipversion.Enum, string, resources.SizingRequirements, string, string, bool) (*resources.Network, error)
 	List(context.Context, bool) ([]*resources.Network, error)
 	Inspect(context.Context, string) (*resources.Network, error)
 	Delete(context.Context, string) error
+	Import(context.Context, string, string, string, string) (*resources.Network, error)
+	Peer(context.Context, string, string, iaas.Service, string, string) error
+	AddForward(context.Context, string, string, int, string, int) (*propsv1.NetworkForward, error)
+	ListForwards(context.Context, string) ([]*propsv1.NetworkForward, error)
+	DeleteForward(context.Context, string, string, int) error
+	ReplaceGateway(context.Context, string, *resources.SizingRequirements, string) error
+	EnableFailover(context.Context, string, string) error
+	DisableFailover(context.Context, string) error
+	RotateKeys(context.Context, string) error
 }
 
 // NetworkHandler an implementation of NetworkAPI
@@ -71,9 +80,10 @@ func NewNetworkHandler(svc iaas.Service) NetworkAPI {
 }
 
 // Create creates a network
+// ipVersion set to IPv6 creates a dual-stack network, using cidrV6 as IPv6 range if not empty
 func (handler *NetworkHandler) Create(
 	ctx context.Context,
-	name string, cidr string, ipVersion ipversion.Enum,
+	name string, cidr string, ipVersion ipversion.Enum, cidrV6 string,
 	sizing resources.SizingRequirements, theos string, gwname string,
 	failover bool,
 ) (network *resources.Network, err error) {
@@ -90,7 +100,7 @@ func (handler *NetworkHandler) Create(
 
 	tracer := concurrency.NewTracer(
 		nil,
-		fmt.Sprintf("('%s', '%s', %s, <sizing>, '%s', '%s', %v)", name, cidr, ipVersion.String(), theos, gwname, failover),
+		fmt.Sprintf("('%s', '%s', %s, '%s', <sizing>, '%s', '%s', %v)", name, cidr, ipVersion.String(), cidrV6, theos, gwname, failover),
 		true,
 	).WithStopwatch().GoingIn()
 	defer tracer.OnExitTrace()()
@@ -119,12 +129,31 @@ func (handler *NetworkHandler) Create(
 		return nil, fmt.Errorf("cannot create such a network, CIDR must be not routable; please provide an appropriate CIDR (RFC1918)")
 	}
 
+	// Verify the IPv6 range of a dual-stack network is not routable either
+	if ipVersion == ipversion.IPv6 {
+		if !handler.service.GetCapabilities().DualStackNetworking {
+			return nil, scerr.NotImplementedError("provider doesn't support dual-stack networks")
+		}
+		if cidrV6 != "" {
+			local, err := utils.IsIPv6CIDRLocal(cidrV6)
+			if err != nil {
+				return nil, scerr.InvalidParameterError("cidrV6", err.Error())
+			}
+			if !local {
+				return nil, fmt.Errorf("cannot create such a network, IPv6 CIDR must be made of unique local addresses; please provide an appropriate CIDR (RFC4193, fd00::/8)")
+			}
+		}
+	} else if cidrV6 != "" {
+		return nil, scerr.InvalidParameterError("cidrV6", "cannot be set if the network is not dual-stack")
+	}
+
 	// Create the network
 	logrus.Debugf("Creating network '%s' ...", name)
 	network, err = handler.service.CreateNetwork(resources.NetworkRequest{
 		Name:      name,
 		IPVersion: ipVersion,
 		CIDR:      cidr,
+		CIDRv6:    cidrV6,
 	})
 	if err != nil {
 		switch err.(type) {
@@ -489,6 +518,16 @@ func (handler *NetworkHandler) Create(
 		}
 	}
 
+	// Starts the DNS server serving the zone of the network on the gateway(s); the network is usable without it, so
+	// a failure doesn't abort the creation (the zone is recorded only if the DNS server runs on all the gateways)
+	gateways := []*resources.Host{primaryGateway}
+	if secondaryGateway != nil {
+		gateways = append(gateways, secondaryGateway)
+	}
+	if dnsErr := handler.setUpDNS(ctx, network, gateways); dnsErr != nil {
+		logrus.Warnf("network '%s' created without DNS zone: %v", network.Name, dnsErr)
+	}
+
 	select {
 	case <-ctx.Done():
 		logrus.Warnf("Network creation cancelled by user")
@@ -669,14 +708,14 @@ func (handler *NetworkHandler) installPhase2OnGateway(task concurrency.Task, par
 	// logrus.Debugf("Configuring gateway '%s', phase 2", gw.Name)
 	returnCode, _, _, err := sshHandler.Run(task.GetContext(), gw.Name, command)
 	if err != nil {
-		retrieveForensicsData(task.GetContext(), sshHandler, gw)
+		retrieveForensicsData(task.GetContext(), sshHandler, gw, "phase2")
 
 		return nil, err
 	}
 	if returnCode != 0 {
-		retrieveForensicsData(task.GetContext(), sshHandler, gw)
+		retrieveForensicsData(task.GetContext(), sshHandler, gw, "phase2")
 
-		warnings, errs := getPhaseWarningsAndErrors(task.GetContext(), sshHandler, gw)
+		warnings, errs := getPhaseWarningsAndErrors(task.GetContext(), sshHandler, gw, "phase2")
 
 		return nil, fmt.Errorf("failed to finalize gateway '%s' installation: errorcode '%d', warnings '%s', errors '%s'", gw.Name, returnCode, warnings, errs)
 	}
@@ -799,6 +838,88 @@ func (handler *NetworkHandler) Inspect(ctx context.Context, ref string) (network
 	return mn.Get()
 }
 
+// Import registers in metadata a network created outside SafeScale, identified by its ID (or name) on provider side
+// If gwRef is not empty, the host it designates is imported as the gateway of the network, after SSH access has been
+// validated using privateKey; account, if not empty, is used to prepare the gateway as buildImportedHost does
+func (handler *NetworkHandler) Import(ctx context.Context, id string, gwRef string, privateKey string, account string) (network *resources.Network, err error) {
+	if handler == nil {
+		return nil, scerr.InvalidInstanceError()
+	}
+	if id == "" {
+		return nil, scerr.InvalidParameterError("id", "cannot be empty string")
+	}
+	if gwRef != "" && privateKey == "" {
+		return nil, scerr.InvalidParameterError("privateKey", "cannot be empty string when a gateway is imported")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s', <privateKey>, '%s')", id, gwRef, account), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	if mn, err := metadata.LoadNetwork(handler.service, id); err == nil {
+		if known, err := mn.Get(); err == nil {
+			return nil, resources.ResourceDuplicateError("network", known.Name)
+		}
+		return nil, resources.ResourceDuplicateError("network", id)
+	} else if _, ok := err.(scerr.ErrNotFound); !ok {
+		return nil, err
+	}
+
+	network, err = handler.service.GetNetwork(id)
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotFound); !ok {
+			return nil, err
+		}
+		network, err = handler.service.GetNetworkByName(id)
+		if err != nil {
+			if _, ok := err.(scerr.ErrNotFound); ok {
+				return nil, resources.ResourceNotFoundError("network", id)
+			}
+			return nil, err
+		}
+	}
+	if _, err = metadata.LoadNetwork(handler.service, network.ID); err == nil {
+		return nil, resources.ResourceDuplicateError("network", network.Name)
+	}
+
+	mn, err := metadata.SaveNetwork(handler.service, network)
+	if err != nil {
+		return nil, err
+	}
+
+	if gwRef != "" {
+		defer func() {
+			if err != nil {
+				derr := mn.Delete()
+				if derr != nil {
+					logrus.Errorf("failed to remove metadata of network '%s' after import failure: %v", network.Name, derr)
+				}
+			}
+		}()
+
+		var gw *resources.Host
+		gw, err = buildImportedHost(ctx, handler.service, gwRef, privateKey, account, network.ID, true)
+		if err != nil {
+			return nil, err
+		}
+		_, err = metadata.SaveGateway(handler.service, gw, network.ID)
+		if err != nil {
+			return nil, err
+		}
+		err = mn.Reload()
+		if err != nil {
+			return nil, err
+		}
+		network, err = mn.Get()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logrus.Infof("Network '%s' (%s) imported", network.Name, network.ID)
+	return network, nil
+}
+
 // Delete deletes network referenced by ref
 func (handler *NetworkHandler) Delete(ctx context.Context, ref string) (err error) {
 	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
@@ -860,6 +981,11 @@ func (handler *NetworkHandler) Delete(ctx context.Context, ref string) (err erro
 		return err
 	}
 
+	// Removes the peerings on the side of the peer networks; a failure doesn't prevent the deletion
+	if uerr := handler.unpeer(ctx, network); uerr != nil {
+		logrus.Error(uerr)
+	}
+
 	// Delete gateway(s)
 	if network.GatewayID != "" {
 		mh, err := metadata.LoadHost(handler.service, network.GatewayID)
@@ -0,0 +1,591 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/curve25519"
+
+	"github.com/CS-SI/SafeScale/lib/server/iaas"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/networkproperty"
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/metadata"
+	"github.com/CS-SI/SafeScale/lib/system"
+	"github.com/CS-SI/SafeScale/lib/utils"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+)
+
+// wireguardBasePort is the UDP port of the first WireGuard interface of a gateway; the next ones use the following ports
+const wireguardBasePort = 51820
+
+// peeringSide gathers what is needed to set up one end of a peering
+type peeringSide struct {
+	tenant   string
+	service  iaas.Service
+	network  *resources.Network
+	gateways []*resources.Host
+	// endpoint is the public IP the other side sends the tunneled traffic to
+	endpoint string
+	// routeIP is the private IP the hosts of the network route the traffic to the peer network through
+	routeIP    string
+	index      int
+	privateKey string
+	publicKey  string
+}
+
+// Peer sets up a WireGuard tunnel between the gateways of the network ref of the tenant of the handler and the ones of
+// the network peerRef of peerTenant, then routes the traffic of the hosts of each network to the other one through it
+func (handler *NetworkHandler) Peer(ctx context.Context, ref, tenant string, peerService iaas.Service, peerRef, peerTenant string) (err error) {
+	if handler == nil {
+		return scerr.InvalidInstanceError()
+	}
+	if ref == "" {
+		return scerr.InvalidParameterError("ref", "cannot be empty string")
+	}
+	if peerService == nil {
+		return scerr.InvalidParameterError("peerService", "cannot be nil")
+	}
+	if peerRef == "" {
+		return scerr.InvalidParameterError("peerRef", "cannot be empty string")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s@%s', '%s@%s')", ref, tenant, peerRef, peerTenant), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	local, err := loadPeeringSide(handler.service, tenant, ref)
+	if err != nil {
+		return err
+	}
+	remote, err := loadPeeringSide(peerService, peerTenant, peerRef)
+	if err != nil {
+		return err
+	}
+	if local.tenant == remote.tenant && local.network.ID == remote.network.ID {
+		return scerr.InvalidRequestError("cannot peer a network with itself")
+	}
+	overlap, err := cidrOverlap(local.network.CIDR, remote.network.CIDR)
+	if err != nil {
+		return err
+	}
+	if overlap {
+		return scerr.InvalidRequestError(fmt.Sprintf("cannot peer network '%s' (%s) with network '%s' (%s): CIDRs overlap", local.network.Name, local.network.CIDR, remote.network.Name, remote.network.CIDR))
+	}
+
+	sides := []*peeringSide{local, remote}
+	otherSide := func(side *peeringSide) *peeringSide {
+		if side == local {
+			return remote
+		}
+		return local
+	}
+	for _, side := range sides {
+		other := otherSide(side)
+		err = side.network.Properties.LockForRead(networkproperty.PeeringsV1).ThenUse(func(clonable data.Clonable) error {
+			peerings := clonable.(*propsv1.NetworkPeerings).ByPeer
+			if _, ok := peerings[peeringKey(other.network.Name, other.tenant)]; ok {
+				return resources.ResourceDuplicateError("peering", peeringKey(side.network.Name, side.tenant)+" <-> "+peeringKey(other.network.Name, other.tenant))
+			}
+			side.index = freePeeringIndex(peerings)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		side.privateKey, side.publicKey, err = generateWireguardKeys()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Sets up the tunnel on the gateways of both sides; on failure, removes what has been set up
+	var done []*peeringSide
+	defer func() {
+		if err != nil {
+			for _, side := range done {
+				derr := side.tearDown(ctx, otherSide(side).network.CIDR)
+				if derr != nil {
+					err = scerr.AddConsequence(err, derr)
+				}
+			}
+		}
+	}()
+	for _, side := range sides {
+		done = append(done, side)
+		err = side.setUp(ctx, otherSide(side))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Records the peering on both sides
+	now := time.Now()
+	for _, side := range sides {
+		other := otherSide(side)
+		err = side.network.Properties.LockForWrite(networkproperty.PeeringsV1).ThenUse(func(clonable data.Clonable) error {
+			networkPeeringsV1 := clonable.(*propsv1.NetworkPeerings)
+			if !side.network.Properties.Lookup(networkproperty.PeeringsV1) {
+				networkPeeringsV1.Reset()
+			}
+			networkPeeringsV1.ByPeer[peeringKey(other.network.Name, other.tenant)] = &propsv1.NetworkPeering{
+				PeerTenant:      other.tenant,
+				PeerNetworkID:   other.network.ID,
+				PeerNetworkName: other.network.Name,
+				PeerCIDR:        other.network.CIDR,
+				PeerEndpoint:    other.endpointWithPort(),
+				PeerPublicKey:   other.publicKey,
+				Interface:       side.wireguardInterface(),
+				Port:            side.port(),
+				PublicKey:       side.publicKey,
+				Created:         now,
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		_, err = metadata.SaveNetwork(side.service, side.network)
+		if err != nil {
+			return err
+		}
+	}
+
+	logrus.Infof("Network '%s' of tenant '%s' peered with network '%s' of tenant '%s'", local.network.Name, local.tenant, remote.network.Name, remote.tenant)
+	return nil
+}
+
+// unpeer removes the peerings of the network on the side of the peer networks, when the network is deleted;
+// the gateways of the network being deleted with it, nothing is done on its side
+func (handler *NetworkHandler) unpeer(ctx context.Context, network *resources.Network) error {
+	var peerings []*propsv1.NetworkPeering
+	err := network.Properties.LockForRead(networkproperty.PeeringsV1).ThenUse(func(clonable data.Clonable) error {
+		for _, peering := range clonable.(*propsv1.NetworkPeerings).ByPeer {
+			peerings = append(peerings, peering)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, peering := range peerings {
+		peerService, err := iaas.UseService(peering.PeerTenant)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("tenant '%s': %s", peering.PeerTenant, err.Error()))
+			continue
+		}
+		remote, err := loadPeeringSide(peerService, peering.PeerTenant, peering.PeerNetworkID)
+		if err != nil {
+			if _, ok := err.(scerr.ErrNotFound); ok {
+				continue
+			}
+			errs = append(errs, fmt.Sprintf("network '%s' of tenant '%s': %s", peering.PeerNetworkName, peering.PeerTenant, err.Error()))
+			continue
+		}
+		key := ""
+		err = remote.network.Properties.LockForRead(networkproperty.PeeringsV1).ThenUse(func(clonable data.Clonable) error {
+			for k, p := range clonable.(*propsv1.NetworkPeerings).ByPeer {
+				if p.PeerNetworkID == network.ID {
+					key = k
+					remote.index = peeringIndex(p)
+				}
+			}
+			return nil
+		})
+		if err == nil && key != "" {
+			err = remote.tearDown(ctx, network.CIDR)
+		}
+		if err == nil && key != "" {
+			err = remote.network.Properties.LockForWrite(networkproperty.PeeringsV1).ThenUse(func(clonable data.Clonable) error {
+				delete(clonable.(*propsv1.NetworkPeerings).ByPeer, key)
+				return nil
+			})
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("network '%s' of tenant '%s': %s", peering.PeerNetworkName, peering.PeerTenant, err.Error()))
+			continue
+		}
+		if key == "" {
+			continue
+		}
+		_, err = metadata.SaveNetwork(peerService, remote.network)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("network '%s' of tenant '%s': %s", peering.PeerNetworkName, peering.PeerTenant, err.Error()))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to remove peering(s) of network '%s': %s", network.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// renewPeerings sets up again the peerings of the network once its gateways have been replaced; the WireGuard private
+// keys being kept nowhere, new keys are generated for both sides of each peering
+func (handler *NetworkHandler) renewPeerings(ctx context.Context, network *resources.Network) error {
+	var peerings []*propsv1.NetworkPeering
+	err := network.Properties.LockForRead(networkproperty.PeeringsV1).ThenUse(func(clonable data.Clonable) error {
+		for _, peering := range clonable.(*propsv1.NetworkPeerings).ByPeer {
+			peerings = append(peerings, peering)
+		}
+		return nil
+	})
+	if err != nil || len(peerings) == 0 {
+		return err
+	}
+
+	local, err := loadPeeringSide(handler.service, "", network.ID)
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for _, peering := range peerings {
+		err = renewPeering(ctx, local, peering)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("network '%s' of tenant '%s': %s", peering.PeerNetworkName, peering.PeerTenant, err.Error()))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to renew peering(s) of network '%s': %s", network.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// renewPeering sets up again one peering of the local side with new keys, then records them on both sides
+func renewPeering(ctx context.Context, local *peeringSide, peering *propsv1.NetworkPeering) (err error) {
+	peerService, err := iaas.UseService(peering.PeerTenant)
+	if err != nil {
+		return err
+	}
+	remote, err := loadPeeringSide(peerService, peering.PeerTenant, peering.PeerNetworkID)
+	if err != nil {
+		return err
+	}
+	remoteKey := ""
+	err = remote.network.Properties.LockForRead(networkproperty.PeeringsV1).ThenUse(func(clonable data.Clonable) error {
+		for k, p := range clonable.(*propsv1.NetworkPeerings).ByPeer {
+			if p.PeerNetworkID == local.network.ID {
+				remoteKey = k
+				remote.index = peeringIndex(p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if remoteKey == "" {
+		return scerr.NotFoundError(fmt.Sprintf("peering with network '%s' not found on the peer side", local.network.Name))
+	}
+	local.index = peeringIndex(peering)
+
+	for _, side := range []*peeringSide{local, remote} {
+		side.privateKey, side.publicKey, err = generateWireguardKeys()
+		if err != nil {
+			return err
+		}
+	}
+	err = local.setUp(ctx, remote)
+	if err != nil {
+		return err
+	}
+	err = remote.setUp(ctx, local)
+	if err != nil {
+		return err
+	}
+
+	records := []struct {
+		side, other *peeringSide
+		key         string
+	}{
+		{local, remote, peeringKey(remote.network.Name, remote.tenant)},
+		{remote, local, remoteKey},
+	}
+	for _, r := range records {
+		err = r.side.network.Properties.LockForWrite(networkproperty.PeeringsV1).ThenUse(func(clonable data.Clonable) error {
+			p, ok := clonable.(*propsv1.NetworkPeerings).ByPeer[r.key]
+			if !ok {
+				return resources.ResourceNotFoundError("peering", r.key)
+			}
+			p.PeerEndpoint = r.other.endpointWithPort()
+			p.PeerPublicKey = r.other.publicKey
+			p.PublicKey = r.side.publicKey
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		_, err = metadata.SaveNetwork(r.side.service, r.side.network)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyPeeringRoutes routes the traffic of a new host of the network to the peer networks through the gateway
+func applyPeeringRoutes(ctx context.Context, svc iaas.Service, network *resources.Network, hostID string) error {
+	var cidrs []string
+	err := network.Properties.LockForRead(networkproperty.PeeringsV1).ThenUse(func(clonable data.Clonable) error {
+		for _, peering := range clonable.(*propsv1.NetworkPeerings).ByPeer {
+			cidrs = append(cidrs, peering.PeerCIDR)
+		}
+		return nil
+	})
+	if err != nil || len(cidrs) == 0 {
+		return err
+	}
+	side, err := loadPeeringSide(svc, "", network.ID)
+	if err != nil {
+		return err
+	}
+	for _, cidr := range cidrs {
+		err = exec(ctx, "add_peering_route.sh", side.routeParams(cidr), hostID, svc)
+		if err != nil {
+			return fmt.Errorf("failed to route '%s' through the gateway: %s", cidr, err.Error())
+		}
+	}
+	return nil
+}
+
+// loadPeeringSide loads the network and its gateways
+func loadPeeringSide(svc iaas.Service, tenant, ref string) (*peeringSide, error) {
+	mn, err := metadata.LoadNetwork(svc, ref)
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotFound); ok {
+			return nil, resources.ResourceNotFoundError("network", ref)
+		}
+		return nil, err
+	}
+	network, err := mn.Get()
+	if err != nil {
+		return nil, err
+	}
+	if network.GatewayID == "" {
+		return nil, scerr.InvalidRequestError(fmt.Sprintf("network '%s' has no gateway", network.Name))
+	}
+
+	side := &peeringSide{tenant: tenant, service: svc, network: network}
+	for _, id := range []string{network.GatewayID, network.SecondaryGatewayID} {
+		if id == "" {
+			continue
+		}
+		mh, err := metadata.LoadHost(svc, id)
+		if err != nil {
+			return nil, err
+		}
+		gw, err := mh.Get()
+		if err != nil {
+			return nil, err
+		}
+		side.gateways = append(side.gateways, gw)
+	}
+	if network.VIP != nil {
+		side.endpoint = network.VIP.PublicIP
+		side.routeIP = network.VIP.PrivateIP
+	}
+	if side.endpoint == "" {
+		side.endpoint = side.gateways[0].GetPublicIP()
+	}
+	if side.routeIP == "" {
+		side.routeIP = side.gateways[0].GetPrivateIP()
+	}
+	if side.endpoint == "" {
+		return nil, scerr.InvalidRequestError(fmt.Sprintf("gateway of network '%s' has no public IP", network.Name))
+	}
+	return side, nil
+}
+
+// setUp configures the WireGuard interface on the gateways of the side and the routes on its hosts
+func (side *peeringSide) setUp(ctx context.Context, other *peeringSide) error {
+	sshHandler := NewSSHHandler(side.service)
+	bashLibrary, err := system.GetBashLibrary()
+	if err != nil {
+		return err
+	}
+	// The same key is used on both gateways of a HA network, the peer reaching the one holding the VIP
+	f, err := system.CreateTempFileFromString(side.privateKey, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %s", err.Error())
+	}
+	defer func() {
+		if derr := utils.LazyRemove(f.Name()); derr != nil {
+			logrus.Warnf("failed to remove temporary file '%s': %v", f.Name(), derr)
+		}
+	}()
+
+	uploaded := fmt.Sprintf("/tmp/%s.key", side.wireguardInterface())
+	for _, gw := range side.gateways {
+		sshConfig, err := sshHandler.GetConfig(ctx, gw)
+		if err != nil {
+			return err
+		}
+		retcode, _, stderr, err := sshConfig.Copy(uploaded, f.Name(), true)
+		if err != nil {
+			return fmt.Errorf("failed to upload WireGuard key to gateway '%s': %s", gw.Name, err.Error())
+		}
+		if retcode != 0 {
+			return fmt.Errorf("failed to upload WireGuard key to gateway '%s': %s", gw.Name, stderr)
+		}
+		params := map[string]interface{}{
+			"reserved_BashLibrary": bashLibrary,
+			"Interface":            side.wireguardInterface(),
+			"Port":                 side.port(),
+			"UploadedKey":          uploaded,
+			"PeerPublicKey":        other.publicKey,
+			"PeerEndpoint":         other.endpointWithPort(),
+			"PeerCIDR":             other.network.CIDR,
+		}
+		err = exec(ctx, "wireguard_peer.sh", params, gw.ID, side.service)
+		if err != nil {
+			return fmt.Errorf("failed to set up WireGuard on gateway '%s': %s", gw.Name, err.Error())
+		}
+	}
+
+	for _, hostID := range side.hostIDs() {
+		err = exec(ctx, "add_peering_route.sh", side.routeParams(other.network.CIDR), hostID, side.service)
+		if err != nil {
+			return fmt.Errorf("failed to route '%s' through the gateway on host '%s': %s", other.network.CIDR, hostID, err.Error())
+		}
+	}
+	return nil
+}
+
+// tearDown removes the WireGuard interface from the gateways of the side and the routes to peerCIDR from its hosts
+func (side *peeringSide) tearDown(ctx context.Context, peerCIDR string) error {
+	bashLibrary, err := system.GetBashLibrary()
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for _, hostID := range side.hostIDs() {
+		err = exec(ctx, "remove_peering_route.sh", side.routeParams(peerCIDR), hostID, side.service)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("host '%s': %s", hostID, err.Error()))
+		}
+	}
+	for _, gw := range side.gateways {
+		params := map[string]interface{}{
+			"reserved_BashLibrary": bashLibrary,
+			"Interface":            side.wireguardInterface(),
+			"Port":                 side.port(),
+		}
+		err = exec(ctx, "wireguard_unpeer.sh", params, gw.ID, side.service)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("gateway '%s': %s", gw.Name, err.Error()))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to tear down peering on network '%s': %s", side.network.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// hostIDs returns the IDs of the hosts of the network, gateways excluded
+func (side *peeringSide) hostIDs() []string {
+	var list []string
+	_ = side.network.Properties.LockForRead(networkproperty.HostsV1).ThenUse(func(clonable data.Clonable) error {
+		for id := range clonable.(*propsv1.NetworkHosts).ByID {
+			if id != side.network.GatewayID && id != side.network.SecondaryGatewayID {
+				list = append(list, id)
+			}
+		}
+		return nil
+	})
+	return list
+}
+
+func (side *peeringSide) routeParams(cidr string) map[string]interface{} {
+	return map[string]interface{}{
+		"CIDR": cidr,
+		"Via":  side.routeIP,
+		"Unit": "safescale-route-" + strings.NewReplacer(".", "-", "/", "-", ":", "-").Replace(cidr) + ".service",
+	}
+}
+
+func (side *peeringSide) wireguardInterface() string {
+	return fmt.Sprintf("wg%d", side.index)
+}
+
+func (side *peeringSide) port() int {
+	return wireguardBasePort + side.index
+}
+
+func (side *peeringSide) endpointWithPort() string {
+	return fmt.Sprintf("%s:%d", side.endpoint, side.port())
+}
+
+// peeringKey returns the key of a peering in NetworkPeerings.ByPeer
+func peeringKey(network, tenant string) string {
+	return network + "@" + tenant
+}
+
+// peeringIndex returns the index of the WireGuard interface of a recorded peering
+func peeringIndex(peering *propsv1.NetworkPeering) int {
+	return peering.Port - wireguardBasePort
+}
+
+// freePeeringIndex returns the lowest index of WireGuard interface not used by the peerings
+func freePeeringIndex(peerings map[string]*propsv1.NetworkPeering) int {
+	used := map[int]bool{}
+	for _, p := range peerings {
+		used[peeringIndex(p)] = true
+	}
+	i := 0
+	for used[i] {
+		i++
+	}
+	return i
+}
+
+// cidrOverlap tells if two CIDRs have addresses in common
+func cidrOverlap(a, b string) (bool, error) {
+	_, netA, err := net.ParseCIDR(a)
+	if err != nil {
+		return false, fmt.Errorf("invalid CIDR '%s': %s", a, err.Error())
+	}
+	_, netB, err := net.ParseCIDR(b)
+	if err != nil {
+		return false, fmt.Errorf("invalid CIDR '%s': %s", b, err.Error())
+	}
+	return netA.Contains(netB.IP) || netB.Contains(netA.IP), nil
+}
+
+// generateWireguardKeys returns a new WireGuard key pair, base64 encoded
+func generateWireguardKeys() (privateKey string, publicKey string, err error) {
+	var private, public [32]byte
+	_, err = rand.Read(private[:])
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate WireGuard key: %s", err.Error())
+	}
+	// Clamps the key as expected by Curve25519
+	private[0] &= 248
+	private[31] = (private[31] & 127) | 64
+	curve25519.ScalarBaseMult(&public, &private)
+	return base64.StdEncoding.EncodeToString(private[:]), base64.StdEncoding.EncodeToString(public[:]), nil
+}
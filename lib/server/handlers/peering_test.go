@@ -0,0 +1,74 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/curve25519"
+
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+)
+
+func TestCidrOverlap(t *testing.T) {
+	overlap, err := cidrOverlap("192.168.0.0/24", "192.168.1.0/24")
+	assert.NoError(t, err)
+	assert.False(t, overlap)
+
+	overlap, err = cidrOverlap("10.0.0.0/8", "10.1.2.0/24")
+	assert.NoError(t, err)
+	assert.True(t, overlap)
+
+	overlap, err = cidrOverlap("10.1.2.0/24", "10.0.0.0/8")
+	assert.NoError(t, err)
+	assert.True(t, overlap)
+
+	_, err = cidrOverlap("10.0.0.0", "10.0.0.0/8")
+	assert.Error(t, err)
+}
+
+func TestFreePeeringIndex(t *testing.T) {
+	peerings := map[string]*propsv1.NetworkPeering{}
+	assert.Equal(t, 0, freePeeringIndex(peerings))
+
+	peerings["a@t1"] = &propsv1.NetworkPeering{Port: wireguardBasePort}
+	peerings["b@t2"] = &propsv1.NetworkPeering{Port: wireguardBasePort + 2}
+	assert.Equal(t, 1, freePeeringIndex(peerings))
+
+	peerings["c@t2"] = &propsv1.NetworkPeering{Port: wireguardBasePort + 1}
+	assert.Equal(t, 3, freePeeringIndex(peerings))
+}
+
+func TestGenerateWireguardKeys(t *testing.T) {
+	privateKey, publicKey, err := generateWireguardKeys()
+	assert.NoError(t, err)
+
+	private, err := base64.StdEncoding.DecodeString(privateKey)
+	assert.NoError(t, err)
+	assert.Len(t, private, 32)
+
+	var priv, pub [32]byte
+	copy(priv[:], private)
+	curve25519.ScalarBaseMult(&pub, &priv)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(pub[:]), publicKey)
+
+	otherPrivateKey, _, err := generateWireguardKeys()
+	assert.NoError(t, err)
+	assert.NotEqual(t, privateKey, otherPrivateKey)
+}
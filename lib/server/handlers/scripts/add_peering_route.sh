@@ -0,0 +1,40 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# add_peering_route.sh
+#
+# Routes the traffic to the peer network {{.CIDR}} through the gateway of the network, as a systemd service to survive reboots
+
+cat >/etc/systemd/system/{{.Unit}} <<-EOF
+[Unit]
+Description=Route to peer network {{.CIDR}}
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=/sbin/ip route replace {{.CIDR}} via {{.Via}}
+ExecStop=/sbin/ip route del {{.CIDR}}
+
+[Install]
+WantedBy=multi-user.target
+EOF
+
+systemctl daemon-reload
+systemctl enable {{.Unit}} || { echo "failed to enable {{.Unit}}" >&2; exit 1; }
+systemctl restart {{.Unit}} || { echo "failed to route {{.CIDR}} via {{.Via}}" >&2; exit 1; }
+exit 0
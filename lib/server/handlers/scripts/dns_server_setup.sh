@@ -0,0 +1,69 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# dns_server_setup.sh
+#
+# Installs on a gateway dnsmasq, serving to the hosts of the network the zone {{.Domain}} and forwarding the other queries.
+# The records of the zone are written in /etc/dnsmasq.safescale.hosts by dns_zone_update.sh
+
+{{.reserved_BashLibrary}}
+
+fail() {
+    echo "$*" >&2
+    exit 1
+}
+
+if ! which dnsmasq &>/dev/null; then
+    case $LINUX_KIND in
+        debian|ubuntu)
+            sfApt update && sfApt install -y dnsmasq || fail "failed to install dnsmasq"
+            # Leaves the resolver of the gateway itself untouched
+            echo "IGNORE_RESOLVCONF=yes" >>/etc/default/dnsmasq
+            ;;
+        redhat|rhel|centos|fedora)
+            yum install -y dnsmasq || fail "failed to install dnsmasq"
+            ;;
+        *)
+            fail "Unsupported Linux distribution '$LINUX_KIND'"
+            ;;
+    esac
+fi
+
+touch /etc/dnsmasq.safescale.hosts
+cat >/etc/dnsmasq.d/safescale.conf <<-EOF
+# Zone {{.Domain}} of the network, managed by SafeScale
+listen-address={{ .ListenAddresses }}
+bind-dynamic
+no-resolv
+{{- range .Upstream }}
+server={{ . }}
+{{- end }}
+domain-needed
+bogus-priv
+local=/{{.Domain}}/
+domain={{.Domain}}
+no-hosts
+addn-hosts=/etc/dnsmasq.safescale.hosts
+EOF
+
+# Allows DNS queries from the network only
+sfFirewallAdd --direct --add-rule ipv4 filter INPUT 0 -s {{.CIDR}} -p udp --dport 53 -j ACCEPT
+sfFirewallAdd --direct --add-rule ipv4 filter INPUT 0 -s {{.CIDR}} -p tcp --dport 53 -j ACCEPT
+sfFirewallReload || fail "failed to reload firewall rules"
+
+systemctl enable dnsmasq || fail "failed to enable dnsmasq"
+systemctl restart dnsmasq || fail "failed to start dnsmasq"
+exit 0
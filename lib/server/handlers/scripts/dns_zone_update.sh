@@ -0,0 +1,29 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# dns_zone_update.sh
+#
+# Replaces the records of the zone served by dnsmasq on a gateway; the whole zone is written each time,
+# so the gateways of a network in failover serve the same records
+
+cat >/etc/dnsmasq.safescale.hosts.new <<-'EOF'
+{{.Hosts}}
+EOF
+mv -f /etc/dnsmasq.safescale.hosts.new /etc/dnsmasq.safescale.hosts
+
+# dnsmasq reads again its additional hosts files on SIGHUP
+systemctl kill -s HUP dnsmasq || { echo "failed to reload dnsmasq" >&2; exit 1; }
+exit 0
@@ -0,0 +1,38 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# forward_sync.sh
+#
+# Replaces the port forwarding rules of the public zone of a gateway by the ones recorded in the metadata of its network.
+# Rules are permanent, so they survive reboots of the gateway.
+
+{{.reserved_BashLibrary}}
+
+fail() {
+    echo "$*" >&2
+    exit 1
+}
+
+for rule in $(firewall-cmd --permanent --zone=public --list-forward-ports); do
+    sfFirewallAdd --zone=public --remove-forward-port="$rule" || fail "failed to remove forwarding rule '$rule'"
+done
+{{- range .Forwards }}
+sfFirewallAdd --zone=public --add-forward-port=port={{.PublicPort}}:proto={{.Protocol}}:toport={{.Port}}:toaddr={{.HostIP}} || fail "failed to add forwarding rule {{.Protocol}}/{{.PublicPort}}"
+{{- end }}
+# DNAT to another address requires masquerading
+sfFirewallAdd --zone=public --add-masquerade
+sfFirewallReload || fail "failed to reload firewall rules"
+exit 0
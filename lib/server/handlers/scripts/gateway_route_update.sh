@@ -0,0 +1,39 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# gateway_route_update.sh
+#
+# Moves the default route and the DNS server of a host from the former gateway {{.OldIP}} of its network to the new one
+# {{.NewIP}}, in the running system and in the network configuration files so the change survives reboots
+
+files="/etc/netplan /etc/network/interfaces /etc/network/interfaces.d /etc/sysconfig/network /etc/sysconfig/network-scripts"
+files+=" /etc/resolv.conf /etc/resolvconf/resolv.conf.d /etc/systemd/resolved.conf"
+for f in $(grep -rlwF "{{.OldIP}}" $files 2>/dev/null); do
+    sed -i --follow-symlinks "s/\b$(echo "{{.OldIP}}" | sed 's/\./\\./g')\b/{{.NewIP}}/g" "$f" || { echo "failed to update '$f'" >&2; exit 1; }
+done
+ip route replace default via {{.NewIP}} || { echo "failed to route default traffic via {{.NewIP}}" >&2; exit 1; }
+{{- if .NewIPv6 }}
+
+for f in $(grep -rlwF "{{.OldIPv6}}" $files 2>/dev/null); do
+    sed -i --follow-symlinks "s/\b{{.OldIPv6}}\b/{{.NewIPv6}}/g" "$f" || { echo "failed to update '$f'" >&2; exit 1; }
+done
+ip -6 route replace default via {{.NewIPv6}} || { echo "failed to route default IPv6 traffic via {{.NewIPv6}}" >&2; exit 1; }
+{{- end }}
+
+if systemctl is-active systemd-resolved &>/dev/null; then
+    systemctl restart systemd-resolved || { echo "failed to restart systemd-resolved" >&2; exit 1; }
+fi
+exit 0
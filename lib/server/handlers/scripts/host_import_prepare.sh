@@ -0,0 +1,51 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# host_import_prepare.sh
+#
+# Prepares a host created outside SafeScale as the userdata would have done: creates the operator account
+# authorized to log in with the key of the host, allowed to use sudo, and the folders SafeScale works in
+
+USER_NAME={{ .User }}
+SUDOERS_FILE=/etc/sudoers.d/${USER_NAME}
+
+if ! id -u ${USER_NAME} &>/dev/null; then
+    useradd ${USER_NAME} --home-dir /home/${USER_NAME} --shell /bin/bash --comment "" --create-home || { echo "failed to create account '${USER_NAME}'" >&2; exit 192; }
+fi
+
+cat >${SUDOERS_FILE} <<EOT
+Defaults:${USER_NAME} !requiretty
+${USER_NAME} ALL=(ALL) NOPASSWD:ALL
+EOT
+chmod 0440 ${SUDOERS_FILE}
+visudo -cf ${SUDOERS_FILE} &>/dev/null || { rm -f ${SUDOERS_FILE}; echo "invalid sudoers entry for '${USER_NAME}'" >&2; exit 193; }
+
+HOME_DIR=$(getent passwd ${USER_NAME} | cut -d: -f6)
+mkdir -p ${HOME_DIR}/.ssh
+grep -qxF '{{ .PublicKey }}' ${HOME_DIR}/.ssh/authorized_keys 2>/dev/null || echo '{{ .PublicKey }}' >>${HOME_DIR}/.ssh/authorized_keys
+cat >${HOME_DIR}/.ssh/id_rsa <<'EOT'
+{{ .PrivateKey }}
+EOT
+chmod 0700 ${HOME_DIR}/.ssh
+chmod 0600 ${HOME_DIR}/.ssh/*
+chown -R ${USER_NAME}:${USER_NAME} ${HOME_DIR}
+
+mkdir -p /opt/safescale/var/log /opt/safescale/var/run /opt/safescale/var/state /opt/safescale/var/tmp || { echo "failed to create SafeScale folders" >&2; exit 194; }
+chown -R ${USER_NAME}:${USER_NAME} /opt/safescale
+chmod -R 0640 /opt/safescale
+find /opt/safescale -type d -exec chmod a+rx {} \;
+chmod 1777 /opt/safescale/var/tmp
+exit 0
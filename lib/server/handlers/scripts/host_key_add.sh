@@ -0,0 +1,27 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# host_key_add.sh
+#
+# Authorizes a new SSH public key for the operator user, besides the current one, during the rotation of the keypair of the host
+
+SSH_DIR=$(getent passwd {{ .User }} | cut -d: -f6)/.ssh
+mkdir -p ${SSH_DIR}
+echo "{{ .PublicKey }}" >>${SSH_DIR}/authorized_keys || { echo "failed to authorize the new key" >&2; exit 192; }
+chown {{ .User }}: ${SSH_DIR} ${SSH_DIR}/authorized_keys
+chmod 0700 ${SSH_DIR}
+chmod 0600 ${SSH_DIR}/authorized_keys
+exit 0
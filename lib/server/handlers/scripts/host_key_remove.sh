@@ -0,0 +1,41 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# host_key_remove.sh
+#
+# Revokes an SSH public key of the operator user: the previous key at the end of the rotation of the keypair of the host
+# (then also replacing the private key of the user), or the new one when the rotation is rolled back
+
+SSH_DIR=$(getent passwd {{ .User }} | cut -d: -f6)/.ssh
+AUTHORIZED_KEYS=${SSH_DIR}/authorized_keys
+KEY_BLOB=$(echo "{{ .PublicKey }}" | awk '{print $2}')
+[ -z "${KEY_BLOB}" ] && { echo "invalid public key" >&2; exit 192; }
+
+grep -vF "${KEY_BLOB}" ${AUTHORIZED_KEYS} >${AUTHORIZED_KEYS}.new
+# Never leaves the operator user without any key
+[ -s ${AUTHORIZED_KEYS}.new ] || { rm -f ${AUTHORIZED_KEYS}.new; echo "refusing to revoke the last authorized key" >&2; exit 193; }
+chown {{ .User }}: ${AUTHORIZED_KEYS}.new
+chmod 0600 ${AUTHORIZED_KEYS}.new
+mv -f ${AUTHORIZED_KEYS}.new ${AUTHORIZED_KEYS} || exit 194
+
+{{ if .PrivateKey -}}
+cat >${SSH_DIR}/id_rsa <<'EOF'
+{{ .PrivateKey }}
+EOF
+chown {{ .User }}: ${SSH_DIR}/id_rsa
+chmod 0600 ${SSH_DIR}/id_rsa
+{{- end }}
+exit 0
@@ -0,0 +1,51 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# host_user_add.sh
+#
+# Creates a personal account authorized to log in with its own SSH keys, optionally allowed to use sudo;
+# an account already managed by SafeScale is updated
+
+USER_NAME={{ .Name }}
+SUDOERS_FILE=/etc/sudoers.d/90-safescale-user-${USER_NAME}
+
+if id -u ${USER_NAME} &>/dev/null; then
+{{- if not .Update }}
+    echo "account '${USER_NAME}' already exists and is not managed by SafeScale" >&2
+    exit 192
+{{- end }}
+    :
+else
+    useradd -m -s /bin/bash ${USER_NAME} || { echo "failed to create account '${USER_NAME}'" >&2; exit 193; }
+fi
+
+HOME_DIR=$(getent passwd ${USER_NAME} | cut -d: -f6)
+mkdir -p ${HOME_DIR}/.ssh
+cat >${HOME_DIR}/.ssh/authorized_keys <<'EOF'
+{{ .PublicKeys }}
+EOF
+chown -R ${USER_NAME}: ${HOME_DIR}/.ssh
+chmod 0700 ${HOME_DIR}/.ssh
+chmod 0600 ${HOME_DIR}/.ssh/authorized_keys
+
+{{ if .Sudo -}}
+echo "${USER_NAME} ALL=(ALL) NOPASSWD:ALL" >${SUDOERS_FILE}
+chmod 0440 ${SUDOERS_FILE}
+visudo -cf ${SUDOERS_FILE} &>/dev/null || { rm -f ${SUDOERS_FILE}; echo "invalid sudoers entry for '${USER_NAME}'" >&2; exit 194; }
+{{- else -}}
+rm -f ${SUDOERS_FILE}
+{{- end }}
+exit 0
@@ -0,0 +1,32 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# host_user_remove.sh
+#
+# Revokes a personal account created by host_user_add.sh: kills its processes, then deletes it with its home directory
+
+USER_NAME={{ .Name }}
+
+rm -f /etc/sudoers.d/90-safescale-user-${USER_NAME}
+id -u ${USER_NAME} &>/dev/null || exit 0
+
+pkill -KILL -u ${USER_NAME} &>/dev/null
+sleep 1
+userdel -r ${USER_NAME} &>/dev/null
+# userdel returns 12 when the home directory cannot be removed, the account itself being deleted
+rc=$?
+[ $rc -eq 0 -o $rc -eq 12 ] || { echo "failed to delete account '${USER_NAME}'" >&2; exit 192; }
+exit 0
@@ -0,0 +1,25 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# keepalived_peer_update.sh
+#
+# Makes keepalived on a gateway exchange with the new peer gateway {{.NewIP}} instead of the former one {{.OldIP}};
+# keepalived is reloaded, so the gateway keeps the VIP if it holds it
+
+[ -f /etc/keepalived/keepalived.conf ] || { echo "keepalived is not configured" >&2; exit 1; }
+sed -i "s/\b$(echo "{{.OldIP}}" | sed 's/\./\\./g')\b/{{.NewIP}}/g" /etc/keepalived/keepalived.conf || { echo "failed to update keepalived configuration" >&2; exit 1; }
+systemctl reload keepalived || { echo "failed to reload keepalived" >&2; exit 1; }
+exit 0
@@ -0,0 +1,72 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# keepalived_setup.sh
+#
+# Configures keepalived on a gateway so it holds the VIP {{.VIP}} of the network {{ if .Primary }}as primary{{ else }}as secondary{{ end }} gateway,
+# exchanging with the peer gateway {{.PeerIP}}; same settings as the gateways created with failover
+
+{{.reserved_BashLibrary}}
+
+fail() {
+    echo "$*" >&2
+    exit 1
+}
+
+if ! which keepalived &>/dev/null; then
+    case $LINUX_KIND in
+        debian|ubuntu)
+            sfApt update && sfApt install -y keepalived || fail "failed to install keepalived"
+            ;;
+        redhat|rhel|centos|fedora)
+            yum install -q -y keepalived || fail "failed to install keepalived"
+            ;;
+        *)
+            fail "Unsupported Linux distribution '$LINUX_KIND'"
+            ;;
+    esac
+fi
+
+IF=$(ip -o -4 addr show | awk -v ip="{{.SourceIP}}/" 'index($4, ip) == 1 { print $2; exit }')
+[ -z "$IF" ] && fail "no interface has the IP {{.SourceIP}}"
+NETMASK=$(echo {{.CIDR}} | cut -d/ -f2)
+
+mkdir -p /etc/keepalived
+cat >/etc/keepalived/keepalived.conf <<-EOF
+vrrp_instance vrrp_group_gws_internal {
+    state BACKUP
+    interface ${IF}
+    virtual_router_id 1
+    priority {{ if .Primary }}151{{ else }}100{{ end }}
+    nopreempt
+    advert_int 2
+    authentication {
+        auth_type PASS
+        auth_pass password
+    }
+    unicast_src_ip {{.SourceIP}}
+    unicast_peer {
+        {{.PeerIP}}
+    }
+    virtual_ipaddress {
+        {{.VIP}}/${NETMASK}
+    }
+}
+EOF
+
+systemctl enable keepalived || fail "failed to enable keepalived"
+systemctl restart keepalived || fail "failed to start keepalived"
+exit 0
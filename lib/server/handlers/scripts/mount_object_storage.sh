@@ -13,45 +13,96 @@
 # WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 # See the License for the specific language governing permissions and
 # limitations under the License.
+#
+# mount_object_storage.sh
+#
+# Mounts a bucket with {{.Tool}}, as a systemd service to survive reboots.
+# Credentials have been uploaded beforehand in {{.UploadedCredentials}}; they never appear in this script.
 
-# Instal s3ql
-# TODO move this installation in a dedicated go executable which will be eanble to handle different linux flavor (apt, yum, ...)
-apt-get update && apt-get install -y s3ql && apt-get clean && rm -rf /var/lib/apt/lists/*
+{{.reserved_BashLibrary}}
 
-mkdir -p /etc/s3ql
+fail() {
+    rm -f "{{.UploadedCredentials}}"
+    echo "$*" >&2
+    exit 1
+}
 
-# Create auth file
-cat <<- EOF > /etc/s3ql/auth.{{.Bucket}}
-[swift]
-backend-login: {{.Tenant}}:{{.Login}}
-backend-password: {{.Password}}
-storage-url: {{.Protocol}}://{{.AuthURL}}/{{.Region}}:{{.Bucket}}
-fs-passpharse: {{.Password}}
-EOF
+# Installs the tool if needed
+case "{{.Tool}}" in
+    rclone)
+        if ! which rclone &>/dev/null; then
+            case $LINUX_KIND in
+                debian|ubuntu)
+                    sfApt update && sfApt install -y rclone fuse || fail "failed to install rclone"
+                    ;;
+                redhat|rhel|centos|fedora)
+                    yum install -y epel-release && yum install -y rclone fuse || fail "failed to install rclone"
+                    ;;
+                *)
+                    fail "Unsupported Linux distribution '$LINUX_KIND'"
+                    ;;
+            esac
+        fi
+        # The package of older distributions may predate the VFS cache the mount relies on (rclone 1.39)
+        rclone mount --help 2>&1 | grep -q -- "--vfs-cache-mode" || fail "$(rclone --version 2>/dev/null | head -n 1) is too old, rclone 1.39 or later is needed"
+        ;;
+    s3fs)
+        if ! which s3fs &>/dev/null; then
+            case $LINUX_KIND in
+                debian|ubuntu)
+                    sfApt update && sfApt install -y s3fs || fail "failed to install s3fs"
+                    ;;
+                redhat|rhel|centos|fedora)
+                    yum install -y epel-release && yum install -y s3fs-fuse || fail "failed to install s3fs"
+                    ;;
+                *)
+                    fail "Unsupported Linux distribution '$LINUX_KIND'"
+                    ;;
+            esac
+        fi
+        ;;
+    *)
+        fail "unknown bucket mount tool '{{.Tool}}'"
+        ;;
+esac
 
-chmod 0600 /etc/s3ql/auth.{{.Bucket}}
+# allow_other requires this setting for non-root users
+grep -q "^user_allow_other" /etc/fuse.conf 2>/dev/null || echo "user_allow_other" >>/etc/fuse.conf
 
-# Format filesystem
-echo "{{.Password}}"| mkfs.s3ql --authfile /etc/s3ql/auth.{{.Bucket}} --quiet {{.Protocol}}://{{.AuthURL}}/{{.Region}}:{{.Bucket}}
+# Moves credentials out of reach of other users
+mkdir -p "$(dirname {{.CredentialsFile}})"
+chmod 0700 "$(dirname {{.CredentialsFile}})"
+mv -f "{{.UploadedCredentials}}" "{{.CredentialsFile}}" || fail "failed to install credentials"
+chown root:root "{{.CredentialsFile}}"
+chmod 0600 "{{.CredentialsFile}}"
 
-# Create MountPoint
-mkdir -p {{.MountPoint}}
+mkdir -p "{{.MountPoint}}"
 
-# Create script to mount container
-cat <<- FOE > /usr/local/bin/mount-{{.Bucket}}
-sudo /bin/bash << EOF
-echo "{{.Password}}" |mount.s3ql --allow-other --authfile /etc/s3ql/auth.{{.Bucket}} {{.Protocol}}://{{.AuthURL}}/{{.Region}}:{{.Bucket}} {{.MountPoint}}
-EOF
-FOE
-chmod +x /usr/local/bin/mount-{{.Bucket}}
+cat >/etc/systemd/system/{{.Unit}} <<-EOF
+[Unit]
+Description=Mount of bucket {{.Bucket}} in {{.MountPoint}}
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+ExecStart={{.Command}}
+ExecStop=/bin/fusermount -u -z {{.MountPoint}}
+Restart=on-failure
+RestartSec=10
 
-# Create script to umount container
-cat <<- FOE > /usr/local/bin/umount-{{.Bucket}}
-sudo /bin/bash << EOF
-echo "{{.Password}}" |umount.s3ql {{.MountPoint}}
+[Install]
+WantedBy=multi-user.target
 EOF
-FOE
-chmod +x /usr/local/bin/umount-{{.Bucket}}
 
-/usr/local/bin/mount-{{.Bucket}}
-chmod a+w {{.MountPoint}}
+systemctl daemon-reload
+systemctl enable {{.Unit}} || fail "failed to enable {{.Unit}}"
+systemctl restart {{.Unit}} || fail "failed to start {{.Unit}}"
+
+# Waits for the mount to be effective
+for i in $(seq 1 30); do
+    mountpoint -q "{{.MountPoint}}" && exit 0
+    sleep 2
+done
+journalctl -u {{.Unit}} --no-pager | tail -n 20 >&2
+fail "bucket '{{.Bucket}}' not mounted in '{{.MountPoint}}' after 60s"
@@ -0,0 +1,25 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# remove_peering_route.sh
+#
+# Removes the route to the peer network {{.CIDR}} set up by add_peering_route.sh
+
+systemctl stop {{.Unit}} || true
+systemctl disable {{.Unit}} || true
+rm -f /etc/systemd/system/{{.Unit}}
+systemctl daemon-reload
+exit 0
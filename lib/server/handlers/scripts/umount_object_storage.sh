@@ -13,12 +13,17 @@
 # WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 # See the License for the specific language governing permissions and
 # limitations under the License.
+#
+# umount_object_storage.sh
+#
+# Unmounts a bucket mounted by mount_object_storage.sh and removes its credentials
+
+systemctl stop {{.Unit}} || true
+systemctl disable {{.Unit}} || true
+rm -f /etc/systemd/system/{{.Unit}}
+systemctl daemon-reload
 
-/usr/local/bin/umount-{{.Bucket}}
-echo "umount: $?" >/tmp/umount.log
-rm /etc/s3ql/auth.{{.Bucket}}
-echo "rm auth: $?" >>/tmp/umount.log
-rm /usr/local/bin/mount-{{.Bucket}}
-echo "rm mount: $?" >>/tmp/umount.log
-rm /usr/local/bin/umount-{{.Bucket}}
-echo "rm umount: $?" >>/tmp/umount.log
+mountpoint -q "{{.MountPoint}}" && fusermount -u -z "{{.MountPoint}}"
+rm -f "{{.CredentialsFile}}"
+rmdir "{{.MountPoint}}" 2>/dev/null || true
+exit 0
@@ -0,0 +1,69 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# wireguard_peer.sh
+#
+# Sets up on a gateway the WireGuard interface {{.Interface}}, tunneling the traffic to {{.PeerCIDR}} to the gateway(s) of the peer network.
+# The private key has been uploaded beforehand in {{.UploadedKey}}; it never appears in this script.
+
+{{.reserved_BashLibrary}}
+
+fail() {
+    rm -f "{{.UploadedKey}}"
+    echo "$*" >&2
+    exit 1
+}
+
+if ! which wg &>/dev/null; then
+    case $LINUX_KIND in
+        debian|ubuntu)
+            sfApt update && sfApt install -y wireguard || fail "failed to install wireguard"
+            ;;
+        redhat|rhel|centos|fedora)
+            yum install -y epel-release elrepo-release && yum install -y kmod-wireguard wireguard-tools || fail "failed to install wireguard"
+            ;;
+        *)
+            fail "Unsupported Linux distribution '$LINUX_KIND'"
+            ;;
+    esac
+fi
+
+mkdir -p /etc/wireguard
+chmod 0700 /etc/wireguard
+umask 077
+cat >/etc/wireguard/{{.Interface}}.conf <<EOF
+[Interface]
+PrivateKey = $(cat "{{.UploadedKey}}")
+ListenPort = {{.Port}}
+
+[Peer]
+PublicKey = {{.PeerPublicKey}}
+Endpoint = {{.PeerEndpoint}}
+AllowedIPs = {{.PeerCIDR}}
+PersistentKeepalive = 25
+EOF
+rm -f "{{.UploadedKey}}"
+
+# Lets the tunneled traffic through, without masquerading it
+sfFirewallAdd --zone=public --add-port={{.Port}}/udp
+sfFirewallAdd --direct --add-rule ipv4 filter FORWARD 0 -i {{.Interface}} -j ACCEPT
+sfFirewallAdd --direct --add-rule ipv4 filter FORWARD 0 -o {{.Interface}} -j ACCEPT
+sfFirewallAdd --direct --add-rule ipv4 nat POSTROUTING 0 -o {{.Interface}} -j ACCEPT
+sfFirewallReload || fail "failed to reload firewall rules"
+
+systemctl enable wg-quick@{{.Interface}} || fail "failed to enable wg-quick@{{.Interface}}"
+systemctl restart wg-quick@{{.Interface}} || fail "failed to start wg-quick@{{.Interface}}"
+exit 0
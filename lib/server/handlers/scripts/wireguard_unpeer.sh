@@ -0,0 +1,32 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# wireguard_unpeer.sh
+#
+# Removes from a gateway the WireGuard interface set up by wireguard_peer.sh
+
+{{.reserved_BashLibrary}}
+
+systemctl stop wg-quick@{{.Interface}} || true
+systemctl disable wg-quick@{{.Interface}} || true
+rm -f /etc/wireguard/{{.Interface}}.conf
+
+sfFirewallAdd --zone=public --remove-port={{.Port}}/udp || true
+sfFirewallAdd --direct --remove-rule ipv4 filter FORWARD 0 -i {{.Interface}} -j ACCEPT || true
+sfFirewallAdd --direct --remove-rule ipv4 filter FORWARD 0 -o {{.Interface}} -j ACCEPT || true
+sfFirewallAdd --direct --remove-rule ipv4 nat POSTROUTING 0 -o {{.Interface}} -j ACCEPT || true
+sfFirewallReload || true
+exit 0
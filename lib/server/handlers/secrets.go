@@ -0,0 +1,153 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/CS-SI/SafeScale/lib/server/iaas"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/objectstorage"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/crypt"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+)
+
+// secretsFolder is the folder of the metadata bucket containing the secrets of the features, encrypted with the
+// metadata key of the tenant
+const secretsFolder = "secrets/features"
+
+// secretsPath returns the path of the object containing the secrets of the feature installed on the target
+func secretsPath(targetType, targetName, feature string) string {
+	return strings.Join([]string{secretsFolder, targetType, targetName, feature}, "/")
+}
+
+// ReadFeatureSecrets returns the secrets stored for the feature installed on the target (an empty map if there is
+// none), and tells if the tenant defines the metadata key needed to store secrets
+func (handler *TenantHandler) ReadFeatureSecrets(ctx context.Context, targetType, targetName, feature string) (secrets map[string]string, keyDefined bool, err error) {
+	if handler == nil {
+		return nil, false, scerr.InvalidInstanceError()
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s', '%s')", targetType, targetName, feature), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	key := handler.service.GetMetadataKey()
+	if key == nil {
+		return map[string]string{}, false, nil
+	}
+	secrets, err = readSecrets(handler.service, key, secretsPath(targetType, targetName, feature))
+	if err != nil {
+		return nil, true, err
+	}
+	return secrets, true, nil
+}
+
+// WriteFeatureSecrets stores the secrets of the feature installed on the target, encrypted with the metadata key
+// of the tenant
+func (handler *TenantHandler) WriteFeatureSecrets(ctx context.Context, targetType, targetName, feature string, secrets map[string]string) (err error) {
+	if handler == nil {
+		return scerr.InvalidInstanceError()
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s', '%s')", targetType, targetName, feature), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	key := handler.service.GetMetadataKey()
+	if key == nil {
+		return fmt.Errorf("no metadata encryption key ('CryptKey' in section 'metadata' of the tenant) to store secrets")
+	}
+	return writeSecrets(handler.service, key, secretsPath(targetType, targetName, feature), secrets)
+}
+
+// DeleteFeatureSecrets removes the secrets stored for the feature installed on the target
+func (handler *TenantHandler) DeleteFeatureSecrets(ctx context.Context, targetType, targetName, feature string) (err error) {
+	if handler == nil {
+		return scerr.InvalidInstanceError()
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s', '%s')", targetType, targetName, feature), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	path := secretsPath(targetType, targetName, feature)
+	found, err := secretsExist(handler.service, path)
+	if err != nil || !found {
+		return err
+	}
+	return handler.service.GetMetadataBucket().DeleteObject(path)
+}
+
+// secretsExist tells if secrets are stored in 'path'
+func secretsExist(svc iaas.Service, path string) (bool, error) {
+	list, err := svc.GetMetadataBucket().List(path, objectstorage.NoPrefix)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range list {
+		if item == path {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// readSecrets returns the secrets stored in 'path', decrypted with 'key'; an empty map if there is none
+func readSecrets(svc iaas.Service, key *crypt.Key, path string) (map[string]string, error) {
+	secrets := map[string]string{}
+	found, err := secretsExist(svc, path)
+	if err != nil || !found {
+		return secrets, err
+	}
+	var buffer bytes.Buffer
+	_, err = svc.GetMetadataBucket().ReadObject(path, &buffer, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets '%s': %s", path, err.Error())
+	}
+	content, err := crypt.Decrypt(buffer.Bytes(), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets '%s': %s", path, err.Error())
+	}
+	err = json.Unmarshal(content, &secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secrets '%s': %s", path, err.Error())
+	}
+	return secrets, nil
+}
+
+// writeSecrets stores the secrets in 'path', encrypted with 'key'
+func writeSecrets(svc iaas.Service, key *crypt.Key, path string, secrets map[string]string) error {
+	content, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	encrypted, err := crypt.Encrypt(content, key)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secrets '%s': %s", path, err.Error())
+	}
+	source := bytes.NewBuffer(encrypted)
+	_, err = svc.GetMetadataBucket().WriteObject(path, source, int64(source.Len()), nil)
+	if err != nil {
+		return fmt.Errorf("failed to write secrets '%s': %s", path, err.Error())
+	}
+	return nil
+}
@@ -43,6 +43,7 @@ import (
 // ShareAPI defines API to manipulate Shares
 type ShareAPI interface {
 	Create(context.Context, string, string, string, []string, bool, bool, bool, bool, bool, bool, bool) (*propsv1.HostShare, error)
+	CreateHA(context.Context, string, string, string, string, string, []string, bool, bool, bool, bool, bool, bool, bool) (*propsv1.HostShare, error)
 	ForceInspect(context.Context, string) (*resources.Host, *propsv1.HostShare, map[string]*propsv1.HostRemoteMount, error)
 	Inspect(context.Context, string) (*resources.Host, *propsv1.HostShare, map[string]*propsv1.HostRemoteMount, error)
 	Delete(context.Context, string) error
@@ -110,19 +111,7 @@ func (handler *ShareHandler) Create(
 	}
 
 	// Check if the path to share isn't a remote mount or contains a remote mount
-	err = server.Properties.LockForRead(hostproperty.MountsV1).ThenUse(func(clonable data.Clonable) error {
-		serverMountsV1 := clonable.(*propsv1.HostMounts)
-		if _, found := serverMountsV1.RemoteMountsByPath[path]; found {
-			return fmt.Errorf("path to export '%s' is a mounted share", sharePath)
-		}
-		for k := range serverMountsV1.RemoteMountsByPath {
-			if strings.Index(sharePath, k) == 0 {
-				return fmt.Errorf("export path '%s' contains a share mounted in '%s'", sharePath, k)
-			}
-		}
-
-		return nil
-	})
+	err = checkExportPath(server, sharePath)
 	if err != nil {
 		return nil, err
 	}
@@ -238,6 +227,369 @@ func (handler *ShareHandler) Create(
 	return share, nil
 }
 
+// checkExportPath checks the path to export on server isn't a remote mount and doesn't contain a remote mount
+func checkExportPath(server *resources.Host, sharePath string) error {
+	return server.Properties.LockForRead(hostproperty.MountsV1).ThenUse(func(clonable data.Clonable) error {
+		serverMountsV1 := clonable.(*propsv1.HostMounts)
+		if _, found := serverMountsV1.RemoteMountsByPath[sharePath]; found {
+			return fmt.Errorf("path to export '%s' is a mounted share", sharePath)
+		}
+		for k := range serverMountsV1.RemoteMountsByPath {
+			if strings.Index(sharePath, k) == 0 {
+				return fmt.Errorf("export path '%s' contains a share mounted in '%s'", sharePath, k)
+			}
+		}
+
+		return nil
+	})
+}
+
+// CreateHA creates a share exported in high availability by a pair of hosts
+// The block device 'device', present on both hosts and dedicated to the share, is replicated from the active host to the
+// passive one; the share is exported on a VIP that moves to the passive host if the active one fails.
+func (handler *ShareHandler) CreateHA(
+	ctx context.Context,
+	shareName, hostName, peerName, path, device string, securityModes []string,
+	readOnly, rootSquash, secure, async, noHide, crossMount, subtreeCheck bool,
+) (share *propsv1.HostShare, err error) {
+	if handler == nil {
+		return nil, scerr.InvalidInstanceError()
+	}
+	if shareName == "" {
+		return nil, scerr.InvalidParameterError("shareName", "cannot be empty string")
+	}
+	if hostName == "" {
+		return nil, scerr.InvalidParameterError("hostName", "cannot be empty string")
+	}
+	if peerName == "" {
+		return nil, scerr.InvalidParameterError("peerName", "cannot be empty string")
+	}
+	if device == "" {
+		return nil, scerr.InvalidParameterError("device", "cannot be empty string")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s', '%s', '%s', '%s')", shareName, hostName, peerName, path, device), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	// Check if a share already exists with the same name
+	server, _, _, err := handler.Inspect(ctx, shareName)
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotFound); !ok {
+			return nil, err
+		}
+	}
+	if server != nil {
+		return nil, resources.ResourceDuplicateError("share", shareName)
+	}
+
+	sharePath, err := sanitize(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hostHandler := NewHostHandler(handler.service)
+	server, err = hostHandler.Inspect(ctx, hostName)
+	if err != nil {
+		return nil, err
+	}
+	peer, err := hostHandler.Inspect(ctx, peerName)
+	if err != nil {
+		return nil, err
+	}
+	if server.ID == peer.ID {
+		return nil, scerr.InvalidParameterError("peerName", "must designate another host than hostName")
+	}
+	for _, h := range []*resources.Host{server, peer} {
+		err = checkExportPath(h, sharePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Both servers must be in the same network, the VIP being allocated in it
+	var serverNetworkID, peerNetworkID string
+	err = server.Properties.LockForRead(hostproperty.NetworkV1).ThenUse(func(clonable data.Clonable) error {
+		serverNetworkID = clonable.(*propsv1.HostNetwork).DefaultNetworkID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = peer.Properties.LockForRead(hostproperty.NetworkV1).ThenUse(func(clonable data.Clonable) error {
+		peerNetworkID = clonable.(*propsv1.HostNetwork).DefaultNetworkID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if serverNetworkID == "" || serverNetworkID != peerNetworkID {
+		return nil, fmt.Errorf("hosts '%s' and '%s' must share the same default network", server.Name, peer.Name)
+	}
+	mn, err := metadata.LoadNetwork(handler.service, serverNetworkID)
+	if err != nil {
+		return nil, err
+	}
+	network, err := mn.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	// Selects the first replica index unused on both servers
+	used := map[int]bool{}
+	for _, h := range []*resources.Host{server, peer} {
+		err = h.Properties.LockForRead(hostproperty.ShareReplicasV1).ThenUse(func(clonable data.Clonable) error {
+			for _, replica := range clonable.(*propsv1.HostShareReplicas).ByShareID {
+				used[replica.Minor] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	minor := 0
+	for used[minor] {
+		minor++
+	}
+
+	shareID, err := uuid.NewV4()
+	if err != nil {
+		return nil, scerr.Wrap(err, "Error creating UUID for share")
+	}
+	share = propsv1.NewHostShare()
+	share.ID = shareID.String()
+	share.Name = shareName
+	share.Path = sharePath
+	share.Type = "nfs-ha"
+	resource := "sf-" + strings.Replace(share.ID, "-", "", -1)[:12]
+
+	vip, err := handler.service.CreateVIP(network.ID, fmt.Sprintf("for share %s", shareName))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			derr := handler.service.DeleteVIP(vip)
+			if derr != nil {
+				log.Errorf("failed to delete VIP: %+v", derr)
+				err = scerr.AddConsequence(err, derr)
+			}
+		}
+	}()
+	for _, h := range []*resources.Host{server, peer} {
+		err = handler.service.BindHostToVIP(vip, h.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sshHandler := NewSSHHandler(handler.service)
+	serverSSHConfig, err := sshHandler.GetConfig(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	peerSSHConfig, err := sshHandler.GetConfig(ctx, peer)
+	if err != nil {
+		return nil, err
+	}
+	primaryServer, err := nfs.NewServer(serverSSHConfig)
+	if err != nil {
+		return nil, err
+	}
+	secondaryServer, err := nfs.NewServer(peerSSHConfig)
+	if err != nil {
+		return nil, err
+	}
+	haServer, err := nfs.NewHAServer(
+		nfs.HAPeer{Server: primaryServer, PrivateIP: server.GetPrivateIP()},
+		nfs.HAPeer{Server: secondaryServer, PrivateIP: peer.GetPrivateIP()},
+		resource, minor, device, vip.PrivateIP, network.CIDR,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = haServer.AddShare(sharePath, securityModes, readOnly, rootSquash, secure, async, noHide, crossMount, subtreeCheck)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			derr := haServer.RemoveShare(sharePath)
+			if derr != nil {
+				log.Warnf("failed to remove highly available share '%s'", shareName)
+				err = scerr.AddConsequence(err, derr)
+			}
+		}
+	}()
+
+	// Records the share on both servers, each knowing the part it takes in the pair
+	defer func() {
+		if err != nil {
+			for _, h := range []*resources.Host{server, peer} {
+				derr := forgetShare(handler.service, h, share)
+				if derr != nil {
+					log.Warnf("failed to remove share '%s' from metadata of host '%s'", shareName, h.Name)
+					err = scerr.AddConsequence(err, derr)
+				}
+			}
+		}
+	}()
+	for _, h := range []*resources.Host{server, peer} {
+		primary := h == server
+		other := peer
+		if !primary {
+			other = server
+		}
+		err = h.Properties.LockForWrite(hostproperty.SharesV1).ThenUse(func(clonable data.Clonable) error {
+			hostSharesV1 := clonable.(*propsv1.HostShares)
+			hostSharesV1.ByID[share.ID] = share.Clone().(*propsv1.HostShare)
+			hostSharesV1.ByName[share.Name] = share.ID
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = h.Properties.LockForWrite(hostproperty.ShareReplicasV1).ThenUse(func(clonable data.Clonable) error {
+			replica := propsv1.NewHostShareReplica()
+			replica.ShareID = share.ID
+			replica.Primary = primary
+			replica.PeerID = other.ID
+			replica.PeerName = other.Name
+			replica.Device = device
+			replica.Resource = resource
+			replica.Minor = minor
+			replica.VIPID = vip.ID
+			replica.VIPAddress = vip.PrivateIP
+			clonable.(*propsv1.HostShareReplicas).ByShareID[share.ID] = replica
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		_, err = metadata.SaveHost(handler.service, h)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The share metadata designates the server holding the share at creation
+	_, err = metadata.SaveShare(handler.service, server.ID, server.Name, share.ID, share.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		log.Warnf("Share creation cancelled by user")
+		derr := metadata.RemoveShare(handler.service, server.ID, server.Name, share.ID, share.Name)
+		if derr != nil {
+			log.Warnf("failed to delete metadata of share '%s'", share.Name)
+		}
+		err = fmt.Errorf("share creation cancelled by user")
+		return nil, err
+	default:
+	}
+
+	return share, nil
+}
+
+// forgetShare removes the share from the metadata of the host
+func forgetShare(svc iaas.Service, host *resources.Host, share *propsv1.HostShare) error {
+	err := host.Properties.LockForWrite(hostproperty.SharesV1).ThenUse(func(clonable data.Clonable) error {
+		hostSharesV1 := clonable.(*propsv1.HostShares)
+		delete(hostSharesV1.ByID, share.ID)
+		delete(hostSharesV1.ByName, share.Name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = host.Properties.LockForWrite(hostproperty.ShareReplicasV1).ThenUse(func(clonable data.Clonable) error {
+		delete(clonable.(*propsv1.HostShareReplicas).ByShareID, share.ID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = metadata.SaveHost(svc, host)
+	return err
+}
+
+// getShareReplica returns the information about the replication of the share by the host, nil if the share isn't
+// exported in high availability
+func getShareReplica(host *resources.Host, shareID string) (replica *propsv1.HostShareReplica, err error) {
+	err = host.Properties.LockForRead(hostproperty.ShareReplicasV1).ThenUse(func(clonable data.Clonable) error {
+		if r, ok := clonable.(*propsv1.HostShareReplicas).ByShareID[shareID]; ok {
+			replica = r.Clone().(*propsv1.HostShareReplica)
+		}
+		return nil
+	})
+	return replica, err
+}
+
+// deleteHA removes a share exported in high availability from both servers, and the VIP used to reach it
+func (handler *ShareHandler) deleteHA(ctx context.Context, server *resources.Host, share *propsv1.HostShare, replica *propsv1.HostShareReplica) error {
+	hostHandler := NewHostHandler(handler.service)
+	peer, err := hostHandler.Inspect(ctx, replica.PeerID)
+	if err != nil {
+		return err
+	}
+
+	sshHandler := NewSSHHandler(handler.service)
+	serverSSHConfig, err := sshHandler.GetConfig(ctx, server)
+	if err != nil {
+		return err
+	}
+	peerSSHConfig, err := sshHandler.GetConfig(ctx, peer)
+	if err != nil {
+		return err
+	}
+	serverNFS, err := nfs.NewServer(serverSSHConfig)
+	if err != nil {
+		return err
+	}
+	peerNFS, err := nfs.NewServer(peerSSHConfig)
+	if err != nil {
+		return err
+	}
+	primary := nfs.HAPeer{Server: serverNFS, PrivateIP: server.GetPrivateIP()}
+	secondary := nfs.HAPeer{Server: peerNFS, PrivateIP: peer.GetPrivateIP()}
+	if !replica.Primary {
+		primary, secondary = secondary, primary
+	}
+	haServer, err := nfs.NewHAServer(primary, secondary, replica.Resource, replica.Minor, replica.Device, replica.VIPAddress, "")
+	if err != nil {
+		return err
+	}
+	err = haServer.RemoveShare(share.Path)
+	if err != nil {
+		return err
+	}
+
+	for _, h := range []*resources.Host{server, peer} {
+		err = forgetShare(handler.service, h, share)
+		if err != nil {
+			return err
+		}
+	}
+
+	vip := resources.NewVirtualIP()
+	vip.ID = replica.VIPID
+	vip.PrivateIP = replica.VIPAddress
+	for _, h := range []*resources.Host{server, peer} {
+		uerr := handler.service.UnbindHostFromVIP(vip, h.ID)
+		if uerr != nil {
+			log.Warnf("failed to unbind host '%s' from VIP of share '%s': %v", h.Name, share.Name, uerr)
+		}
+	}
+	err = handler.service.DeleteVIP(vip)
+	if err != nil {
+		log.Errorf("failed to delete VIP of share '%s': %v", share.Name, err)
+	}
+	return nil
+}
+
 // Delete a share from host
 func (handler *ShareHandler) Delete(ctx context.Context, name string) (err error) {
 	if handler == nil {
@@ -261,6 +613,25 @@ func (handler *ShareHandler) Delete(ctx context.Context, name string) (err error
 		return fmt.Errorf("delete share: unable to found share of host '%s'", name)
 	}
 
+	replica, err := getShareReplica(server, share.ID)
+	if err != nil {
+		return err
+	}
+	if replica != nil {
+		if len(share.ClientsByName) > 0 {
+			var list []string
+			for k := range share.ClientsByName {
+				list = append(list, "'"+k+"'")
+			}
+			return fmt.Errorf("still used by: %s", strings.Join(list, ","))
+		}
+		err = handler.deleteHA(ctx, server, share, replica)
+		if err != nil {
+			return err
+		}
+		return metadata.RemoveShare(handler.service, server.ID, server.Name, share.ID, share.Name)
+	}
+
 	err = server.Properties.LockForWrite(hostproperty.SharesV1).ThenUse(func(clonable data.Clonable) error {
 		serverSharesV1 := clonable.(*propsv1.HostShares)
 		if len(share.ClientsByName) > 0 {
@@ -442,17 +813,26 @@ func (handler *ShareHandler) Mount(
 	}
 
 	export := ""
-	err = target.Properties.LockForRead(hostproperty.NetworkV1).ThenUse(func(clonable data.Clonable) error {
-		if clonable.(*propsv1.HostNetwork).DefaultGatewayPrivateIP == server.GetPrivateIP() {
-			export = server.GetPrivateIP() + ":" + share.Path
-		} else {
-			export = server.GetAccessIP() + ":" + share.Path
-		}
-		return nil
-	})
+	replica, err := getShareReplica(server, share.ID)
 	if err != nil {
 		return nil, err
 	}
+	if replica != nil {
+		// Share exported in high availability is reached through the VIP, whatever the server holding it
+		export = replica.VIPAddress + ":" + share.Path
+	} else {
+		err = target.Properties.LockForRead(hostproperty.NetworkV1).ThenUse(func(clonable data.Clonable) error {
+			if clonable.(*propsv1.HostNetwork).DefaultGatewayPrivateIP == server.GetPrivateIP() {
+				export = server.GetPrivateIP() + ":" + share.Path
+			} else {
+				export = server.GetAccessIP() + ":" + share.Path
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	sshHandler := NewSSHHandler(handler.service)
 	sshConfig, err := sshHandler.GetConfig(ctx, target)
@@ -664,7 +1044,11 @@ func (handler *ShareHandler) Unmount(ctx context.Context, shareName, hostName st
 		if err != nil {
 			return err
 		}
-		err = nfsClient.Unmount(server.GetAccessIP() + ":" + share.Path)
+		export := mount.Export
+		if export == "" {
+			export = server.GetAccessIP() + ":" + share.Path
+		}
+		err = nfsClient.Unmount(export)
 		if err != nil {
 			return err
 		}
@@ -65,6 +65,23 @@ func NewSSHHandler(svc iaas.Service) *SSHHandler {
 	}
 }
 
+// getOperatorUsername returns the name of the user used by SafeScale to operate the hosts of the tenant
+func getOperatorUsername(svc iaas.Service) (string, error) {
+	cfg, err := svc.GetConfigurationOptions()
+	if err != nil {
+		return "", err
+	}
+	user := resources.DefaultUser
+	if userIf, ok := cfg.Get("OperatorUsername"); ok {
+		user = userIf.(string)
+		if user == "" {
+			logrus.Warnf("OperatorUsername is empty ! Check your tenants.toml file ! Using 'safescale' user instead.")
+			user = resources.DefaultUser
+		}
+	}
+	return user, nil
+}
+
 // GetConfig creates SSHConfig to connect to an host
 func (handler *SSHHandler) GetConfig(ctx context.Context, hostParam interface{}) (sshConfig *system.SSHConfig, err error) {
 	if handler == nil {
@@ -100,18 +117,10 @@ func (handler *SSHHandler) GetConfig(ctx context.Context, hostParam interface{})
 	defer tracer.OnExitTrace()()
 	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
 
-	cfg, err := handler.service.GetConfigurationOptions()
+	user, err := getOperatorUsername(handler.service)
 	if err != nil {
 		return nil, err
 	}
-	user := resources.DefaultUser
-	if userIf, ok := cfg.Get("OperatorUsername"); ok {
-		user = userIf.(string)
-		if user == "" {
-			logrus.Warnf("OperatorUsername is empty ! Check your tenants.toml file ! Using 'safescale' user instead.")
-			user = resources.DefaultUser
-		}
-	}
 
 	sshConfig = &system.SSHConfig{
 		PrivateKey: host.PrivateKey,
@@ -0,0 +1,541 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/CS-SI/SafeScale/lib/server/iaas"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/hostproperty"
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/metadata"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+)
+
+//go:generate mockgen -destination=../mocks/mock_tenantapi.go -package=mocks github.com/CS-SI/SafeScale/lib/server/handlers TenantAPI
+
+const (
+	// DriftOrphan qualifies a provider resource without SafeScale metadata, named after SafeScale conventions
+	DriftOrphan = "orphan"
+	// DriftDangling qualifies SafeScale metadata pointing to a resource that doesn't exist anymore on provider side
+	DriftDangling = "dangling"
+	// DriftConflict qualifies a provider resource having the name of a SafeScale resource, but another ID
+	DriftConflict = "conflict"
+)
+
+var (
+	// safescaleHostNameRegexp matches the names SafeScale chooses by itself for the hosts it creates: gateways
+	// (gw-<network>, gw2-<network>) and cluster masters and nodes (<cluster>-master-<n>, <cluster>-node-<n>)
+	safescaleHostNameRegexp = regexp.MustCompile(`^(gw2?-.+|.+-(master|node)-[0-9]+)$`)
+	// gatewayNameRegexp extracts the name of the network from the name of a gateway
+	gatewayNameRegexp = regexp.MustCompile(`^gw2?-(.+)$`)
+	// replacementSuffixRegexp matches the suffix of the name of a replacement gateway
+	replacementSuffixRegexp = regexp.MustCompile(`-r[0-9]+$`)
+	// safescaleVIPNameRegexp matches the names SafeScale gives to the VIPs (ports without host) it creates, for the
+	// gateways of a network, for a share exported in high availability or for the control plane of a cluster
+	safescaleVIPNameRegexp = regexp.MustCompile(`^(for gateways of network .+|for share .+|(.+)-ControlPlaneVIP)$`)
+)
+
+// GCOptions tells what to do with the drifts found by TenantAPI.GC
+type GCOptions struct {
+	Apply         bool   // if false, only reports what would be done
+	DeleteOrphans bool   // deletes provider resources without metadata
+	ImportOrphans bool   // registers orphan hosts and networks in metadata instead of deleting them
+	PurgeDangling bool   // removes metadata pointing to vanished resources
+	PrivateKey    string // private key used to reach the imported hosts and gateways
+	User          string // existing account of the imported hosts used to create the operator account, if not empty
+}
+
+// GCEntry describes a drift between provider resources and SafeScale metadata
+type GCEntry struct {
+	Kind   string // "host", "network", "volume", "vip" or "keypair"
+	ID     string
+	Name   string
+	Drift  string // one of DriftOrphan, DriftDangling or DriftConflict
+	Action string // what has been done (or would be done in dry-run mode)
+}
+
+// TenantAPI defines API to manipulate the resources of a tenant as a whole
+type TenantAPI interface {
+	GC(ctx context.Context, options GCOptions) ([]GCEntry, error)
+	ReadFeatureSecrets(ctx context.Context, targetType, targetName, feature string) (map[string]string, bool, error)
+	WriteFeatureSecrets(ctx context.Context, targetType, targetName, feature string, secrets map[string]string) error
+	DeleteFeatureSecrets(ctx context.Context, targetType, targetName, feature string) error
+}
+
+// TenantHandler tenant service
+type TenantHandler struct {
+	service iaas.Service
+}
+
+// NewTenantHandler creates a tenant service
+func NewTenantHandler(svc iaas.Service) TenantAPI {
+	return &TenantHandler{
+		service: svc,
+	}
+}
+
+// GC compares the hosts, networks, volumes, VIPs and key pairs known by the provider with the ones recorded in
+// SafeScale metadata, reports the drifts in both directions and optionally fixes them.
+// A provider resource without metadata is reported as orphan only if its name follows the conventions SafeScale
+// uses for the resources it names by itself (gateways, cluster nodes, VIPs, key pairs); the others may not belong
+// to SafeScale and are left alone. Orphan hosts and networks are either deleted or imported, as HostHandler.Import
+// and NetworkHandler.Import do. Unless options.Apply is set, nothing is changed.
+func (handler *TenantHandler) GC(ctx context.Context, options GCOptions) (entries []GCEntry, err error) {
+	if handler == nil {
+		return nil, scerr.InvalidInstanceError()
+	}
+	if options.DeleteOrphans && options.ImportOrphans {
+		return nil, scerr.InvalidParameterError("options", "orphans cannot be both deleted and imported")
+	}
+	if options.ImportOrphans && options.PrivateKey == "" {
+		return nil, scerr.InvalidParameterError("options.PrivateKey", "cannot be empty string when orphans are imported")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("(apply=%v, deleteOrphans=%v, importOrphans=%v, purgeDangling=%v, '%s')",
+		options.Apply, options.DeleteOrphans, options.ImportOrphans, options.PurgeDangling, options.User), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	providerHosts, err := handler.service.ListHosts()
+	if err != nil {
+		return nil, err
+	}
+
+	// Orphan networks are imported before hosts, a host is attached on import to the networks already known
+	var networkEntries []GCEntry
+	if options.ImportOrphans {
+		networkEntries, err = handler.gcNetworks(ctx, options, providerHosts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hostEntries, err := handler.gcHosts(ctx, options, providerHosts)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, hostEntries...)
+
+	keypairEntries, err := handler.gcKeyPairs(options)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, keypairEntries...)
+
+	volumeEntries, err := handler.gcVolumes(options)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, volumeEntries...)
+
+	// VIPs are handled before networks, a port left in a network prevents its deletion
+	vipEntries, err := handler.gcVIPs(options)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, vipEntries...)
+
+	// Otherwise networks are handled last, hosts attached to orphan networks may have been deleted before
+	if !options.ImportOrphans {
+		networkEntries, err = handler.gcNetworks(ctx, options, providerHosts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	entries = append(entries, networkEntries...)
+
+	return entries, nil
+}
+
+// gcAction returns the action to report for a drift, running 'fn' only if options.Apply is set
+func gcAction(options GCOptions, entry GCEntry, verb string, fn func() error) string {
+	if !options.Apply {
+		return "would be " + verb
+	}
+	if err := fn(); err != nil {
+		logrus.Errorf("failed to %s %s '%s' (%s): %v", verb, entry.Kind, entry.Name, entry.ID, err)
+		return "failed to be " + verb + ": " + err.Error()
+	}
+	logrus.Infof("%s %s '%s' (%s) %s", entry.Drift, entry.Kind, entry.Name, entry.ID, verb)
+	return verb
+}
+
+// gatewayNetworkNames returns the names of the networks the SafeScale gateways among hosts are named after
+func gatewayNetworkNames(hosts []*resources.Host) map[string]struct{} {
+	names := map[string]struct{}{}
+	for _, host := range hosts {
+		m := gatewayNameRegexp.FindStringSubmatch(host.Name)
+		if m == nil {
+			continue
+		}
+		names[m[1]] = struct{}{}
+		names[replacementSuffixRegexp.ReplaceAllString(m[1], "")] = struct{}{}
+	}
+	return names
+}
+
+// primaryGatewayOf returns the host among hosts acting as primary gateway of the network named networkName,
+// possibly a replacement one ('gw-<network>-r<n>'), or nil if there is none
+func primaryGatewayOf(hosts []*resources.Host, networkName string) *resources.Host {
+	for _, host := range hosts {
+		if !strings.HasPrefix(host.Name, "gw-") {
+			continue
+		}
+		m := gatewayNameRegexp.FindStringSubmatch(host.Name)
+		if m != nil && (m[1] == networkName || replacementSuffixRegexp.ReplaceAllString(m[1], "") == networkName) {
+			return host
+		}
+	}
+	return nil
+}
+
+func (handler *TenantHandler) gcHosts(ctx context.Context, options GCOptions, providerHosts []*resources.Host) ([]GCEntry, error) {
+	var entries []GCEntry
+
+	mh, err := metadata.NewHost(handler.service)
+	if err != nil {
+		return nil, err
+	}
+	knownByID := map[string]*resources.Host{}
+	knownByName := map[string]*resources.Host{}
+	err = mh.Browse(func(host *resources.Host) error {
+		knownByID[host.ID] = host
+		knownByName[host.Name] = host
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]struct{}{}
+	for _, host := range providerHosts {
+		existing[host.ID] = struct{}{}
+		if _, ok := knownByID[host.ID]; ok {
+			continue
+		}
+		entry := GCEntry{Kind: "host", ID: host.ID, Name: host.Name, Drift: DriftOrphan}
+		if _, ok := knownByName[host.Name]; ok {
+			// Never touch a resource that may be confused with a SafeScale one
+			entry.Drift = DriftConflict
+			entries = append(entries, entry)
+			continue
+		}
+		if !safescaleHostNameRegexp.MatchString(host.Name) {
+			continue
+		}
+		if options.DeleteOrphans {
+			h := host
+			entry.Action = gcAction(options, entry, "deleted", func() error {
+				return handler.service.DeleteHost(h.ID)
+			})
+		}
+		if options.ImportOrphans {
+			h := host
+			entry.Action = gcAction(options, entry, "imported", func() error {
+				_, err := NewHostHandler(handler.service).Import(ctx, h.ID, options.PrivateKey, options.User, "")
+				return err
+			})
+		}
+		entries = append(entries, entry)
+	}
+
+	for id, host := range knownByID {
+		if _, ok := existing[id]; ok {
+			continue
+		}
+		entry := GCEntry{Kind: "host", ID: id, Name: host.Name, Drift: DriftDangling}
+		if options.PurgeDangling {
+			h := host
+			entry.Action = gcAction(options, entry, "purged", func() error {
+				// The networks of the host keep a reference to it, removed as HostHandler.Delete does
+				err := detachHostFromNetworks(ctx, handler.service, h)
+				if err != nil {
+					return err
+				}
+				return metadata.RemoveHost(handler.service, h)
+			})
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// gcKeyPairs looks for the key pairs SafeScale registers on provider side for its gateways ('kp_<host name>')
+// whose host is unknown
+func (handler *TenantHandler) gcKeyPairs(options GCOptions) ([]GCEntry, error) {
+	var entries []GCEntry
+
+	keypairs, err := handler.service.ListKeyPairs()
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotImplemented); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for _, kp := range keypairs {
+		hostName := strings.TrimPrefix(kp.Name, "kp_")
+		if hostName == kp.Name || !safescaleHostNameRegexp.MatchString(hostName) {
+			continue
+		}
+		if _, err := metadata.LoadHost(handler.service, hostName); err == nil {
+			continue
+		} else if _, ok := err.(scerr.ErrNotFound); !ok {
+			return nil, err
+		}
+		entry := GCEntry{Kind: "keypair", ID: kp.ID, Name: kp.Name, Drift: DriftOrphan}
+		if options.DeleteOrphans {
+			id := kp.ID
+			entry.Action = gcAction(options, entry, "deleted", func() error {
+				return handler.service.DeleteKeyPair(id)
+			})
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (handler *TenantHandler) gcVolumes(options GCOptions) ([]GCEntry, error) {
+	var entries []GCEntry
+
+	providerVolumes, err := handler.service.ListVolumes()
+	if err != nil {
+		return nil, err
+	}
+	mv, err := metadata.NewVolume(handler.service)
+	if err != nil {
+		return nil, err
+	}
+	knownByID := map[string]*resources.Volume{}
+	knownByName := map[string]*resources.Volume{}
+	err = mv.Browse(func(volume *resources.Volume) error {
+		knownByID[volume.ID] = volume
+		knownByName[volume.Name] = volume
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]struct{}{}
+	for _, volume := range providerVolumes {
+		existing[volume.ID] = struct{}{}
+		if _, ok := knownByID[volume.ID]; ok {
+			continue
+		}
+		// Volumes are named by users only, a volume without metadata can't be told from a volume created
+		// outside SafeScale; only name conflicts are reported
+		if _, ok := knownByName[volume.Name]; ok {
+			entries = append(entries, GCEntry{Kind: "volume", ID: volume.ID, Name: volume.Name, Drift: DriftConflict})
+		}
+	}
+
+	for id, volume := range knownByID {
+		if _, ok := existing[id]; ok {
+			continue
+		}
+		entry := GCEntry{Kind: "volume", ID: id, Name: volume.Name, Drift: DriftDangling}
+		if options.PurgeDangling {
+			entry.Action = gcAction(options, entry, "purged", func() error {
+				return metadata.RemoveVolume(handler.service, entry.ID)
+			})
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// gcVIPs handles the VIPs, i.e. the ports without host SafeScale creates on provider side; the VIPs of the networks
+// and of the shares are known by their IDs, a VIP of the control plane of a cluster is kept as long as masters of the
+// cluster are known
+func (handler *TenantHandler) gcVIPs(options GCOptions) ([]GCEntry, error) {
+	var entries []GCEntry
+
+	providerVIPs, err := handler.service.ListVIPs()
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotImplemented); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	mn, err := metadata.NewNetwork(handler.service)
+	if err != nil {
+		return nil, err
+	}
+	networkByVIP := map[string]*resources.Network{}
+	err = mn.Browse(func(network *resources.Network) error {
+		if network.VIP != nil && network.VIP.ID != "" {
+			networkByVIP[network.VIP.ID] = network
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mh, err := metadata.NewHost(handler.service)
+	if err != nil {
+		return nil, err
+	}
+	shareVIPs := map[string]struct{}{}
+	var hostNames []string
+	err = mh.Browse(func(host *resources.Host) error {
+		hostNames = append(hostNames, host.Name)
+		return host.Properties.LockForRead(hostproperty.ShareReplicasV1).ThenUse(func(clonable data.Clonable) error {
+			for _, replica := range clonable.(*propsv1.HostShareReplicas).ByShareID {
+				shareVIPs[replica.VIPID] = struct{}{}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	hasMasters := func(clusterName string) bool {
+		for _, name := range hostNames {
+			if strings.HasPrefix(name, clusterName+"-master-") {
+				return true
+			}
+		}
+		return false
+	}
+
+	existing := map[string]struct{}{}
+	for _, vip := range providerVIPs {
+		existing[vip.ID] = struct{}{}
+		if _, ok := networkByVIP[vip.ID]; ok {
+			continue
+		}
+		if _, ok := shareVIPs[vip.ID]; ok {
+			continue
+		}
+		m := safescaleVIPNameRegexp.FindStringSubmatch(vip.Name)
+		if m == nil || (m[2] != "" && hasMasters(m[2])) {
+			continue
+		}
+		entry := GCEntry{Kind: "vip", ID: vip.ID, Name: vip.Name, Drift: DriftOrphan}
+		if options.DeleteOrphans {
+			v := vip
+			entry.Action = gcAction(options, entry, "deleted", func() error {
+				return handler.service.DeleteVIP(v)
+			})
+		}
+		entries = append(entries, entry)
+	}
+
+	for id, network := range networkByVIP {
+		if _, ok := existing[id]; ok {
+			continue
+		}
+		entry := GCEntry{Kind: "vip", ID: id, Name: network.VIP.Name, Drift: DriftDangling}
+		if options.PurgeDangling {
+			n := network
+			entry.Action = gcAction(options, entry, "purged", func() error {
+				n.VIP = nil
+				_, err := metadata.SaveNetwork(handler.service, n)
+				return err
+			})
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// gcNetworks handles networks; a network without metadata is an orphan only if a SafeScale gateway named after it
+// exists (or existed before gcHosts) on provider side
+func (handler *TenantHandler) gcNetworks(ctx context.Context, options GCOptions, providerHosts []*resources.Host) ([]GCEntry, error) {
+	var entries []GCEntry
+
+	providerNetworks, err := handler.service.ListNetworks()
+	if err != nil {
+		return nil, err
+	}
+	mn, err := metadata.NewNetwork(handler.service)
+	if err != nil {
+		return nil, err
+	}
+	knownByID := map[string]*resources.Network{}
+	knownByName := map[string]*resources.Network{}
+	err = mn.Browse(func(network *resources.Network) error {
+		knownByID[network.ID] = network
+		knownByName[network.Name] = network
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gatewayOf := gatewayNetworkNames(providerHosts)
+	existing := map[string]struct{}{}
+	for _, network := range providerNetworks {
+		existing[network.ID] = struct{}{}
+		if _, ok := knownByID[network.ID]; ok {
+			continue
+		}
+		entry := GCEntry{Kind: "network", ID: network.ID, Name: network.Name, Drift: DriftOrphan}
+		if _, ok := knownByName[network.Name]; ok {
+			entry.Drift = DriftConflict
+			entries = append(entries, entry)
+			continue
+		}
+		if _, ok := gatewayOf[network.Name]; !ok {
+			continue
+		}
+		if options.DeleteOrphans {
+			n := network
+			entry.Action = gcAction(options, entry, "deleted", func() error {
+				return handler.service.DeleteNetwork(n.ID)
+			})
+		}
+		if options.ImportOrphans {
+			n := network
+			entry.Action = gcAction(options, entry, "imported", func() error {
+				var gwRef string
+				if gw := primaryGatewayOf(providerHosts, n.Name); gw != nil {
+					gwRef = gw.ID
+				}
+				_, err := NewNetworkHandler(handler.service).Import(ctx, n.ID, gwRef, options.PrivateKey, options.User)
+				return err
+			})
+		}
+		entries = append(entries, entry)
+	}
+
+	for id, network := range knownByID {
+		if _, ok := existing[id]; ok {
+			continue
+		}
+		entry := GCEntry{Kind: "network", ID: id, Name: network.Name, Drift: DriftDangling}
+		if options.PurgeDangling {
+			n := network
+			entry.Action = gcAction(options, entry, "purged", func() error {
+				return metadata.RemoveNetwork(handler.service, n)
+			})
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources"
+)
+
+func TestSafeScaleHostNameRegexp(t *testing.T) {
+	for _, n := range []string{"gw-net1", "gw2-net1", "gw-net1-r1", "k8s-master-1", "my-cluster-node-12"} {
+		assert.True(t, safescaleHostNameRegexp.MatchString(n), n)
+	}
+	for _, n := range []string{"myhost", "gateway", "k8s-master", "web-node-a", "gw"} {
+		assert.False(t, safescaleHostNameRegexp.MatchString(n), n)
+	}
+}
+
+func TestSafeScaleVIPNameRegexp(t *testing.T) {
+	m := safescaleVIPNameRegexp.FindStringSubmatch("k8s-ControlPlaneVIP")
+	assert.NotNil(t, m)
+	assert.Equal(t, "k8s", m[2])
+
+	for _, n := range []string{"for gateways of network net1", "for share data"} {
+		m = safescaleVIPNameRegexp.FindStringSubmatch(n)
+		assert.NotNil(t, m, n)
+		assert.Equal(t, "", m[2], n)
+	}
+	assert.Nil(t, safescaleVIPNameRegexp.FindStringSubmatch("my-port"))
+}
+
+func TestGatewayNetworkNames(t *testing.T) {
+	names := gatewayNetworkNames([]*resources.Host{{Name: "gw-net1"}, {Name: "gw2-net2-r1"}, {Name: "myhost"}})
+	for _, n := range []string{"net1", "net2", "net2-r1"} {
+		_, ok := names[n]
+		assert.True(t, ok, n)
+	}
+	_, ok := names["myhost"]
+	assert.False(t, ok)
+}
+
+func TestPrimaryGatewayOf(t *testing.T) {
+	hosts := []*resources.Host{{ID: "1", Name: "gw2-net1"}, {ID: "2", Name: "gw-net1-r1"}, {ID: "3", Name: "gw-net2"}, {ID: "4", Name: "myhost"}}
+	gw := primaryGatewayOf(hosts, "net1")
+	assert.NotNil(t, gw)
+	assert.Equal(t, "2", gw.ID)
+	gw = primaryGatewayOf(hosts, "net2")
+	assert.NotNil(t, gw)
+	assert.Equal(t, "3", gw.ID)
+	assert.Nil(t, primaryGatewayOf(hosts, "myhost"))
+	assert.Nil(t, primaryGatewayOf(hosts, "net3"))
+}
+
+func TestGCOptions(t *testing.T) {
+	handler := &TenantHandler{}
+	_, err := handler.GC(context.Background(), GCOptions{DeleteOrphans: true, ImportOrphans: true, PrivateKey: "key"})
+	assert.NotNil(t, err)
+	_, err = handler.GC(context.Background(), GCOptions{ImportOrphans: true})
+	assert.NotNil(t, err)
+}
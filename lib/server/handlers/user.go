@@ -0,0 +1,204 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/hostproperty"
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/metadata"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+)
+
+var hostUserNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// checkUserName validates the login of a personal account, refusing the accounts used by the system and SafeScale
+func (handler *HostHandler) checkUserName(name string) error {
+	if !hostUserNameRegexp.MatchString(name) {
+		return scerr.InvalidParameterError("name", "must start with a lowercase letter or '_', followed by at most 31 lowercase letters, digits, '_' or '-'")
+	}
+	operator, err := getOperatorUsername(handler.service)
+	if err != nil {
+		return err
+	}
+	if name == "root" || name == operator {
+		return scerr.InvalidParameterError("name", fmt.Sprintf("'%s' is reserved", name))
+	}
+	return nil
+}
+
+// normalizePublicKeys validates the SSH public keys, in authorized_keys format, and drops the empty ones
+func normalizePublicKeys(publicKeys []string) ([]string, error) {
+	var keys []string
+	for _, k := range publicKeys {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k)); err != nil {
+			return nil, scerr.InvalidParameterError("publicKeys", fmt.Sprintf("invalid SSH public key '%s': %s", k, err.Error()))
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return nil, scerr.InvalidParameterError("publicKeys", "at least one SSH public key is required")
+	}
+	return keys, nil
+}
+
+// loadHostForUsers loads the metadata of the host on which personal accounts are managed
+func (handler *HostHandler) loadHostForUsers(ref string) (*metadata.Host, *resources.Host, error) {
+	mh, err := metadata.LoadHost(handler.service, ref)
+	if err != nil {
+		if _, ok := err.(scerr.ErrNotFound); ok {
+			return nil, nil, resources.ResourceNotFoundError("host", ref)
+		}
+		return nil, nil, err
+	}
+	host, err := mh.Get()
+	if err != nil {
+		return nil, nil, err
+	}
+	return mh, host, nil
+}
+
+// AddUser creates on the host the account name, authorized to log in with publicKeys and allowed to use sudo if sudo is true
+// If the account has already been added, its keys and sudo permission are replaced
+func (handler *HostHandler) AddUser(ctx context.Context, ref string, name string, publicKeys []string, sudo bool) (err error) {
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s', %v)", ref, name, sudo), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	err = handler.checkUserName(name)
+	if err != nil {
+		return err
+	}
+	keys, err := normalizePublicKeys(publicKeys)
+	if err != nil {
+		return err
+	}
+	mh, host, err := handler.loadHostForUsers(ref)
+	if err != nil {
+		return err
+	}
+
+	var update bool
+	err = host.Properties.LockForRead(hostproperty.UsersV1).ThenUse(func(clonable data.Clonable) error {
+		_, update = clonable.(*propsv1.HostUsers).ByName[name]
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{
+		"Name":       name,
+		"PublicKeys": strings.Join(keys, "\n"),
+		"Sudo":       sudo,
+		"Update":     update,
+	}
+	err = exec(ctx, "host_user_add.sh", params, host.ID, handler.service)
+	if err != nil {
+		return fmt.Errorf("failed to add user '%s' on host '%s': %s", name, host.Name, err.Error())
+	}
+
+	err = host.Properties.LockForWrite(hostproperty.UsersV1).ThenUse(func(clonable data.Clonable) error {
+		clonable.(*propsv1.HostUsers).ByName[name] = &propsv1.HostUser{
+			Name:       name,
+			PublicKeys: keys,
+			Sudo:       sudo,
+			Created:    time.Now(),
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return mh.Write()
+}
+
+// RemoveUser deletes from the host the account name previously added, with its home directory
+func (handler *HostHandler) RemoveUser(ctx context.Context, ref string, name string) (err error) {
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s')", ref, name), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	mh, host, err := handler.loadHostForUsers(ref)
+	if err != nil {
+		return err
+	}
+
+	var found bool
+	err = host.Properties.LockForRead(hostproperty.UsersV1).ThenUse(func(clonable data.Clonable) error {
+		_, found = clonable.(*propsv1.HostUsers).ByName[name]
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return resources.ResourceNotFoundError("user", fmt.Sprintf("%s on host %s", name, host.Name))
+	}
+
+	err = exec(ctx, "host_user_remove.sh", map[string]interface{}{"Name": name}, host.ID, handler.service)
+	if err != nil {
+		return fmt.Errorf("failed to remove user '%s' from host '%s': %s", name, host.Name, err.Error())
+	}
+
+	err = host.Properties.LockForWrite(hostproperty.UsersV1).ThenUse(func(clonable data.Clonable) error {
+		delete(clonable.(*propsv1.HostUsers).ByName, name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return mh.Write()
+}
+
+// ListUsers returns the accounts added on the host, sorted by name
+func (handler *HostHandler) ListUsers(ctx context.Context, ref string) (users []*propsv1.HostUser, err error) {
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	_, host, err := handler.loadHostForUsers(ref)
+	if err != nil {
+		return nil, err
+	}
+	err = host.Properties.LockForRead(hostproperty.UsersV1).ThenUse(func(clonable data.Clonable) error {
+		for _, u := range clonable.(*propsv1.HostUsers).ByName {
+			users = append(users, u.Clone().(*propsv1.HostUser))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+	return users, nil
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePublicKeys(t *testing.T) {
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl jdoe@laptop"
+
+	keys, err := normalizePublicKeys([]string{"  " + key + "\n", ""})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{key}, keys)
+
+	_, err = normalizePublicKeys([]string{"", " "})
+	assert.NotNil(t, err)
+
+	_, err = normalizePublicKeys([]string{key, "ssh-rsa notbase64"})
+	assert.NotNil(t, err)
+}
+
+func TestHostUserNameRegexp(t *testing.T) {
+	for _, n := range []string{"jdoe", "_svc", "j-doe_2"} {
+		assert.True(t, hostUserNameRegexp.MatchString(n), n)
+	}
+	for _, n := range []string{"", "JDoe", "2jdoe", "j.doe", "jdoe;rm", "abcdefghijklmnopqrstuvwxyz0123456"} {
+		assert.False(t, hostUserNameRegexp.MatchString(n), n)
+	}
+}
@@ -34,6 +34,8 @@ const (
 type Location interface {
 	// ReadTenant(projectName string, provider string) (Config, error)
 	GetType() string
+	// GetConfig returns the configuration of the Location (including credentials)
+	GetConfig() Config
 	//Inspect() (map[string][]string, error)
 	// SumSize() string
 	// Count(key string, pattern string) (int, error)
@@ -106,6 +106,11 @@ func (l location) GetType() string {
 	return l.config.Type
 }
 
+// GetConfig returns the configuration used to connect to the ObjectStorage
+func (l location) GetConfig() Config {
+	return l.config
+}
+
 // ListBuckets ...
 func (l *location) ListBuckets(prefix string) ([]string, error) {
 	if l == nil {
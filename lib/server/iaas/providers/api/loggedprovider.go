@@ -167,6 +167,12 @@ func (w LoggedProvider) DeleteGateway(networkID string) error {
 	return w.InnerProvider.DeleteGateway(networkID)
 }
 
+// UpdateGatewayRoutes ...
+func (w LoggedProvider) UpdateGatewayRoutes(networkID string, gatewayName string) error {
+	defer w.prepare(w.trace("UpdateGatewayRoutes"))
+	return w.InnerProvider.UpdateGatewayRoutes(networkID, gatewayName)
+}
+
 // CreateVIP ...
 func (w LoggedProvider) CreateVIP(networkID string, description string) (*resources.VirtualIP, error) {
 	defer w.prepare(w.trace("CreateVIP"))
@@ -197,6 +203,12 @@ func (w LoggedProvider) DeleteVIP(vip *resources.VirtualIP) error {
 	return w.InnerProvider.DeleteVIP(vip)
 }
 
+// ListVIPs lists the VIPs, i.e. the ports not attached to any host
+func (w LoggedProvider) ListVIPs() ([]*resources.VirtualIP, error) {
+	defer w.prepare(w.trace("ListVIPs"))
+	return w.InnerProvider.ListVIPs()
+}
+
 // CreateHost ...
 func (w LoggedProvider) CreateHost(request resources.HostRequest) (*resources.Host, *userdata.Content, error) {
 	defer w.prepare(w.trace("CreateHost"))
@@ -233,6 +233,16 @@ func (w ErrorTraceProvider) DeleteGateway(networkID string) (err error) {
 	return w.InnerProvider.DeleteGateway(networkID)
 }
 
+// UpdateGatewayRoutes ...
+func (w ErrorTraceProvider) UpdateGatewayRoutes(networkID string, gatewayName string) (err error) {
+	defer func(prefix string) {
+		if err != nil {
+			logrus.Warnf("%s : Intercepted error: %v", prefix, err)
+		}
+	}(fmt.Sprintf("%s:UpdateGatewayRoutes", w.Name))
+	return w.InnerProvider.UpdateGatewayRoutes(networkID, gatewayName)
+}
+
 // CreateVIP ...
 func (w ErrorTraceProvider) CreateVIP(networkID string, description string) (_ *resources.VirtualIP, err error) {
 	defer func(prefix string) {
@@ -283,6 +293,16 @@ func (w ErrorTraceProvider) DeleteVIP(vip *resources.VirtualIP) (err error) {
 	return w.InnerProvider.DeleteVIP(vip)
 }
 
+// ListVIPs lists the VIPs, i.e. the ports not attached to any host
+func (w ErrorTraceProvider) ListVIPs() (_ []*resources.VirtualIP, err error) {
+	defer func(prefix string) {
+		if err != nil {
+			logrus.Warnf("%s : Intercepted error: %v", prefix, err)
+		}
+	}(fmt.Sprintf("%s:ListVIPs", w.Name))
+	return w.InnerProvider.ListVIPs()
+}
+
 // CreateHost ...
 func (w ErrorTraceProvider) CreateHost(request resources.HostRequest) (_ *resources.Host, _ *userdata.Content, err error) {
 	defer func(prefix string) {
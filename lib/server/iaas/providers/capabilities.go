@@ -24,4 +24,6 @@ type Capabilities struct {
 	PrivateVirtualIP bool
 	// Layer3Networking indicates if the provider uses Layer3 networking
 	Layer3Networking bool
+	// DualStackNetworking indicates if the provider can create networks with both IPv4 and IPv6 addresses
+	DualStackNetworking bool
 }
@@ -200,7 +200,9 @@ func (p *provider) GetTenantParameters() map[string]interface{} {
 
 // GetCapabilities returns the capabilities of the provider
 func (p *provider) GetCapabilities() providers.Capabilities {
-	return providers.Capabilities{}
+	return providers.Capabilities{
+		DualStackNetworking: true,
+	}
 }
 
 func init() {
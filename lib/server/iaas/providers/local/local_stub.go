@@ -112,6 +112,9 @@ func (provider *provider) CreateGateway(req resources.GatewayRequest) (*resource
 func (provider *provider) DeleteGateway(string) error {
 	return fmt.Errorf(errorStr)
 }
+func (provider *provider) UpdateGatewayRoutes(networkID string, gatewayName string) error {
+	return fmt.Errorf(errorStr)
+}
 func (provider *provider) CreateVIP(networkID string, description string) (*resources.VirtualIP, error) {
 	return nil, fmt.Errorf(errorStr)
 }
@@ -127,6 +130,9 @@ func (provider *provider) UnbindHostFromVIP(vip *resources.VirtualIP, hostID str
 func (provider *provider) DeleteVIP(vip *resources.VirtualIP) error {
 	return fmt.Errorf(errorStr)
 }
+func (provider *provider) ListVIPs() ([]*resources.VirtualIP, error) {
+	return nil, fmt.Errorf(errorStr)
+}
 
 func (provider *provider) CreateHost(request resources.HostRequest) (*resources.Host, *userdata.Content, error) {
 	return nil, nil, fmt.Errorf(errorStr)
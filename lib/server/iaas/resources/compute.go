@@ -209,6 +209,20 @@ func (h *Host) GetPrivateIP() string {
 	return ip
 }
 
+// GetPrivateIPv6 returns the IPv6 address of the host in its default network, empty if this network is not dual-stack
+func (h *Host) GetPrivateIPv6() string {
+	var ip string
+	err := h.Properties.LockForRead(hostproperty.NetworkV1).ThenUse(func(clonable data.Clonable) error {
+		hostNetworkV1 := clonable.(*propsv1.HostNetwork)
+		ip = hostNetworkV1.IPv6Addresses[hostNetworkV1.DefaultNetworkID]
+		return nil
+	})
+	if err != nil {
+		return ""
+	}
+	return ip
+}
+
 // Content ...
 // satisfies interface data.Clonable
 func (h *Host) Content() data.Clonable {
@@ -31,4 +31,10 @@ const (
 	SharesV1 = "6"
 	// MountsV1 contains optional additional info about mounted devices (locally attached or remote filesystem)
 	MountsV1 = "7"
+	// LifecycleV1 contains optional additional info about the expiration of the host
+	LifecycleV1 = "8"
+	// ShareReplicasV1 contains optional additional info about the shares exported in high availability with another host
+	ShareReplicasV1 = "9"
+	// UsersV1 contains optional additional info about the personal accounts created on the host
+	UsersV1 = "10"
 )
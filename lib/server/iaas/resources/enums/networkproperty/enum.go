@@ -21,4 +21,10 @@ const (
 	DescriptionV1 = "1"
 	// HostsV1 contains list of hosts attached to the network
 	HostsV1 = "2"
+	// PeeringsV1 contains the VPN peerings of the network with networks of other tenants
+	PeeringsV1 = "3"
+	// DNSV1 contains the zone served by the DNS server of the gateway(s) of the network
+	DNSV1 = "4"
+	// ForwardsV1 contains the port forwarding rules set on the gateway(s) of the network
+	ForwardsV1 = "5"
 )
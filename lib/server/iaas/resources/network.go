@@ -41,10 +41,12 @@ type GatewayRequest struct {
 // like "192.0.2.0/24" or "2001:db8::/32", as defined in RFC 4632 and RFC 4291.
 type NetworkRequest struct {
 	Name string
-	// IPVersion must be IPv4 or IPv6 (see IPVersion)
+	// IPVersion must be IPv4 or IPv6 (see IPVersion); IPv6 requests a dual-stack network, IPv4 remaining used by SafeScale
 	IPVersion ipversion.Enum
 	// CIDR mask
 	CIDR string
+	// CIDRv6 is the IPv6 range of a dual-stack network; if empty, the provider chooses it
+	CIDRv6 string
 	// DNSServers
 	DNSServers []string
 	// HA tells if 2 gateways and a VIP needs to be created; the VIP IP address will be used as gateway
@@ -59,7 +61,8 @@ type Network struct {
 	GatewayID          string                    `json:"gateway_id,omitempty"`           // contains the id of the host acting as primary gateway for the network
 	SecondaryGatewayID string                    `json:"secondary_gateway_id,omitempty"` // contains the id of the host acting as secondary gateway for the network
 	VIP                *VirtualIP                `json:"vip,omitempty"`                  // contains the VIP of the network if created with HA
-	IPVersion          ipversion.Enum            `json:"ip_version,omitempty"`           // IPVersion is IPv4 or IPv6 (see IPVersion)
+	IPVersion          ipversion.Enum            `json:"ip_version,omitempty"`           // IPVersion is IPv4, or IPv6 for a dual-stack network (see IPVersion)
+	CIDRv6             string                    `json:"cidr_v6,omitempty"`              // IPv6 network in CIDR notation of a dual-stack network
 	Properties         *serialize.JSONProperties `json:"properties,omitempty"`           // contains optional supplemental information
 }
 
@@ -578,6 +578,12 @@ type HostInstalledFeature struct {
 	HostContext bool     `json:"host_context,omitempty"` // tells if the feature has been explicitly installed for host (opposed to for cluster)
 	RequiredBy  []string `json:"required_by,omitempty"`  // tells what feature(s) needs this one
 	Requires    []string `json:"requires,omitempty"`
+	Version     string   `json:"version,omitempty"` // version of the feature installed, as declared in its specification file
+	// CheckedAt tells when the feature has been checked by the drift detection for the last time
+	CheckedAt    time.Time `json:"checked_at,omitempty"`
+	CheckStatus  string    `json:"check_status,omitempty"`  // result of the last check ("ok", "drifted", "remediated" or "failed")
+	CheckMessage string    `json:"check_message,omitempty"` // details about the result of the last check
+	RemediatedAt time.Time `json:"remediated_at,omitempty"` // tells when the feature has been added again after a drift
 }
 
 // NewHostInstalledFeature ...
@@ -612,6 +618,12 @@ func (hif *HostInstalledFeature) Clone() data.Clonable {
 // satisfies interface data.Clonable
 func (hif *HostInstalledFeature) Replace(p data.Clonable) data.Clonable {
 	src := p.(*HostInstalledFeature)
+	hif.HostContext = src.HostContext
+	hif.Version = src.Version
+	hif.CheckedAt = src.CheckedAt
+	hif.CheckStatus = src.CheckStatus
+	hif.CheckMessage = src.CheckMessage
+	hif.RemediatedAt = src.RemediatedAt
 	hif.RequiredBy = make([]string, len(src.RequiredBy))
 	copy(hif.RequiredBy, src.RequiredBy)
 	hif.Requires = make([]string, len(src.Requires))
@@ -661,6 +673,209 @@ func (hf *HostFeatures) Replace(p data.Clonable) data.Clonable {
 	return hf
 }
 
+// HostLifecycle contains information about the planned end of life of the host
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with needed supplemental/overriding fields
+type HostLifecycle struct {
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // tells when the host will be reclaimed; zero value means never
+}
+
+// NewHostLifecycle ...
+func NewHostLifecycle() *HostLifecycle {
+	return &HostLifecycle{}
+}
+
+// Reset resets the content of the property
+func (hl *HostLifecycle) Reset() {
+	*hl = HostLifecycle{}
+}
+
+// Content ...
+// satisfies interface data.Clonable
+func (hl *HostLifecycle) Content() data.Clonable {
+	return hl
+}
+
+// Clone ...
+// satisfies interface data.Clonable
+func (hl *HostLifecycle) Clone() data.Clonable {
+	return NewHostLifecycle().Replace(hl)
+}
+
+// Replace ...
+// satisfies interface data.Clonable
+func (hl *HostLifecycle) Replace(p data.Clonable) data.Clonable {
+	*hl = *p.(*HostLifecycle)
+	return hl
+}
+
+// HostShareReplica describes the part taken by the host in a share exported in high availability
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with needed supplemental/overriding fields
+type HostShareReplica struct {
+	ShareID    string `json:"share_id"`              // ID of the share, as registered in HostShares of both servers
+	Primary    bool   `json:"primary,omitempty"`     // tells if the host is the one holding the share at creation
+	PeerID     string `json:"peer_id"`               // ID of the other host of the pair
+	PeerName   string `json:"peer_name"`             // Name of the other host of the pair
+	Device     string `json:"device"`                // block device replicated between the servers
+	Resource   string `json:"resource"`              // name of the DRBD resource
+	Minor      int    `json:"minor"`                 // index of the replica on both servers (DRBD minor, VRRP router id, ...)
+	VIPID      string `json:"vip_id"`                // ID of the VIP moving between the servers
+	VIPAddress string `json:"vip_address,omitempty"` // private IP of the VIP, used as export address
+}
+
+// NewHostShareReplica ...
+func NewHostShareReplica() *HostShareReplica {
+	return &HostShareReplica{}
+}
+
+// Reset ...
+func (hsr *HostShareReplica) Reset() {
+	*hsr = HostShareReplica{}
+}
+
+// Content ...
+func (hsr *HostShareReplica) Content() data.Clonable {
+	return hsr
+}
+
+// Clone ...
+func (hsr *HostShareReplica) Clone() data.Clonable {
+	return NewHostShareReplica().Replace(hsr)
+}
+
+// Replace ...
+func (hsr *HostShareReplica) Replace(p data.Clonable) data.Clonable {
+	*hsr = *p.(*HostShareReplica)
+	return hsr
+}
+
+// HostShareReplicas contains information about the shares of the host replicated on another host
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with needed supplemental/overriding fields
+type HostShareReplicas struct {
+	ByShareID map[string]*HostShareReplica `json:"by_share_id"`
+}
+
+// NewHostShareReplicas ...
+func NewHostShareReplicas() *HostShareReplicas {
+	return &HostShareReplicas{
+		ByShareID: map[string]*HostShareReplica{},
+	}
+}
+
+// Reset ...
+func (hsr *HostShareReplicas) Reset() {
+	*hsr = HostShareReplicas{
+		ByShareID: map[string]*HostShareReplica{},
+	}
+}
+
+// Content ...
+func (hsr *HostShareReplicas) Content() data.Clonable {
+	return hsr
+}
+
+// Clone ...
+func (hsr *HostShareReplicas) Clone() data.Clonable {
+	return NewHostShareReplicas().Replace(hsr)
+}
+
+// Replace ...
+func (hsr *HostShareReplicas) Replace(p data.Clonable) data.Clonable {
+	src := p.(*HostShareReplicas)
+	hsr.ByShareID = make(map[string]*HostShareReplica, len(src.ByShareID))
+	for k, v := range src.ByShareID {
+		hsr.ByShareID[k] = v.Clone().(*HostShareReplica)
+	}
+	return hsr
+}
+
+// HostUser describes a personal account created on the host
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with needed supplemental/overriding fields
+type HostUser struct {
+	Name       string    `json:"name"`                  // login of the user
+	PublicKeys []string  `json:"public_keys,omitempty"` // SSH public keys authorized to log in as the user
+	Sudo       bool      `json:"sudo,omitempty"`        // tells if the user is allowed to use sudo
+	Created    time.Time `json:"created,omitempty"`     // tells when the user has been added (or updated for the last time)
+}
+
+// NewHostUser ...
+func NewHostUser() *HostUser {
+	return &HostUser{}
+}
+
+// Reset ...
+func (hu *HostUser) Reset() {
+	*hu = HostUser{}
+}
+
+// Content ...
+func (hu *HostUser) Content() data.Clonable {
+	return hu
+}
+
+// Clone ...
+func (hu *HostUser) Clone() data.Clonable {
+	return NewHostUser().Replace(hu)
+}
+
+// Replace ...
+func (hu *HostUser) Replace(p data.Clonable) data.Clonable {
+	src := p.(*HostUser)
+	*hu = *src
+	hu.PublicKeys = make([]string, len(src.PublicKeys))
+	copy(hu.PublicKeys, src.PublicKeys)
+	return hu
+}
+
+// HostUsers contains information about the personal accounts created on the host
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with needed supplemental/overriding fields
+type HostUsers struct {
+	ByName map[string]*HostUser `json:"by_name"`
+}
+
+// NewHostUsers ...
+func NewHostUsers() *HostUsers {
+	return &HostUsers{
+		ByName: map[string]*HostUser{},
+	}
+}
+
+// Reset ...
+func (hu *HostUsers) Reset() {
+	*hu = HostUsers{
+		ByName: map[string]*HostUser{},
+	}
+}
+
+// Content ...
+func (hu *HostUsers) Content() data.Clonable {
+	return hu
+}
+
+// Clone ...
+func (hu *HostUsers) Clone() data.Clonable {
+	return NewHostUsers().Replace(hu)
+}
+
+// Replace ...
+func (hu *HostUsers) Replace(p data.Clonable) data.Clonable {
+	src := p.(*HostUsers)
+	hu.ByName = make(map[string]*HostUser, len(src.ByName))
+	for k, v := range src.ByName {
+		hu.ByName[k] = v.Clone().(*HostUser)
+	}
+	return hu
+}
+
 func init() {
 	serialize.PropertyTypeRegistry.Register("resources.host", hostproperty.DescriptionV1, NewHostDescription())
 	serialize.PropertyTypeRegistry.Register("resources.host", hostproperty.NetworkV1, NewHostNetwork())
@@ -669,4 +884,7 @@ func init() {
 	serialize.PropertyTypeRegistry.Register("resources.host", hostproperty.VolumesV1, NewHostVolumes())
 	serialize.PropertyTypeRegistry.Register("resources.host", hostproperty.MountsV1, NewHostMounts())
 	serialize.PropertyTypeRegistry.Register("resources.host", hostproperty.FeaturesV1, NewHostFeatures())
+	serialize.PropertyTypeRegistry.Register("resources.host", hostproperty.LifecycleV1, NewHostLifecycle())
+	serialize.PropertyTypeRegistry.Register("resources.host", hostproperty.ShareReplicasV1, NewHostShareReplicas())
+	serialize.PropertyTypeRegistry.Register("resources.host", hostproperty.UsersV1, NewHostUsers())
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestHostDescription_Clone(t *testing.T) {
@@ -151,6 +152,7 @@ func TestHostShares_Clone(t *testing.T) {
 func TestHostInstalledFeature_Clone(t *testing.T) {
 	ct := NewHostInstalledFeature()
 	ct.Requires = append(ct.Requires, "DarkestRoads")
+	ct.Version = "1.2.0"
 
 	clonedCt, ok := ct.Clone().(*HostInstalledFeature)
 	if !ok {
@@ -187,3 +189,71 @@ func TestHostFeatures_Clone(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestHostLifecycle_Clone(t *testing.T) {
+	ct := NewHostLifecycle()
+	ct.ExpiresAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	clonedCt, ok := ct.Clone().(*HostLifecycle)
+	if !ok {
+		t.Fail()
+	}
+
+	assert.Equal(t, ct, clonedCt)
+	clonedCt.ExpiresAt = clonedCt.ExpiresAt.Add(time.Hour)
+
+	areEqual := reflect.DeepEqual(ct, clonedCt)
+	if areEqual {
+		t.Error("It's a shallow clone !")
+		t.Fail()
+	}
+}
+
+func TestHostShareReplicas_Clone(t *testing.T) {
+	replica := NewHostShareReplica()
+	replica.ShareID = "share"
+	replica.Primary = true
+	replica.PeerName = "peer"
+	replica.Device = "/dev/vdb"
+
+	ct := NewHostShareReplicas()
+	ct.ByShareID[replica.ShareID] = replica
+
+	clonedCt, ok := ct.Clone().(*HostShareReplicas)
+	if !ok {
+		t.Fail()
+	}
+
+	assert.Equal(t, ct, clonedCt)
+	clonedCt.ByShareID["share"].PeerName = "other"
+
+	areEqual := reflect.DeepEqual(ct, clonedCt)
+	if areEqual {
+		t.Error("It's a shallow clone !")
+		t.Fail()
+	}
+}
+
+func TestHostUsers_Clone(t *testing.T) {
+	user := NewHostUser()
+	user.Name = "jdoe"
+	user.PublicKeys = []string{"ssh-ed25519 AAAA jdoe@laptop"}
+	user.Sudo = true
+
+	ct := NewHostUsers()
+	ct.ByName[user.Name] = user
+
+	clonedCt, ok := ct.Clone().(*HostUsers)
+	if !ok {
+		t.Fail()
+	}
+
+	assert.Equal(t, ct, clonedCt)
+	clonedCt.ByName["jdoe"].PublicKeys[0] = "ssh-rsa AAAA jdoe@desktop"
+
+	areEqual := reflect.DeepEqual(ct, clonedCt)
+	if areEqual {
+		t.Error("It's a shallow clone !")
+		t.Fail()
+	}
+}
@@ -109,7 +109,190 @@ func (nh *NetworkHosts) Replace(p data.Clonable) data.Clonable {
 	return nh
 }
 
+// NetworkPeering describes a WireGuard tunnel between the gateways of the network and the ones of a peer network,
+// possibly of another tenant
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with needed supplemental/overriding fields
+type NetworkPeering struct {
+	PeerTenant      string    `json:"peer_tenant"`       // tenant of the peer network
+	PeerNetworkID   string    `json:"peer_network_id"`   // ID of the peer network
+	PeerNetworkName string    `json:"peer_network_name"` // name of the peer network
+	PeerCIDR        string    `json:"peer_cidr"`         // CIDR of the peer network, routed through the tunnel
+	PeerEndpoint    string    `json:"peer_endpoint"`     // public IP and port of the tunnel on the peer side
+	PeerPublicKey   string    `json:"peer_public_key"`   // WireGuard public key of the peer side
+	Interface       string    `json:"interface"`         // WireGuard interface on the gateways of the network
+	Port            int       `json:"port"`              // UDP port listened by the WireGuard interface
+	PublicKey       string    `json:"public_key"`        // WireGuard public key of the network side
+	Created         time.Time `json:"created,omitempty"` // date of creation of the peering
+}
+
+// NewNetworkPeering ...
+func NewNetworkPeering() *NetworkPeering {
+	return &NetworkPeering{}
+}
+
+// NetworkPeerings contains the peerings of the network
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with needed supplemental/overriding fields
+type NetworkPeerings struct {
+	ByPeer map[string]*NetworkPeering `json:"by_peer"` // peerings indexed by <peer network name>@<peer tenant>
+}
+
+// NewNetworkPeerings ...
+func NewNetworkPeerings() *NetworkPeerings {
+	return &NetworkPeerings{
+		ByPeer: map[string]*NetworkPeering{},
+	}
+}
+
+// Reset resets the content of the property
+func (np *NetworkPeerings) Reset() {
+	*np = NetworkPeerings{
+		ByPeer: map[string]*NetworkPeering{},
+	}
+}
+
+// Content ...
+// satisfies interface data.Clonable
+func (np *NetworkPeerings) Content() data.Clonable {
+	return np
+}
+
+// Clone ...
+// satisfies interface data.Clonable
+func (np *NetworkPeerings) Clone() data.Clonable {
+	return NewNetworkPeerings().Replace(np)
+}
+
+// Replace ...
+// satisfies interface data.Clonable
+func (np *NetworkPeerings) Replace(p data.Clonable) data.Clonable {
+	src := p.(*NetworkPeerings)
+	np.ByPeer = make(map[string]*NetworkPeering, len(src.ByPeer))
+	for k, v := range src.ByPeer {
+		peering := *v
+		np.ByPeer[k] = &peering
+	}
+	return np
+}
+
+// NetworkDNS contains the zone served to the hosts of the network by the DNS server of its gateway(s)
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with needed supplemental/overriding fields
+type NetworkDNS struct {
+	Domain  string            `json:"domain,omitempty"`  // domain of the zone, <network>.safescale; empty if the gateways serve no zone
+	Records map[string]string `json:"records,omitempty"` // private IP of the hosts, indexed by host name in the zone
+}
+
+// NewNetworkDNS ...
+func NewNetworkDNS() *NetworkDNS {
+	return &NetworkDNS{
+		Records: map[string]string{},
+	}
+}
+
+// Reset resets the content of the property
+func (nd *NetworkDNS) Reset() {
+	*nd = NetworkDNS{
+		Records: map[string]string{},
+	}
+}
+
+// Content ...
+// satisfies interface data.Clonable
+func (nd *NetworkDNS) Content() data.Clonable {
+	return nd
+}
+
+// Clone ...
+// satisfies interface data.Clonable
+func (nd *NetworkDNS) Clone() data.Clonable {
+	return NewNetworkDNS().Replace(nd)
+}
+
+// Replace ...
+// satisfies interface data.Clonable
+func (nd *NetworkDNS) Replace(p data.Clonable) data.Clonable {
+	src := p.(*NetworkDNS)
+	nd.Domain = src.Domain
+	nd.Records = make(map[string]string, len(src.Records))
+	for k, v := range src.Records {
+		nd.Records[k] = v
+	}
+	return nd
+}
+
+// NetworkForward describes a port forwarding rule (DNAT) from the public IP of the gateway(s) to a host of the network
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with needed supplemental/overriding fields
+type NetworkForward struct {
+	Protocol   string `json:"protocol"`    // tcp or udp
+	PublicPort int    `json:"public_port"` // port on the public IP of the gateway(s)
+	HostID     string `json:"host_id"`     // ID of the host receiving the traffic
+	HostName   string `json:"host_name"`   // name of the host receiving the traffic
+	HostIP     string `json:"host_ip"`     // private IP of the host in the network
+	Port       int    `json:"port"`        // port on the host
+}
+
+// NewNetworkForward ...
+func NewNetworkForward() *NetworkForward {
+	return &NetworkForward{}
+}
+
+// NetworkForwards contains the port forwarding rules of the network
+// not FROZEN yet
+// Note: if tagged as FROZEN, must not be changed ever.
+//       Create a new version instead with needed supplemental/overriding fields
+type NetworkForwards struct {
+	ByPublicPort map[string]*NetworkForward `json:"by_public_port"` // rules indexed by <protocol>/<public port>
+}
+
+// NewNetworkForwards ...
+func NewNetworkForwards() *NetworkForwards {
+	return &NetworkForwards{
+		ByPublicPort: map[string]*NetworkForward{},
+	}
+}
+
+// Reset resets the content of the property
+func (nf *NetworkForwards) Reset() {
+	*nf = NetworkForwards{
+		ByPublicPort: map[string]*NetworkForward{},
+	}
+}
+
+// Content ...
+// satisfies interface data.Clonable
+func (nf *NetworkForwards) Content() data.Clonable {
+	return nf
+}
+
+// Clone ...
+// satisfies interface data.Clonable
+func (nf *NetworkForwards) Clone() data.Clonable {
+	return NewNetworkForwards().Replace(nf)
+}
+
+// Replace ...
+// satisfies interface data.Clonable
+func (nf *NetworkForwards) Replace(p data.Clonable) data.Clonable {
+	src := p.(*NetworkForwards)
+	nf.ByPublicPort = make(map[string]*NetworkForward, len(src.ByPublicPort))
+	for k, v := range src.ByPublicPort {
+		forward := *v
+		nf.ByPublicPort[k] = &forward
+	}
+	return nf
+}
+
 func init() {
+	serialize.PropertyTypeRegistry.Register("resources.network", networkproperty.DNSV1, NewNetworkDNS())
+	serialize.PropertyTypeRegistry.Register("resources.network", networkproperty.ForwardsV1, NewNetworkForwards())
 	serialize.PropertyTypeRegistry.Register("resources.network", networkproperty.HostsV1, NewNetworkHosts())
+	serialize.PropertyTypeRegistry.Register("resources.network", networkproperty.PeeringsV1, NewNetworkPeerings())
 	serialize.PropertyTypeRegistry.Register("resources.network", networkproperty.DescriptionV1, NewNetworkDescription())
 }
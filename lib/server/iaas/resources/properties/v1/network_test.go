@@ -43,3 +43,61 @@ func TestNetworkHosts_Clone(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestNetworkPeerings_Clone(t *testing.T) {
+	ct := NewNetworkPeerings()
+	ct.ByPeer["net@tenant"] = &NetworkPeering{PeerTenant: "tenant", PeerNetworkName: "net", Port: 51820}
+
+	clonedCt, ok := ct.Clone().(*NetworkPeerings)
+	if !ok {
+		t.Fail()
+	}
+
+	assert.Equal(t, ct, clonedCt)
+	clonedCt.ByPeer["net@tenant"].Port = 51821
+
+	areEqual := reflect.DeepEqual(ct, clonedCt)
+	if areEqual {
+		t.Error("It's a shallow clone !")
+		t.Fail()
+	}
+}
+
+func TestNetworkDNS_Clone(t *testing.T) {
+	ct := NewNetworkDNS()
+	ct.Domain = "net.safescale"
+	ct.Records["host"] = "192.168.0.10"
+
+	clonedCt, ok := ct.Clone().(*NetworkDNS)
+	if !ok {
+		t.Fail()
+	}
+
+	assert.Equal(t, ct, clonedCt)
+	clonedCt.Records["host"] = "192.168.0.11"
+
+	areEqual := reflect.DeepEqual(ct, clonedCt)
+	if areEqual {
+		t.Error("It's a shallow clone !")
+		t.Fail()
+	}
+}
+
+func TestNetworkForwards_Clone(t *testing.T) {
+	ct := NewNetworkForwards()
+	ct.ByPublicPort["tcp/8080"] = &NetworkForward{Protocol: "tcp", PublicPort: 8080, HostName: "host", Port: 80}
+
+	clonedCt, ok := ct.Clone().(*NetworkForwards)
+	if !ok {
+		t.Fail()
+	}
+
+	assert.Equal(t, ct, clonedCt)
+	clonedCt.ByPublicPort["tcp/8080"].Port = 8000
+
+	areEqual := reflect.DeepEqual(ct, clonedCt)
+	if areEqual {
+		t.Error("It's a shallow clone !")
+		t.Fail()
+	}
+}
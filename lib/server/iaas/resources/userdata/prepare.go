@@ -64,10 +64,18 @@ type Content struct {
 	// DNSServers contains the list of DNS servers to use
 	// Used only if IsGateway is true
 	DNSServers []string
+	// DNSDomain contains the domain of the zone served by the gateway(s) of the default network, used as search domain
+	DNSDomain string
 	// CIDR contains the cidr of the network
 	CIDR string
+	// AdditionalCIDRs contains the cidr of the networks attached besides the default one; they don't get the default route
+	AdditionalCIDRs []string
 	// DefaultRouteIP is the IP of the gateway or the VIP if gateway HA is enabled
 	DefaultRouteIP string
+	// CIDRv6 contains the IPv6 cidr of the network if it is dual-stack
+	CIDRv6 string
+	// DefaultRouteIPv6 is the IPv6 address of the primary gateway in a dual-stack network
+	DefaultRouteIPv6 string
 	// PrimaryGatewayPrivateIP is the private IP of the primary gateway
 	PrimaryGatewayPrivateIP string
 	// PrimaryGatewayPublicIP is the public IP of the primary gateway
@@ -88,6 +96,10 @@ type Content struct {
 	PublicVIP string // VPL: change to EndpointIP
 	// PrivateVIP contains the private IP of the VIP instance if it exists
 	PrivateVIP string // VPL: change to DefaultRouteIP
+	// UserData contains the user-supplied cloud-config or script applied in phase3
+	UserData string
+	// PostInstallScript contains the user-supplied script run at the end of phase3
+	PostInstallScript string
 
 	// Dashboard bool // Add kubernetes dashboard
 }
@@ -95,6 +107,7 @@ type Content struct {
 var (
 	userdataPhase1Template atomic.Value //*template.Template
 	userdataPhase2Template atomic.Value //*template.Template
+	userdataPhase3Template atomic.Value //*template.Template
 )
 
 // NewContent ...
@@ -167,7 +180,16 @@ func (ud *Content) Prepare(
 	ud.AddGateway = !request.PublicIP && !useLayer3Networking && ip != "" && !useNATService
 	ud.DNSServers = dnsList
 	ud.CIDR = cidr
+	ud.AdditionalCIDRs = nil
+	for _, n := range request.Networks[1:] {
+		ud.AdditionalCIDRs = append(ud.AdditionalCIDRs, n.CIDR)
+	}
 	ud.DefaultRouteIP = ip
+	ud.CIDRv6 = request.Networks[0].CIDRv6
+	ud.DefaultRouteIPv6 = ""
+	if ud.CIDRv6 != "" && request.DefaultGateway != nil {
+		ud.DefaultRouteIPv6 = request.DefaultGateway.GetPrivateIPv6()
+	}
 	ud.Password = request.Password
 	ud.EmulatedPublicNet = defaultNetworkCIDR
 
@@ -275,6 +297,34 @@ func (ud *Content) Generate(phase string) ([]byte, error) {
 			}
 		}
 
+	case "phase3":
+		anon := userdataPhase3Template.Load()
+		if anon == nil {
+			box, err = rice.FindBox("../userdata/scripts")
+			if err != nil {
+				return nil, err
+			}
+
+			// phase3 only applies user customization, there is no flavor of it
+			tmplString, err := box.String("userdata.phase3.sh")
+			if err != nil {
+				return nil, fmt.Errorf("error loading script template for phase3 : %s", err.Error())
+			}
+			tmpl, err := template.New("userdata.phase3").Parse(tmplString)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing script template for phase 3 : %s", err.Error())
+			}
+			userdataPhase3Template.Store(tmpl)
+			anon = userdataPhase3Template.Load()
+		}
+		tmpl := anon.(*template.Template)
+		buf := bytes.NewBufferString("")
+		err = tmpl.Execute(buf, ud)
+		if err != nil {
+			return nil, err
+		}
+		result = buf.Bytes()
+
 	default:
 		return nil, fmt.Errorf("phase '%s' not managed", phase)
 	}
@@ -147,6 +147,21 @@ is_ip_private() {
     return 1
 }
 
+# Tells if the interface is attached to the default network, the only one providing the default route
+is_default_nic() {
+{{- if .AdditionalCIDRs }}
+    ip=$(ip -f inet -o addr show $1 | awk '{print $4}' | cut -d/ -f1)
+    [ -z "$ip" ] && return 1
+    ipv=$(sfIP2long $ip)
+    r=$(sfCidr2iprange {{ .CIDR }})
+    bv=$(sfIP2long $(cut -d- -f1 <<<$r))
+    ev=$(sfIP2long $(cut -d- -f2 <<<$r))
+    [ $ipv -ge $bv -a $ipv -le $ev ]
+{{- else }}
+    return 0
+{{- end }}
+}
+
 identify_nics() {
     NICS=$(for i in $(find /sys/devices -name net -print | grep -v virtual); do ls $i; done)
     NICS=${NICS/[[:cntrl:]]/ }
@@ -193,6 +208,9 @@ ensure_network_connectivity() {
     {{- if .AddGateway }}
         route del -net default &>/dev/null
         route add -net default gw {{ .DefaultRouteIP }}
+        {{- if .DefaultRouteIPv6 }}
+        ip -6 route replace default via {{ .DefaultRouteIPv6 }} || true
+        {{- end }}
     {{- else }}
     :
     {{- end}}
@@ -262,13 +280,25 @@ configure_network_debian() {
 auto ${IF}
 iface ${IF} inet dhcp
 EOF
-        else
+        elif is_default_nic $IF; then
             cat <<-EOF >$path/11-$IF-private.cfg
 auto ${IF}
 iface ${IF} inet dhcp
 {{- if .AddGateway }}
   up route add -net default gw {{ .DefaultRouteIP }} || true
 {{- end}}
+{{- if .CIDRv6 }}
+iface ${IF} inet6 dhcp
+{{- if and .AddGateway .DefaultRouteIPv6 }}
+  up ip -6 route replace default via {{ .DefaultRouteIPv6 }} dev ${IF} || true
+{{- end}}
+{{- end}}
+EOF
+        else
+            cat <<-EOF >$path/12-$IF-private.cfg
+auto ${IF}
+iface ${IF} inet dhcp
+  up ip route del default dev ${IF} || true
 EOF
         fi
     done
@@ -307,7 +337,7 @@ network:
           use-dns: false
           use-routes: true
 EOF
-        else
+        elif is_default_nic $IF; then
             cat <<-EOF >/etc/netplan/11-$IF-private.yaml
 network:
   version: 2
@@ -316,7 +346,7 @@ network:
   ethernets:
     $IF:
       dhcp4: true
-      dhcp6: false
+      dhcp6: {{ if .CIDRv6 }}true{{ else }}false{{ end }}
       critical: true
       dhcp4-overrides:
         use-dns: false
@@ -327,9 +357,29 @@ network:
         via: {{ .DefaultRouteIP }}
         scope: global
         on-link: true
+{{- if .DefaultRouteIPv6 }}
+      - to: ::/0
+        via: {{ .DefaultRouteIPv6 }}
+        on-link: true
+{{- end}}
 {{- else }}
         use-routes: true
 {{- end}}
+EOF
+        else
+            cat <<-EOF >/etc/netplan/12-$IF-private.yaml
+network:
+  version: 2
+  renderer: networkd
+
+  ethernets:
+    $IF:
+      dhcp4: true
+      dhcp6: false
+      critical: true
+      dhcp4-overrides:
+        use-dns: false
+        use-routes: false
 EOF
         fi
     done
@@ -390,6 +440,13 @@ BOOTPROTO=dhcp
 ONBOOT=yes
 NM_CONTROLLED=no
 EOF
+            is_default_nic $IF || echo "DEFROUTE=no" >>/etc/sysconfig/network-scripts/ifcfg-$IF
+            {{- if .CIDRv6 }}
+            if is_default_nic $IF && [ "$IF" != "$PU_IF" ]; then
+                echo "IPV6INIT=yes" >>/etc/sysconfig/network-scripts/ifcfg-$IF
+                echo "DHCPV6C=yes" >>/etc/sysconfig/network-scripts/ifcfg-$IF
+            fi
+            {{- end }}
             {{- if .DNSServers }}
             i=1
             {{- range .DNSServers }}
@@ -406,6 +463,15 @@ EOF
 
     {{- if .AddGateway }}
     echo "GATEWAY={{ .DefaultRouteIP }}" >/etc/sysconfig/network
+    {{- if .DefaultRouteIPv6 }}
+    echo "NETWORKING_IPV6=yes" >>/etc/sysconfig/network
+    echo "IPV6_DEFAULTGW={{ .DefaultRouteIPv6 }}" >>/etc/sysconfig/network
+    {{- end }}
+    {{- if .AdditionalCIDRs }}
+    for IF in $PR_IFs; do
+        is_default_nic $IF && echo "GATEWAYDEV=$IF" >>/etc/sysconfig/network
+    done
+    {{- end }}
     {{- end }}
 
     enable_svc network
@@ -454,6 +520,12 @@ configure_as_gateway() {
         cat >/etc/sysctl.d/21-gateway.conf <<-EOF
 net.ipv4.ip_forward=1
 net.ipv4.ip_nonlocal_bind=1
+{{- if .CIDRv6 }}
+net.ipv6.conf.all.forwarding=1
+# Keeps accepting router advertisements on the public side despite forwarding
+net.ipv6.conf.all.accept_ra=2
+net.ipv6.conf.default.accept_ra=2
+{{- end }}
 EOF
         case $LINUX_KIND in
             ubuntu) systemctl restart systemd-sysctl;;
@@ -468,6 +540,12 @@ EOF
         sfFirewallAdd --direct --add-rule ipv4 filter INPUT 0 -p icmp -m icmp --icmp-type 8 -s 0.0.0.0/0 -d 0.0.0.0/0 -j ACCEPT
         # Allows masquerading on public zone
         sfFirewallAdd --zone=public --add-masquerade
+        {{- if .CIDRv6 }}
+        # Unique local IPv6 addresses of the network are not routable, they are masqueraded as IPv4 ones
+        sfFirewallAdd --direct --add-rule ipv6 nat POSTROUTING 0 -s {{ .CIDRv6 }} ! -d {{ .CIDRv6 }} -j MASQUERADE
+        sfFirewallAdd --direct --add-rule ipv6 filter FORWARD 0 -s {{ .CIDRv6 }} -j ACCEPT
+        sfFirewallAdd --direct --add-rule ipv6 filter FORWARD 0 -d {{ .CIDRv6 }} -m state --state RELATED,ESTABLISHED -j ACCEPT
+        {{- end }}
     fi
     # Enables masquerading on trusted zone (mainly for docker networks)
     sfFirewallAdd --zone=trusted --add-masquerade
@@ -593,6 +671,9 @@ configure_dns_legacy() {
     fi
     {{- end }}
     cat <<-'EOF' >/etc/resolv.conf
+{{- if .DNSDomain }}
+search {{ .DNSDomain }}
+{{- end }}
 {{- if .DNSServers }}
   {{- range .DNSServers }}
 nameserver {{ . }}
@@ -608,6 +689,9 @@ configure_dns_resolvconf() {
     echo "Configuring resolvconf..."
 
     cat <<-'EOF' >/etc/resolvconf/resolv.conf.d/head
+{{- if .DNSDomain }}
+search {{ .DNSDomain }}
+{{- end }}
 {{- if .DNSServers }}
   {{- range .DNSServers }}
 nameserver {{ . }}
@@ -636,6 +720,9 @@ DNS={{ range .DNSServers }}{{ . }} {{ end }}
 {{- else }}
 DNS=1.1.1.1
 {{- end}}
+{{- if .DNSDomain }}
+Domains={{ .DNSDomain }}
+{{- end }}
 Cache=yes
 DNSStubListener=yes
 EOF
@@ -0,0 +1,99 @@
+#!/bin/bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+{{.Header}}
+
+print_error() {
+    read line file <<<$(caller)
+    echo "An error occurred in line $line of file $file:" "{"`sed "${line}q;d" "$file"`"}" >&2
+    {{.ExitOnError}}
+}
+trap print_error ERR
+
+fail() {
+    echo "PROVISIONING_ERROR: $1"
+    echo -n "$1,${LINUX_KIND},$(date +%Y/%m/%d-%H:%M:%S)" >/opt/safescale/var/state/user_data.phase3.done
+    exit $1
+}
+
+# Redirects outputs to /opt/safescale/log/user_data.phase3.log
+exec 1<&-
+exec 2<&-
+exec 1<>/opt/safescale/var/log/user_data.phase3.log
+exec 2>&1
+set -x
+
+# Includes the BashLibrary
+{{ .BashLibrary }}
+
+# Applies the user data supplied at host creation, either a cloud-config document or a script
+apply_user_data() {
+    local file=/opt/safescale/var/tmp/user_data.custom
+    cat >$file <<'SAFESCALE_USER_DATA_EOF'
+{{ .UserData }}
+SAFESCALE_USER_DATA_EOF
+    chmod 0700 $file
+
+    case "$(head -n 1 $file)" in
+        "#cloud-config"*)
+            which cloud-init &>/dev/null || fail 301
+            # Removes the runcmd script generated by the cloud-config used at boot, so only the one of the user data is run
+            rm -f /var/lib/cloud/instance/scripts/runcmd
+            for module in write_files ca_certs users_groups mounts package_update_upgrade_install runcmd; do
+                cloud-init --file $file single --name $module --frequency always || fail 302
+            done
+            if [ -f /var/lib/cloud/instance/scripts/runcmd ]; then
+                sh /var/lib/cloud/instance/scripts/runcmd || fail 303
+            fi
+            ;;
+        "#!"*)
+            $file || fail 304
+            ;;
+        *)
+            echo "user data format not supported; must start with '#cloud-config' or '#!'"
+            fail 305
+            ;;
+    esac
+}
+
+# Runs the post-installation script supplied at host creation
+run_post_install_script() {
+    local file=/opt/safescale/var/tmp/post_install.sh
+    cat >$file <<'SAFESCALE_POST_INSTALL_EOF'
+{{ .PostInstallScript }}
+SAFESCALE_POST_INSTALL_EOF
+    chmod 0700 $file
+
+    if [[ "$(head -n 1 $file)" == "#!"* ]]; then
+        $file || fail 306
+    else
+        bash $file || fail 306
+    fi
+}
+
+# ---- Main
+
+{{- if .UserData }}
+apply_user_data
+{{- end }}
+{{- if .PostInstallScript }}
+run_post_install_script
+{{- end }}
+
+echo -n "0,linux,${LINUX_KIND},${VERSION_ID},$(hostname),$(date +%Y/%m/%d-%H:%M:%S)" >/opt/safescale/var/state/user_data.phase3.done
+
+set +x
+exit 0
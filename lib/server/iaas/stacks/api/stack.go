@@ -62,6 +62,9 @@ type Stack interface {
 	CreateGateway(req resources.GatewayRequest) (*resources.Host, *userdata.Content, error)
 	// DeleteGateway delete the public gateway of a private network
 	DeleteGateway(networkID string) error
+	// UpdateGatewayRoutes makes the routes of the provider, if any, send the traffic of the network to the gateway
+	// named gatewayName
+	UpdateGatewayRoutes(networkID string, gatewayName string) error
 
 	// CreateVIP ...
 	CreateVIP(string, string) (*resources.VirtualIP, error)
@@ -73,6 +76,8 @@ type Stack interface {
 	UnbindHostFromVIP(*resources.VirtualIP, string) error
 	// DeleteVIP deletes the port corresponding to the VIP
 	DeleteVIP(*resources.VirtualIP) error
+	// ListVIPs lists the VIPs, i.e. the ports not attached to any host
+	ListVIPs() ([]*resources.VirtualIP, error)
 
 	// CreateHost creates an host that fulfils the request
 	CreateHost(request resources.HostRequest) (*resources.Host, *userdata.Content, error)
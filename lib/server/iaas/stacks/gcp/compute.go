@@ -35,6 +35,7 @@ import (
 	"github.com/CS-SI/SafeScale/lib/server/iaas/resources"
 	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/hostproperty"
 	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/hoststate"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/ipversion"
 	converters "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties"
 	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
 	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/userdata"
@@ -237,6 +238,10 @@ func (s *Stack) CreateHost(request resources.HostRequest) (host *resources.Host,
 	if len(networks) == 0 {
 		return nil, userData, fmt.Errorf("the host %s must be on at least one network (even if public)", resourceName)
 	}
+	// buildGcpMachine creates a single network interface, the other networks would be silently ignored
+	if len(networks) > 1 {
+		return nil, userData, scerr.NotImplementedError(fmt.Sprintf("attachment of host %s to several networks not implemented yet", resourceName))
+	}
 
 	// If no key pair is supplied create one
 	if request.KeyPair == nil {
@@ -390,7 +395,7 @@ func (s *Stack) CreateHost(request resources.HostRequest) (host *resources.Host,
 	// Retry creation until success, for 10 minutes
 	retryErr := retry.WhileUnsuccessfulDelay5Seconds(
 		func() error {
-			server, err := buildGcpMachine(s.ComputeService, s.GcpConfig.ProjectID, request.ResourceName, rim.URL, s.GcpConfig.Zone, s.GcpConfig.NetworkName, defaultNetwork.Name, string(userDataPhase1), isGateway, template)
+			server, err := buildGcpMachine(s.ComputeService, s.GcpConfig.ProjectID, request.ResourceName, rim.URL, s.GcpConfig.Zone, s.GcpConfig.NetworkName, defaultNetwork.Name, defaultNetwork.IPVersion == ipversion.IPv6, string(userDataPhase1), isGateway, template)
 			if err != nil {
 				if server != nil {
 					// try deleting server
@@ -546,8 +551,16 @@ func publicAccess(isPublic bool) []*compute.AccessConfig {
 	return []*compute.AccessConfig{}
 }
 
+// stackType returns the stack type of the network interface of a host in a subnetwork, dual-stack or not
+func stackType(dualStack bool) string {
+	if dualStack {
+		return "IPV4_IPV6"
+	}
+	return "IPV4_ONLY"
+}
+
 // buildGcpMachine ...
-func buildGcpMachine(service *compute.Service, projectID string, instanceName string, imageID string, zone string, network string, subnetwork string, userdata string, isPublic bool, template *resources.HostTemplate) (*resources.Host, error) {
+func buildGcpMachine(service *compute.Service, projectID string, instanceName string, imageID string, zone string, network string, subnetwork string, dualStack bool, userdata string, isPublic bool, template *resources.HostTemplate) (*resources.Host, error) {
 	prefix := "https://www.googleapis.com/compute/v1/projects/" + projectID
 
 	imageURL := imageID
@@ -582,6 +595,7 @@ func buildGcpMachine(service *compute.Service, projectID string, instanceName st
 				AccessConfigs: publicAccess(isPublic),
 				Network:       prefix + "/global/networks/" + network,
 				Subnetwork:    prefix + "/regions/europe-west1/subnetworks/" + subnetwork,
+				StackType:     stackType(dualStack),
 			},
 		},
 		ServiceAccounts: []*compute.ServiceAccount{
@@ -696,6 +710,7 @@ func (s *Stack) InspectHost(hostParam interface{}) (host *resources.Host, err er
 			subnets = append(subnets, IPInSubnet{
 				Subnet:   snet,
 				IP:       nit.NetworkIP,
+				IPv6:     nit.Ipv6Address,
 				PublicIP: pubIP,
 			})
 		}
@@ -717,17 +732,22 @@ func (s *Stack) InspectHost(hostParam interface{}) (host *resources.Host, err er
 			Name:     psg.Name,
 			ID:       strconv.FormatUint(psg.Id, 10),
 			IP:       sn.IP,
+			IPv6:     sn.IPv6,
 			PublicIP: sn.PublicIP,
 		})
 	}
 
 	ip4bynetid := make(map[string]string)
+	ip6bynetid := make(map[string]string)
 	netnamebyid := make(map[string]string)
 	netidbyname := make(map[string]string)
 
 	ipv4 := ""
 	for _, rn := range resouceNetworks {
 		ip4bynetid[rn.ID] = rn.IP
+		if rn.IPv6 != "" {
+			ip6bynetid[rn.ID] = rn.IPv6
+		}
 		netnamebyid[rn.ID] = rn.Name
 		netidbyname[rn.Name] = rn.ID
 		if rn.PublicIP != "" {
@@ -738,7 +758,7 @@ func (s *Stack) InspectHost(hostParam interface{}) (host *resources.Host, err er
 	err = host.Properties.LockForWrite(hostproperty.NetworkV1).ThenUse(func(clonable data.Clonable) error {
 		hostNetworkV1 := clonable.(*propsv1.HostNetwork)
 		hostNetworkV1.IPv4Addresses = ip4bynetid
-		hostNetworkV1.IPv6Addresses = make(map[string]string)
+		hostNetworkV1.IPv6Addresses = ip6bynetid
 		hostNetworkV1.NetworksByID = netnamebyid
 		hostNetworkV1.NetworksByName = netidbyname
 		if hostNetworkV1.PublicIPv4 == "" {
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/sirupsen/logrus"
@@ -42,10 +43,13 @@ func (s *Stack) CreateNetwork(req resources.NetworkRequest) (*resources.Network,
 		return nil, scerr.InvalidInstanceError()
 	}
 
-	// disable subnetwork auto-creation
+	dualStack := req.IPVersion == ipversion.IPv6
+
+	// disable subnetwork auto-creation; internal IPv6 is enabled for dual-stack subnetworks
 	ne := compute.Network{
 		Name:                  s.GcpConfig.NetworkName,
 		AutoCreateSubnetworks: false,
+		EnableUlaInternalIpv6: true,
 		ForceSendFields:       []string{"AutoCreateSubnetworks"},
 	}
 
@@ -55,6 +59,9 @@ func (s *Stack) CreateNetwork(req resources.NetworkRequest) (*resources.Network,
 	recnet, err := compuService.Networks.Get(s.GcpConfig.ProjectID, ne.Name).Do()
 	if recnet != nil && err == nil {
 		recreateSafescaleNetwork = false
+		if dualStack && !recnet.EnableUlaInternalIpv6 {
+			return nil, scerr.InvalidRequestError(fmt.Sprintf("cannot create dual-stack network: internal IPv6 is not enabled on network '%s'", ne.Name))
+		}
 	} else if err != nil {
 		if gerr, ok := err.(*googleapi.Error); ok {
 			if gerr.Code != 404 {
@@ -103,6 +110,14 @@ func (s *Stack) CreateNetwork(req resources.NetworkRequest) (*resources.Network,
 		Network:     fmt.Sprintf("projects/%s/global/networks/%s", s.GcpConfig.ProjectID, s.GcpConfig.NetworkName),
 		Region:      theRegion,
 	}
+	if dualStack {
+		// the IPv6 range of the subnetwork is chosen by GCP in the range of the network
+		if req.CIDRv6 != "" {
+			logrus.Warnf("IPv6 range '%s' ignored, GCP chooses the IPv6 range of subnetwork '%s'", req.CIDRv6, req.Name)
+		}
+		subnetReq.StackType = "IPV4_IPV6"
+		subnetReq.Ipv6AccessType = "INTERNAL"
+	}
 
 	opp, err := compuService.Subnetworks.Insert(s.GcpConfig.ProjectID, theRegion, &subnetReq).Context(context.Background()).Do()
 	if err != nil {
@@ -132,6 +147,10 @@ func (s *Stack) CreateNetwork(req resources.NetworkRequest) (*resources.Network,
 	subnet.Name = gcpSubNet.Name
 	subnet.CIDR = gcpSubNet.IpCidrRange
 	subnet.IPVersion = ipversion.IPv4
+	if dualStack {
+		subnet.CIDRv6 = gcpSubNet.InternalIpv6Prefix
+		subnet.IPVersion = ipversion.IPv6
+	}
 
 	buildNewRule := true
 	firewallRuleName := fmt.Sprintf("%s-%s-all-in", s.GcpConfig.NetworkName, gcpSubNet.Name)
@@ -225,10 +244,72 @@ func (s *Stack) CreateNetwork(req resources.NetworkRequest) (*resources.Network,
 
 	}
 
+	if dualStack {
+		err = s.createIPv6Rules(gcpSubNet.Name, req.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// FIXME Validation before return...
 	return subnet, nil
 }
 
+// createIPv6Rules opens the incoming IPv6 traffic of a dual-stack subnetwork, as the IPv4 one, and routes its outgoing
+// IPv6 traffic through the gateway
+func (s *Stack) createIPv6Rules(subnetName, networkName string) error {
+	compuService := s.ComputeService
+	networkURL := fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/global/networks/%s", s.GcpConfig.ProjectID, s.GcpConfig.NetworkName)
+
+	fiw := compute.Firewall{
+		Allowed: []*compute.FirewallAllowed{
+			{
+				IPProtocol: "all",
+			},
+		},
+		Direction:    "INGRESS",
+		Disabled:     false,
+		Name:         fmt.Sprintf("%s-%s-all-in-v6", s.GcpConfig.NetworkName, subnetName),
+		Network:      networkURL,
+		Priority:     999,
+		SourceRanges: []string{"::/0"},
+	}
+	opp, err := compuService.Firewalls.Insert(s.GcpConfig.ProjectID, &fiw).Do()
+	if err != nil {
+		return err
+	}
+	oco := OpContext{
+		Operation:    opp,
+		ProjectID:    s.GcpConfig.ProjectID,
+		Service:      compuService,
+		DesiredState: "DONE",
+	}
+	err = waitUntilOperationIsSuccessfulOrTimeout(oco, temporal.GetMinDelay(), temporal.GetHostTimeout())
+	if err != nil {
+		return err
+	}
+
+	route := &compute.Route{
+		DestRange:       "::/0",
+		Name:            fmt.Sprintf("%s-%s-nat6-allowed", s.GcpConfig.NetworkName, subnetName),
+		Network:         networkURL,
+		NextHopInstance: fmt.Sprintf("projects/%s/zones/%s/instances/gw-%s", s.GcpConfig.ProjectID, s.GcpConfig.Zone, networkName),
+		Priority:        800,
+		Tags:            []string{fmt.Sprintf("no-ip-%s", subnetName)},
+	}
+	opp, err = compuService.Routes.Insert(s.GcpConfig.ProjectID, route).Do()
+	if err != nil {
+		return err
+	}
+	oco = OpContext{
+		Operation:    opp,
+		ProjectID:    s.GcpConfig.ProjectID,
+		Service:      compuService,
+		DesiredState: "DONE",
+	}
+	return waitUntilOperationIsSuccessfulOrTimeout(oco, temporal.GetMinDelay(), 2*temporal.GetContextTimeout())
+}
+
 // GetNetwork returns the network identified by ref (id or name)
 func (s *Stack) GetNetwork(ref string) (*resources.Network, error) {
 	if s == nil {
@@ -308,6 +389,11 @@ func (s *Stack) ListNetworks() ([]*resources.Network, error) {
 			newNet.Name = nett.Name
 			newNet.ID = strconv.FormatUint(nett.Id, 10)
 			newNet.CIDR = nett.IpCidrRange
+			newNet.IPVersion = ipversion.IPv4
+			if nett.StackType == "IPV4_IPV6" {
+				newNet.CIDRv6 = nett.InternalIpv6Prefix
+				newNet.IPVersion = ipversion.IPv6
+			}
 
 			networks = append(networks, newNet)
 		}
@@ -373,48 +459,57 @@ func (s *Stack) DeleteNetwork(ref string) (err error) {
 	}
 
 	// Delete routes and firewall
-	firewallRuleName := fmt.Sprintf("%s-%s-all-in", s.GcpConfig.NetworkName, subnetwork.Name)
-	fws, err := compuService.Firewalls.Get(s.GcpConfig.ProjectID, firewallRuleName).Do()
-	if fws != nil && err == nil {
-		opp, operr := compuService.Firewalls.Delete(s.GcpConfig.ProjectID, firewallRuleName).Do()
-		if operr == nil {
-			oco := OpContext{
-				Operation:    opp,
-				ProjectID:    s.GcpConfig.ProjectID,
-				Service:      compuService,
-				DesiredState: "DONE",
-			}
-
-			operr = waitUntilOperationIsSuccessfulOrTimeout(oco, temporal.GetMinDelay(), temporal.GetHostCleanupTimeout())
-			if operr != nil {
-				logrus.Warn(operr)
+	firewallRuleNames := []string{fmt.Sprintf("%s-%s-all-in", s.GcpConfig.NetworkName, subnetwork.Name)}
+	natRuleNames := []string{fmt.Sprintf("%s-%s-nat-allowed", s.GcpConfig.NetworkName, subnetwork.Name)}
+	if subnetwork.StackType == "IPV4_IPV6" {
+		firewallRuleNames = append(firewallRuleNames, fmt.Sprintf("%s-%s-all-in-v6", s.GcpConfig.NetworkName, subnetwork.Name))
+		natRuleNames = append(natRuleNames, fmt.Sprintf("%s-%s-nat6-allowed", s.GcpConfig.NetworkName, subnetwork.Name))
+	}
+
+	for _, firewallRuleName := range firewallRuleNames {
+		fws, err := compuService.Firewalls.Get(s.GcpConfig.ProjectID, firewallRuleName).Do()
+		if fws != nil && err == nil {
+			opp, operr := compuService.Firewalls.Delete(s.GcpConfig.ProjectID, firewallRuleName).Do()
+			if operr == nil {
+				oco := OpContext{
+					Operation:    opp,
+					ProjectID:    s.GcpConfig.ProjectID,
+					Service:      compuService,
+					DesiredState: "DONE",
+				}
+
+				operr = waitUntilOperationIsSuccessfulOrTimeout(oco, temporal.GetMinDelay(), temporal.GetHostCleanupTimeout())
+				if operr != nil {
+					logrus.Warn(operr)
+				}
 			}
 		}
+		if err != nil {
+			logrus.Warn(err)
+		}
 	}
-	if err != nil {
-		logrus.Warn(err)
-	}
-
-	natRuleName := fmt.Sprintf("%s-%s-nat-allowed", s.GcpConfig.NetworkName, subnetwork.Name)
-	nws, err := compuService.Routes.Get(s.GcpConfig.ProjectID, natRuleName).Do()
-	if nws != nil && err == nil {
-		opp, operr := compuService.Routes.Delete(s.GcpConfig.ProjectID, natRuleName).Do()
-		if operr == nil {
-			oco := OpContext{
-				Operation:    opp,
-				ProjectID:    s.GcpConfig.ProjectID,
-				Service:      compuService,
-				DesiredState: "DONE",
-			}
 
-			operr = waitUntilOperationIsSuccessfulOrTimeout(oco, temporal.GetMinDelay(), temporal.GetHostCleanupTimeout())
-			if operr != nil {
-				logrus.Warn(operr)
+	for _, natRuleName := range natRuleNames {
+		nws, err := compuService.Routes.Get(s.GcpConfig.ProjectID, natRuleName).Do()
+		if nws != nil && err == nil {
+			opp, operr := compuService.Routes.Delete(s.GcpConfig.ProjectID, natRuleName).Do()
+			if operr == nil {
+				oco := OpContext{
+					Operation:    opp,
+					ProjectID:    s.GcpConfig.ProjectID,
+					Service:      compuService,
+					DesiredState: "DONE",
+				}
+
+				operr = waitUntilOperationIsSuccessfulOrTimeout(oco, temporal.GetMinDelay(), temporal.GetHostCleanupTimeout())
+				if operr != nil {
+					logrus.Warn(operr)
+				}
 			}
 		}
-	}
-	if err != nil {
-		logrus.Warn(err)
+		if err != nil {
+			logrus.Warn(err)
+		}
 	}
 
 	return nil
@@ -470,6 +565,81 @@ func (s *Stack) DeleteGateway(ref string) error {
 	return s.DeleteHost(ref)
 }
 
+// UpdateGatewayRoutes makes the routes of the network send the outgoing traffic of the hosts without public IP to the
+// gateway named gatewayName; GCP routes cannot be modified, they are deleted then created again
+func (s *Stack) UpdateGatewayRoutes(networkID string, gatewayName string) error {
+	if s == nil {
+		return scerr.InvalidInstanceError()
+	}
+	if gatewayName == "" {
+		return scerr.InvalidParameterError("gatewayName", "cannot be empty string")
+	}
+
+	theNetwork, err := s.GetNetwork(networkID)
+	if err != nil {
+		return err
+	}
+	compuService := s.ComputeService
+	subnetwork, err := compuService.Subnetworks.Get(s.GcpConfig.ProjectID, s.GcpConfig.Region, theNetwork.Name).Do()
+	if err != nil {
+		return err
+	}
+
+	natRuleNames := []string{fmt.Sprintf("%s-%s-nat-allowed", s.GcpConfig.NetworkName, subnetwork.Name)}
+	if subnetwork.StackType == "IPV4_IPV6" {
+		natRuleNames = append(natRuleNames, fmt.Sprintf("%s-%s-nat6-allowed", s.GcpConfig.NetworkName, subnetwork.Name))
+	}
+	nextHop := fmt.Sprintf("projects/%s/zones/%s/instances/%s", s.GcpConfig.ProjectID, s.GcpConfig.Zone, gatewayName)
+	for _, natRuleName := range natRuleNames {
+		route, err := compuService.Routes.Get(s.GcpConfig.ProjectID, natRuleName).Do()
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(route.NextHopInstance, "/instances/"+gatewayName) {
+			continue
+		}
+
+		opp, err := compuService.Routes.Delete(s.GcpConfig.ProjectID, natRuleName).Do()
+		if err != nil {
+			return err
+		}
+		oco := OpContext{
+			Operation:    opp,
+			ProjectID:    s.GcpConfig.ProjectID,
+			Service:      compuService,
+			DesiredState: "DONE",
+		}
+		err = waitUntilOperationIsSuccessfulOrTimeout(oco, temporal.GetMinDelay(), temporal.GetHostCleanupTimeout())
+		if err != nil {
+			return err
+		}
+
+		newRoute := &compute.Route{
+			DestRange:       route.DestRange,
+			Name:            route.Name,
+			Network:         route.Network,
+			NextHopInstance: nextHop,
+			Priority:        route.Priority,
+			Tags:            route.Tags,
+		}
+		opp, err = compuService.Routes.Insert(s.GcpConfig.ProjectID, newRoute).Do()
+		if err != nil {
+			return err
+		}
+		oco = OpContext{
+			Operation:    opp,
+			ProjectID:    s.GcpConfig.ProjectID,
+			Service:      compuService,
+			DesiredState: "DONE",
+		}
+		err = waitUntilOperationIsSuccessfulOrTimeout(oco, temporal.GetMinDelay(), 2*temporal.GetContextTimeout())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CreateVIP creates a private virtual IP
 // If public is set to true,
 func (s *Stack) CreateVIP(networkID string, description string) (*resources.VirtualIP, error) {
@@ -495,3 +665,8 @@ func (s *Stack) UnbindHostFromVIP(vip *resources.VirtualIP, hostID string) error
 func (s *Stack) DeleteVIP(vip *resources.VirtualIP) error {
 	return scerr.NotImplementedError("DeleteVIP() not implemented yet")
 }
+
+// ListVIPs lists the VIPs, i.e. the ports not attached to any host
+func (s *Stack) ListVIPs() ([]*resources.VirtualIP, error) {
+	return nil, scerr.NotImplementedError("ListVIPs() not implemented yet")
+}
@@ -327,6 +327,11 @@ func (s *Stack) DeleteGateway(ref string) error {
 	return s.DeleteHost(ref)
 }
 
+// UpdateGatewayRoutes does nothing, the hosts route their traffic to the gateway by themselves
+func (s *Stack) UpdateGatewayRoutes(networkID string, gatewayName string) error {
+	return nil
+}
+
 // CreateVIP creates a private virtual IP
 // If public is set to true,
 func (s *Stack) CreateVIP(networkID string, description string) (*resources.VirtualIP, error) {
@@ -352,3 +357,8 @@ func (s *Stack) UnbindHostFromVIP(vip *resources.VirtualIP, hostID string) error
 func (s *Stack) DeleteVIP(vip *resources.VirtualIP) error {
 	return scerr.NotImplementedError("DeleteVIP() not implemented yet")
 }
+
+// ListVIPs lists the VIPs, i.e. the ports not attached to any host
+func (s *Stack) ListVIPs() ([]*resources.VirtualIP, error) {
+	return nil, scerr.NotImplementedError("ListVIPs() not implemented yet")
+}
@@ -119,6 +119,11 @@ func (s *Stack) DeleteGateway(string) error {
 	return fmt.Errorf(errorStr)
 }
 
+// UpdateGatewayRoutes stub
+func (s *Stack) UpdateGatewayRoutes(networkID string, gatewayName string) error {
+	return fmt.Errorf(errorStr)
+}
+
 // CreateVIP stub
 func (s *Stack) CreateVIP(networkID string, description string) (*resources.VirtualIP, error) {
 	return nil, fmt.Errorf(errorStr)
@@ -144,6 +149,11 @@ func (s *Stack) DeleteVIP(vip *resources.VirtualIP) error {
 	return fmt.Errorf(errorStr)
 }
 
+// ListVIPs stub
+func (s *Stack) ListVIPs() ([]*resources.VirtualIP, error) {
+	return nil, fmt.Errorf(errorStr)
+}
+
 // CreateHost stub
 func (s *Stack) CreateHost(request resources.HostRequest) (*resources.Host, *userdata.Content, error) {
 	return nil, nil, fmt.Errorf(errorStr)
@@ -108,7 +108,7 @@ func (s *Stack) CreateNetwork(req resources.NetworkRequest) (newNet *resources.N
 		}
 	}()
 
-	subnet, err := s.createSubnet(req.Name, network.ID, req.CIDR, req.IPVersion, req.DNSServers)
+	subnet, err := s.createSubnet(req.Name, network.ID, req.CIDR, ipversion.IPv4, req.DNSServers)
 	if err != nil {
 		return nil, fmt.Errorf("error creating network '%s': %s", req.Name, ProviderErrorToString(err))
 	}
@@ -128,10 +128,46 @@ func (s *Stack) CreateNetwork(req resources.NetworkRequest) (newNet *resources.N
 	newNet.ID = network.ID
 	newNet.Name = network.Name
 	newNet.CIDR = subnet.Mask
-	newNet.IPVersion = subnet.IPVersion
+	newNet.IPVersion = ipversion.IPv4
+
+	// A dual-stack network gets a second subnet, IPv6
+	if req.IPVersion == ipversion.IPv6 {
+		cidrV6 := req.CIDRv6
+		if cidrV6 == "" {
+			cidrV6, err = utils.GenerateULAPrefix()
+			if err != nil {
+				return nil, fmt.Errorf("error creating network '%s': %s", req.Name, err.Error())
+			}
+		}
+		subnetV6, err := s.createSubnet(req.Name+"-v6", network.ID, cidrV6, ipversion.IPv6, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating IPv6 subnet of network '%s': %s", req.Name, ProviderErrorToString(err))
+		}
+		newNet.CIDRv6 = subnetV6.Mask
+		newNet.IPVersion = ipversion.IPv6
+	}
 	return newNet, nil
 }
 
+// fillNetworkFromSubnets sets the CIDRs of a network from its subnets, and tells if they are the ones of a network
+// created by SafeScale: one IPv4 subnet, plus one IPv6 subnet for a dual-stack network
+func fillNetworkFromSubnets(newNet *resources.Network, sns []Subnet) bool {
+	var v4, v6 int
+	newNet.IPVersion = ipversion.IPv4
+	for _, sn := range sns {
+		switch sn.IPVersion {
+		case ipversion.IPv4:
+			newNet.CIDR = sn.Mask
+			v4++
+		case ipversion.IPv6:
+			newNet.CIDRv6 = sn.Mask
+			newNet.IPVersion = ipversion.IPv6
+			v6++
+		}
+	}
+	return v4 == 1 && v6 <= 1
+}
+
 // GetNetworkByName ...
 func (s *Stack) GetNetworkByName(name string) (*resources.Network, error) {
 	if s == nil {
@@ -187,10 +223,6 @@ func (s *Stack) GetNetwork(id string) (*resources.Network, error) {
 		if err != nil {
 			return nil, scerr.Wrap(err, fmt.Sprintf("error getting network: %s", ProviderErrorToString(err)))
 		}
-		if len(sns) != 1 {
-			return nil, fmt.Errorf("bad configuration, each network should have exactly one subnet")
-		}
-		sn := sns[0]
 		// gwID, _ := client.getGateway(id)
 		// if err != nil {
 		// 	return nil, fmt.Errorf("bad configuration, no gateway associated to this network")
@@ -198,8 +230,9 @@ func (s *Stack) GetNetwork(id string) (*resources.Network, error) {
 		newNet := resources.NewNetwork()
 		newNet.ID = network.ID
 		newNet.Name = network.Name
-		newNet.CIDR = sn.Mask
-		newNet.IPVersion = sn.IPVersion
+		if !fillNetworkFromSubnets(newNet, sns) {
+			return nil, fmt.Errorf("bad configuration, each network should have exactly one IPv4 subnet and at most one IPv6 subnet")
+		}
 		//net.GatewayID = network.GatewayId
 		return newNet, nil
 	}
@@ -233,19 +266,16 @@ func (s *Stack) ListNetworks() ([]*resources.Network, error) {
 				if err != nil {
 					return false, fmt.Errorf("error getting network: %s", ProviderErrorToString(err))
 				}
-				if len(sns) != 1 {
-					continue
-				}
 				if n.ID == s.ProviderNetworkID {
 					continue
 				}
-				sn := sns[0]
 
 				newNet := resources.NewNetwork()
 				newNet.ID = n.ID
 				newNet.Name = n.Name
-				newNet.CIDR = sn.Mask
-				newNet.IPVersion = sn.IPVersion
+				if !fillNetworkFromSubnets(newNet, sns) {
+					continue
+				}
 				// GatewayID: gwID,
 				netList = append(netList, newNet)
 			}
@@ -464,10 +494,15 @@ func (s *Stack) createSubnet(name string, networkID string, cidr string, ipVersi
 		opts.DNSNameservers = dnsServers
 	}
 
-	if !s.cfgOpts.UseLayer3Networking {
+	// IPv6 traffic of a dual-stack network always leaves it through the gateway(s) of the network
+	if !s.cfgOpts.UseLayer3Networking || ipVersion == ipversion.IPv6 {
 		noGateway := ""
 		opts.GatewayIP = &noGateway
 	}
+	if ipVersion == ipversion.IPv6 {
+		// Without router, there are no router advertisements to get addresses from
+		opts.IPv6AddressMode = "dhcpv6-stateful"
+	}
 
 	// Execute the operation and get back a subnets.Subnet struct
 	r := subnets.Create(s.NetworkClient, opts)
@@ -496,7 +531,7 @@ func (s *Stack) createSubnet(name string, networkID string, cidr string, ipVersi
 		}
 	}()
 
-	if s.cfgOpts.UseLayer3Networking {
+	if s.cfgOpts.UseLayer3Networking && ipVersion != ipversion.IPv6 {
 		router, err := s.createRouter(RouterRequest{
 			Name:      subnet.ID,
 			NetworkID: s.ProviderNetworkID,
@@ -774,6 +809,14 @@ func (s *Stack) listPorts(options ports.ListOpts) ([]ports.Port, error) {
 	return ports.ExtractPorts(allPages)
 }
 
+// UpdateGatewayRoutes does nothing, the hosts route their traffic to the gateway by themselves
+func (s *Stack) UpdateGatewayRoutes(networkID string, gatewayName string) error {
+	if s == nil {
+		return scerr.InvalidInstanceError()
+	}
+	return nil
+}
+
 // CreateVIP creates a private virtual IP
 // If public is set to true,
 func (s *Stack) CreateVIP(networkID string, name string) (*resources.VirtualIP, error) {
@@ -901,3 +944,30 @@ func (s *Stack) DeleteVIP(vip *resources.VirtualIP) error {
 	}
 	return ports.Delete(s.NetworkClient, vip.ID).ExtractErr()
 }
+
+// ListVIPs lists the VIPs, i.e. the ports not attached to any host
+func (s *Stack) ListVIPs() ([]*resources.VirtualIP, error) {
+	if s == nil {
+		return nil, scerr.InvalidInstanceError()
+	}
+
+	allPorts, err := s.listPorts(ports.ListOpts{})
+	if err != nil {
+		return nil, err
+	}
+	var vips []*resources.VirtualIP
+	for _, p := range allPorts {
+		if p.DeviceID != "" || p.DeviceOwner != "" {
+			continue
+		}
+		vip := resources.NewVirtualIP()
+		vip.ID = p.ID
+		vip.Name = p.Name
+		vip.NetworkID = p.NetworkID
+		if len(p.FixedIPs) > 0 {
+			vip.PrivateIP = p.FixedIPs[0].IPAddress
+		}
+		vips = append(vips, vip)
+	}
+	return vips, nil
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	pb "github.com/CS-SI/SafeScale/lib"
+	clusterpropsv1 "github.com/CS-SI/SafeScale/lib/server/cluster/control/properties/v1"
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+)
+
+const (
+	// CheckStatusOK means the check of the feature succeeded
+	CheckStatusOK = "ok"
+	// CheckStatusDrifted means the check of the feature failed: the feature isn't installed as expected anymore
+	CheckStatusDrifted = "drifted"
+	// CheckStatusRemediated means the check of the feature failed, and the feature has been added again successfully
+	CheckStatusRemediated = "remediated"
+	// CheckStatusFailed means the check of the feature couldn't be run
+	CheckStatusFailed = "failed"
+)
+
+// FeatureStatus describes the result of the last check of a feature installed on a host or a cluster
+type FeatureStatus struct {
+	Feature      string    `json:"feature"`
+	Status       string    `json:"status,omitempty"` // empty if the feature has never been checked
+	Message      string    `json:"message,omitempty"`
+	CheckedAt    time.Time `json:"checked_at,omitempty"`
+	RemediatedAt time.Time `json:"remediated_at,omitempty"`
+}
+
+// ListFeaturesStatus returns the result of the last check of each feature recorded as installed on the target
+func ListFeaturesStatus(task concurrency.Task, t Target) ([]*FeatureStatus, error) {
+	if task == nil {
+		return nil, scerr.InvalidParameterError("task", "cannot be nil")
+	}
+	if t == nil {
+		return nil, scerr.InvalidParameterError("t", "cannot be nil")
+	}
+
+	list, err := listInstalled(task, t)
+	if err != nil {
+		return nil, err
+	}
+	statuses := []*FeatureStatus{}
+	for _, name := range checkedNames(t, list) {
+		status := list[name].check
+		statuses = append(statuses, &status)
+	}
+	return statuses, nil
+}
+
+// CheckInstalledFeatures runs the action 'check' of each feature recorded as installed on the target, to detect the
+// features that drifted (service stopped, package removed, ...), and records the results in the metadata of the target.
+// If 'remediate' is true, a feature that drifted is added again
+func CheckInstalledFeatures(task concurrency.Task, t Target, remediate bool) ([]*FeatureStatus, error) {
+	if task == nil {
+		return nil, scerr.InvalidParameterError("task", "cannot be nil")
+	}
+	if t == nil {
+		return nil, scerr.InvalidParameterError("t", "cannot be nil")
+	}
+
+	list, err := listInstalled(task, t)
+	if err != nil {
+		return nil, err
+	}
+	statuses := []*FeatureStatus{}
+	for _, name := range checkedNames(t, list) {
+		status := checkInstalledFeature(task, t, name, remediate)
+		if !list[name].check.RemediatedAt.IsZero() && status.RemediatedAt.IsZero() {
+			status.RemediatedAt = list[name].check.RemediatedAt
+		}
+		err = recordCheck(task, t, status)
+		if err != nil {
+			logrus.Warnf("failed to record the check of feature '%s' on %s '%s': %v", name, t.Type(), t.Name(), err)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// checkedNames returns the sorted names of the installed features to check on the target; on a host, the features
+// installed for the cluster the host belongs to are checked with the cluster
+func checkedNames(t Target, list map[string]installedRecord) []string {
+	names := sortedNames(list)
+	if hT, _, _ := determineContext(t); hT == nil {
+		return names
+	}
+	checked := make([]string, 0, len(names))
+	for _, name := range names {
+		if list[name].hostContext {
+			checked = append(checked, name)
+		}
+	}
+	return checked
+}
+
+// checkInstalledFeature checks the feature named 'name' on the target, and adds it again if it drifted and 'remediate' is true
+func checkInstalledFeature(task concurrency.Task, t Target, name string, remediate bool) *FeatureStatus {
+	status := &FeatureStatus{Feature: name, CheckedAt: time.Now()}
+	feature, err := NewFeature(task, name)
+	if err == nil && feature.specs == nil {
+		err = scerr.NotFoundError(fmt.Sprintf("failed to find a feature named '%s'", name))
+	}
+	if err != nil {
+		status.Status = CheckStatusFailed
+		status.Message = err.Error()
+		return status
+	}
+
+	results, err := feature.Check(t, Variables{}, Settings{})
+	if err != nil {
+		status.Status = CheckStatusFailed
+		status.Message = err.Error()
+		return status
+	}
+	if results.Successful() {
+		status.Status = CheckStatusOK
+		return status
+	}
+	status.Status = CheckStatusDrifted
+	status.Message = strings.TrimSpace(results.AllErrorMessages())
+	if !remediate {
+		return status
+	}
+
+	logrus.Infof("Adding again feature '%s' on %s '%s'...", name, t.Type(), t.Name())
+	results, err = feature.Add(t, Variables{}, Settings{AddUnconditionally: true})
+	if err == nil && !results.Successful() {
+		err = fmt.Errorf("%s", strings.TrimSpace(results.AllErrorMessages()))
+	}
+	if err != nil {
+		status.Message = fmt.Sprintf("%s; remediation failed: %s", status.Message, err.Error())
+		return status
+	}
+	status.Status = CheckStatusRemediated
+	status.RemediatedAt = time.Now()
+	return status
+}
+
+// recordCheck records the result of the check of a feature in the metadata of the target
+func recordCheck(task concurrency.Task, t Target, status *FeatureStatus) error {
+	hT, cT, nT := determineContext(t)
+	if cT != nil {
+		return updateClusterFeatures(task, cT.cluster, func(featuresV1 *clusterpropsv1.Features) error {
+			if installed, ok := featuresV1.Installed[status.Feature]; ok {
+				installed.CheckedAt = status.CheckedAt
+				installed.CheckStatus = status.Status
+				installed.CheckMessage = status.Message
+				installed.RemediatedAt = status.RemediatedAt
+			}
+			return nil
+		})
+	}
+
+	var host *pb.Host
+	if nT != nil {
+		host = nT.host
+	}
+	if hT != nil {
+		host = hT.host
+	}
+	return updateHostFeatures(host, func(featuresV1 *propsv1.HostFeatures) error {
+		if installed, ok := featuresV1.Installed[status.Feature]; ok {
+			installed.CheckedAt = status.CheckedAt
+			installed.CheckStatus = status.Status
+			installed.CheckMessage = status.Message
+			installed.RemediatedAt = status.RemediatedAt
+		}
+		return nil
+	})
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"strings"
+	"sync"
+
+	pb "github.com/CS-SI/SafeScale/lib"
+)
+
+// maskedValue replaces the value of secret variables in dry run reports
+const maskedValue = "********"
+
+// secretMarkers are the substrings identifying the name of a variable containing a secret
+var secretMarkers = []string{"password", "secret", "token", "passphrase", "privatekey"}
+
+// DryRunReport collects, in the order of execution, what an action would run on which hosts, without
+// running anything.
+// When set in Settings, the steps of the action (and of the actions on the required features) are rendered
+// and recorded instead of being executed.
+type DryRunReport struct {
+	lock     sync.Mutex
+	Features []*DryRunFeature `json:"features"`
+}
+
+// DryRunFeature describes what an action would do for a feature
+type DryRunFeature struct {
+	Feature string `json:"feature"`
+	Action  string `json:"action"`
+	Method  string `json:"method"`
+	Target  string `json:"target"`
+	// Pace is the order of execution of the steps, as defined in the specification file
+	Pace  []string      `json:"pace,omitempty"`
+	Steps []*DryRunStep `json:"steps"`
+}
+
+// DryRunStep describes a step and the scripts it would run
+type DryRunStep struct {
+	Name       string `json:"name"`
+	Serialized bool   `json:"serialized,omitempty"`
+	// Hosts contains the scripts in the order the hosts would be processed
+	Hosts []DryRunScript `json:"hosts"`
+}
+
+// DryRunScript contains the rendered script for a host, secrets being masked
+type DryRunScript struct {
+	Host   string `json:"host"`
+	Script string `json:"script"`
+}
+
+// NewDryRunReport creates an empty DryRunReport
+func NewDryRunReport() *DryRunReport {
+	return &DryRunReport{Features: []*DryRunFeature{}}
+}
+
+// newFeature registers the action of a feature and returns the entry to fill
+func (r *DryRunReport) newFeature(w *worker, pace []string) *DryRunFeature {
+	entry := &DryRunFeature{
+		Feature: w.feature.DisplayName(),
+		Action:  strings.ToLower(w.action.String()),
+		Method:  strings.ToLower(w.method.String()),
+		Target:  w.target.Type() + " '" + w.target.Name() + "'",
+		Pace:    pace,
+		Steps:   []*DryRunStep{},
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Features = append(r.Features, entry)
+	return entry
+}
+
+// addStep records a step of a feature
+func (r *DryRunReport) addStep(entry *DryRunFeature, step *DryRunStep) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	entry.Steps = append(entry.Steps, step)
+}
+
+// dryRun renders the script of the step for each host, as Run would do, without executing it
+func (is *step) dryRun(hosts []*pb.Host, v Variables) (*DryRunStep, error) {
+	result := &DryRunStep{
+		Name:       is.Name,
+		Serialized: is.Serial || is.Worker.settings.Serialize,
+		Hosts:      []DryRunScript{},
+	}
+	for _, h := range hosts {
+		cloneV := v.Clone()
+		cloneV["HostIP"] = h.PrivateIp
+		cloneV["Hostname"] = h.Name
+		cloneV, err := realizeVariables(cloneV)
+		if err != nil {
+			return nil, err
+		}
+		script, err := replaceVariablesInString(is.Script, cloneV)
+		if err != nil {
+			return nil, err
+		}
+		result.Hosts = append(result.Hosts, DryRunScript{Host: h.Name, Script: script})
+	}
+	return result, nil
+}
+
+// isSecretVariable tells if the name of a variable denotes a secret
+func isSecretVariable(name string) bool {
+	lowered := strings.ToLower(name)
+	for _, m := range secretMarkers {
+		if strings.Contains(lowered, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskSecrets returns a copy of the variables where the values of secrets, parameters declared as secret or
+// variables named as such, are masked
+func maskSecrets(v Variables) Variables {
+	masked := v.Clone()
+	for k, value := range masked {
+		switch value := value.(type) {
+		case secretValue:
+			if value != "" {
+				masked[k] = secretValue(maskedValue)
+			}
+		case string:
+			if value != "" && isSecretVariable(k) {
+				masked[k] = maskedValue
+			}
+		}
+	}
+	return masked
+}
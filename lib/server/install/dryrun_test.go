@@ -0,0 +1,57 @@
+package install
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/CS-SI/SafeScale/lib"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	v := Variables{
+		"Username":             "safescale",
+		"Password":             "p4ssw0rd",
+		"ClusterAdminPassword": "s3cr3t",
+		"APIToken":             "abcdef",
+		"EmptySecret":          "",
+		"Port":                 8080,
+		"Credentials":          secretValue("s3cr3t"),
+	}
+	masked := maskSecrets(v)
+
+	assert.Equal(t, "safescale", masked["Username"])
+	assert.Equal(t, maskedValue, masked["Password"])
+	assert.Equal(t, maskedValue, masked["ClusterAdminPassword"])
+	assert.Equal(t, maskedValue, masked["APIToken"])
+	assert.Equal(t, "", masked["EmptySecret"])
+	assert.Equal(t, 8080, masked["Port"])
+	assert.Equal(t, secretValue(maskedValue), masked["Credentials"])
+	// the original variables are untouched
+	assert.Equal(t, "p4ssw0rd", v["Password"])
+}
+
+func TestStepDryRun(t *testing.T) {
+	is := step{
+		Worker: &worker{},
+		Name:   "install",
+		Script: "echo {{ .Hostname }} {{ .HostIP }} {{ .Login }}",
+		Serial: true,
+	}
+	hosts := []*pb.Host{
+		{Name: "host-1", PrivateIp: "10.0.0.1"},
+		{Name: "host-2", PrivateIp: "10.0.0.2"},
+	}
+	v := maskSecrets(Variables{"Username": "admin", "Password": "p4ssw0rd", "Login": "{{ .Username }}:{{ .Password }}"})
+
+	rendered, err := is.dryRun(hosts, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "install", rendered.Name)
+	assert.True(t, rendered.Serialized)
+	if assert.Len(t, rendered.Hosts, 2) {
+		assert.Equal(t, DryRunScript{Host: "host-1", Script: "echo host-1 10.0.0.1 admin:" + maskedValue}, rendered.Hosts[0])
+		assert.Equal(t, DryRunScript{Host: "host-2", Script: "echo host-2 10.0.0.2 admin:" + maskedValue}, rendered.Hosts[1])
+	}
+}
@@ -32,6 +32,8 @@ const (
 	Add
 	// Remove ...
 	Remove
+	// Upgrade ...
+	Upgrade
 
 	// NextEnum marks the next value (or the max, depending the use)
 	NextEnum
@@ -39,15 +41,17 @@ const (
 
 var (
 	stringMap = map[string]Enum{
-		"check":  Check,
-		"add":    Add,
-		"remove": Remove,
+		"check":   Check,
+		"add":     Add,
+		"remove":  Remove,
+		"upgrade": Upgrade,
 	}
 
 	enumMap = map[Enum]string{
-		Check:  "Check",
-		Add:    "Add",
-		Remove: "Remove",
+		Check:   "Check",
+		Add:     "Add",
+		Remove:  "Remove",
+		Upgrade: "Upgrade",
 	}
 )
 
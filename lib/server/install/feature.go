@@ -28,6 +28,7 @@ import (
 	pb "github.com/CS-SI/SafeScale/lib"
 	clusterpropsv1 "github.com/CS-SI/SafeScale/lib/server/cluster/control/properties/v1"
 	"github.com/CS-SI/SafeScale/lib/server/cluster/enums/property"
+	"github.com/CS-SI/SafeScale/lib/server/install/enums/action"
 	"github.com/CS-SI/SafeScale/lib/server/install/enums/method"
 	"github.com/CS-SI/SafeScale/lib/utils"
 	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
@@ -66,6 +67,11 @@ type Settings struct {
 	SkipSizingRequirements bool
 	// AddUnconditionally tells to not check before addition (no effect for check or removal)
 	AddUnconditionally bool
+	// RemoveDependents tells to remove first the installed features requiring the feature to remove; without it,
+	// the removal fails if there are such features (no effect for check, addition or upgrade)
+	RemoveDependents bool
+	// DryRun, if set, receives the rendered scripts of the steps instead of running them on the hosts
+	DryRun *DryRunReport
 }
 
 // Feature contains the information about an installable feature
@@ -240,10 +246,10 @@ func (f *Feature) installerOfMethod(m method.Enum) Installer {
 		installer = NewDnfInstaller()
 	case method.DCOS:
 		installer = NewDcosInstaller()
-		//	case method.Ansible:
-		//		installer = NewAnsibleInstaller()
-		//	case method.Helm:
-		//		installer = NewHelmInstaller()
+	case method.Ansible:
+		installer = NewAnsibleInstaller()
+	case method.Helm:
+		installer = NewHelmInstaller()
 	}
 	return installer
 }
@@ -268,6 +274,16 @@ func (f *Feature) DisplayFilename() string {
 	return filename
 }
 
+// Version returns the version declared in the specification file (empty string if not declared)
+func (f *Feature) Version() string {
+	return strings.TrimSpace(f.specs.GetString("feature.version"))
+}
+
+// requirements returns the names of the features required by this one
+func (f *Feature) requirements() []string {
+	return f.specs.GetStringSlice("feature.requirements.features")
+}
+
 // Specs returns a copy of the spec file (we don't want external use to modify Feature.specs)
 func (f *Feature) Specs() *viper.Viper {
 	roSpecs := *f.specs
@@ -330,15 +346,15 @@ func (f *Feature) Check(t Target, v Variables, s Settings) (_ Results, err error
 		return nil, err
 	}
 
-	// Checks required parameters have value
-	err = checkParameters(f, myV)
+	// Checks the values of the parameters and resolves the secrets
+	err = f.checkParameters(t, myV, action.Check, s)
 	if err != nil {
 		return nil, err
 	}
 
 	results, err := installer.Check(f, t, myV, s)
 	// _ = checkCache.ForceSet(cacheKey, results)
-	return results, err
+	return hideSecrets(results, err, myV)
 }
 
 // Add installs the feature on the target
@@ -387,13 +403,14 @@ func (f *Feature) Add(t Target, v Variables, s Settings) (_ Results, err error)
 		return nil, err
 	}
 
-	// Checks required parameters have value
-	err = checkParameters(f, myV)
+	// Checks the values of the parameters and resolves the secrets
+	err = f.checkParameters(t, myV, action.Add, s)
 	if err != nil {
 		return nil, err
 	}
 
-	if !s.AddUnconditionally {
+	// A dry run doesn't check the presence of the feature, to stay away from the hosts
+	if !s.AddUnconditionally && s.DryRun == nil {
 		results, err := f.Check(t, v, s)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check feature '%s': %s", f.DisplayName(), err.Error())
@@ -411,12 +428,14 @@ func (f *Feature) Add(t Target, v Variables, s Settings) (_ Results, err error)
 		}
 	}
 	results, err := installer.Add(f, t, myV, s)
-	if err == nil {
-		// _ = checkCache.ForceSet(f.DisplayName()+"@"+t.Name(), results)
-		return nil, err
+	// _ = checkCache.ForceSet(f.DisplayName()+"@"+t.Name(), results)
+	if err == nil && s.DryRun == nil && results.Successful() {
+		// The feature is installed, failing to keep track of it doesn't change that
+		if rerr := f.registerInstallation(t); rerr != nil {
+			logrus.Warnf("failed to record installation of feature '%s' on %s '%s': %v", f.DisplayName(), t.Type(), t.Name(), rerr)
+		}
 	}
-
-	return results, err
+	return hideSecrets(results, err, myV)
 }
 
 // Remove uninstalls the feature from the target
@@ -463,21 +482,156 @@ func (f *Feature) Remove(t Target, v Variables, s Settings) (_ Results, err erro
 		return nil, err
 	}
 
-	// Checks required parameters have value
-	err = checkParameters(f, myV)
+	// Checks the values of the parameters and resolves the secrets
+	err = f.checkParameters(t, myV, action.Remove, s)
+	if err != nil {
+		return nil, err
+	}
+
+	// Features requiring this one would be broken by its removal
+	err = f.removeDependents(t, v, s)
 	if err != nil {
 		return nil, err
 	}
 
 	results, err = installer.Remove(f, t, myV, s)
 	// checkCache.Reset(f.DisplayName() + "@" + t.Name())
-	return results, err
+	if err == nil && results.Successful() {
+		if rerr := f.unregisterInstallation(t); rerr != nil {
+			logrus.Warnf("failed to record removal of feature '%s' from %s '%s': %v", f.DisplayName(), t.Type(), t.Name(), rerr)
+		}
+		if rerr := f.deleteSecrets(t); rerr != nil {
+			logrus.Warnf("failed to delete secrets of feature '%s' on %s '%s': %v", f.DisplayName(), t.Type(), t.Name(), rerr)
+		}
+	}
+	return hideSecrets(results, err, myV)
+}
+
+// Upgrade upgrades the feature installed on the target to the version of the specification, running the action
+// 'upgrade' of the install method, without removing the feature first.
+// The installed version has to satisfy the constraint 'from' of the action, and the version of the specification
+// the constraint 'to', if they are defined
+func (f *Feature) Upgrade(t Target, v Variables, s Settings) (_ Results, err error) {
+	if f == nil {
+		return nil, scerr.InvalidInstanceError()
+	}
+
+	tracer := concurrency.NewTracer(f.task, fmt.Sprintf("(): '%s' on %s '%s'", f.DisplayName(), t.Type(), t.Name()), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	var (
+		installer Installer
+		meth      method.Enum
+	)
+	for _, m := range t.Methods() {
+		if f.specs.IsSet(fmt.Sprintf("feature.install.%s", strings.ToLower(m.String()))) {
+			installer = f.installerOfMethod(m)
+			if installer != nil {
+				meth = m
+				break
+			}
+		}
+	}
+	if installer == nil {
+		return nil, fmt.Errorf("failed to find a way to upgrade '%s'", f.DisplayName())
+	}
+	// Helm upgrades releases in place, the action 'upgrade' is only needed to define constraints
+	upgradeKey := fmt.Sprintf("feature.install.%s.%s", strings.ToLower(meth.String()), strings.ToLower(action.Upgrade.String()))
+	if meth != method.Helm && !f.specs.IsSet(upgradeKey) {
+		return nil, fmt.Errorf("feature '%s' doesn't define how to upgrade it (no key '%s'), it has to be removed then added again", f.DisplayName(), upgradeKey)
+	}
+
+	installed, found, err := f.installedVersion(t)
+	if err != nil {
+		return nil, err
+	}
+	if !found && s.DryRun == nil {
+		// the feature may have been installed before versions were recorded
+		results, err := f.Check(t, v, s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check feature '%s': %s", f.DisplayName(), err.Error())
+		}
+		if !results.Successful() {
+			return nil, scerr.NotFoundError(fmt.Sprintf("feature '%s' is not installed on %s '%s'", f.DisplayName(), t.Type(), t.Name()))
+		}
+	}
+
+	version := f.Version()
+	if installed != "" && version != "" {
+		switch compareVersions(installed, version) {
+		case 0:
+			logrus.Infof("Feature '%s' is already in version '%s' on %s '%s'.", f.DisplayName(), version, t.Type(), t.Name())
+			return Results{}, nil
+		case 1:
+			return nil, fmt.Errorf("version '%s' of feature '%s' installed on %s '%s' is more recent than version '%s', downgrade is not supported", installed, f.DisplayName(), t.Type(), t.Name(), version)
+		}
+	}
+	if from := strings.TrimSpace(f.specs.GetString(upgradeKey + "." + yamlFromKeyword)); from != "" {
+		if installed == "" {
+			return nil, fmt.Errorf("the installed version of feature '%s' is unknown, it cannot be checked against '%s.%s'", f.DisplayName(), upgradeKey, yamlFromKeyword)
+		}
+		ok, err := matchVersion(from, installed)
+		if err != nil {
+			return nil, scerr.SyntaxError(fmt.Sprintf("invalid '%s.%s' in feature '%s': %s", upgradeKey, yamlFromKeyword, f.DisplayName(), err.Error()))
+		}
+		if !ok {
+			return nil, fmt.Errorf("feature '%s' cannot be upgraded from version '%s' (requires '%s'), it has to be removed then added again", f.DisplayName(), installed, from)
+		}
+	}
+	if to := strings.TrimSpace(f.specs.GetString(upgradeKey + "." + yamlToKeyword)); to != "" {
+		ok, err := matchVersion(to, version)
+		if err != nil {
+			return nil, scerr.SyntaxError(fmt.Sprintf("invalid '%s.%s' in feature '%s': %s", upgradeKey, yamlToKeyword, f.DisplayName(), err.Error()))
+		}
+		if !ok {
+			return nil, fmt.Errorf("the upgrade defined by feature '%s' leads to '%s', not to version '%s' of the specification", f.DisplayName(), to, version)
+		}
+	}
+
+	defer temporal.NewStopwatch().OnExitLogInfo(
+		fmt.Sprintf("Starting upgrade of feature '%s' on %s '%s'...", f.DisplayName(), t.Type(), t.Name()),
+		fmt.Sprintf("Ending upgrade of feature '%s' on %s '%s'", f.DisplayName(), t.Type(), t.Name()),
+	)()
+
+	// 'v' may be updated by parallel tasks, so use copy of it
+	myV := make(Variables)
+	for key, value := range v {
+		myV[key] = value
+	}
+
+	// Inits implicit parameters
+	err = f.setImplicitParameters(t, myV)
+	if err != nil {
+		return nil, err
+	}
+	myV["InstalledVersion"] = installed
+
+	// Checks the values of the parameters and resolves the secrets
+	err = f.checkParameters(t, myV, action.Upgrade, s)
+	if err != nil {
+		return nil, err
+	}
+
+	// The new version may require features the installed one didn't
+	if !s.SkipFeatureRequirements {
+		err := f.installRequirements(t, v, s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to install requirements: %s", err.Error())
+		}
+	}
+	results, err := installer.Upgrade(f, t, myV, s)
+	if err == nil && s.DryRun == nil && results.Successful() {
+		if rerr := f.registerInstallation(t); rerr != nil {
+			logrus.Warnf("failed to record upgrade of feature '%s' on %s '%s': %v", f.DisplayName(), t.Type(), t.Name(), rerr)
+		}
+	}
+	return hideSecrets(results, err, myV)
 }
 
 // installRequirements walks through requirements and installs them if needed
 func (f *Feature) installRequirements(t Target, v Variables, s Settings) error {
-	yamlKey := "feature.requirements.features"
-	if f.specs.IsSet(yamlKey) {
+	if requirements := f.requirements(); len(requirements) > 0 {
 		{
 			hostInstance, clusterInstance, nodeInstance := determineContext(t)
 			msgHead := fmt.Sprintf("Checking requirements of feature '%s'", f.DisplayName())
@@ -493,16 +647,19 @@ func (f *Feature) installRequirements(t Target, v Variables, s Settings) error {
 			}
 			logrus.Debugf("%s %s...\n", msgHead, msgTail)
 		}
-		for _, requirement := range f.specs.GetStringSlice(yamlKey) {
+		for _, requirement := range requirements {
 			needed, err := NewFeature(f.task, requirement)
 			if err != nil {
 				return fmt.Errorf("failed to find required feature '%s': %s", requirement, err.Error())
 			}
-			results, err := needed.Check(t, v, s)
-			if err != nil {
-				return fmt.Errorf("failed to check required feature '%s' for feature '%s': %s", requirement, f.DisplayName(), err.Error())
+			results := Results{}
+			if s.DryRun == nil {
+				results, err = needed.Check(t, v, s)
+				if err != nil {
+					return fmt.Errorf("failed to check required feature '%s' for feature '%s': %s", requirement, f.DisplayName(), err.Error())
+				}
 			}
-			if !results.Successful() {
+			if s.DryRun != nil || !results.Successful() {
 				results, err := needed.Add(t, v, s)
 				if err != nil {
 					return fmt.Errorf("failed to install required feature '%s': %s", requirement, err.Error())
@@ -519,6 +676,7 @@ func (f *Feature) installRequirements(t Target, v Variables, s Settings) error {
 // setImplicitParameters configures parameters that are implicitly defined, based on target
 func (f *Feature) setImplicitParameters(t Target, v Variables) error {
 	hT, cT, nT := determineContext(t)
+	v["FeatureVersion"] = f.Version()
 	if cT != nil {
 		cluster := cT.cluster
 		identity := cluster.GetIdentity(f.task)
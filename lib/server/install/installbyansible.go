@@ -0,0 +1,266 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	log "github.com/sirupsen/logrus"
+
+	pb "github.com/CS-SI/SafeScale/lib"
+	"github.com/CS-SI/SafeScale/lib/client"
+	"github.com/CS-SI/SafeScale/lib/server/install/enums/action"
+	"github.com/CS-SI/SafeScale/lib/server/install/enums/method"
+	"github.com/CS-SI/SafeScale/lib/utils"
+	"github.com/CS-SI/SafeScale/lib/utils/cli/enums/outputs"
+	"github.com/CS-SI/SafeScale/lib/utils/temporal"
+)
+
+const (
+	yamlPlaybookKeyword = "playbook"
+	yamlRolesKeyword    = "roles"
+	yamlGalaxyKeyword   = "galaxy"
+
+	// ansibleStepScriptContent is the bash script run on the Ansible controller for each step.
+	// Everything coming from the feature specification is base64-encoded, so Ansible/Jinja2 templating
+	// doesn't collide with the templating of the step script.
+	ansibleStepScriptContent = `
+ANSIBLE_DIR={{ .Dir }}
+rm -rf ${ANSIBLE_DIR}
+mkdir -p ${ANSIBLE_DIR}/roles
+echo '{{ .Inventory }}' | base64 -d >${ANSIBLE_DIR}/inventory
+echo '{{ .Playbook }}' | base64 -d >${ANSIBLE_DIR}/playbook.yml
+echo '{{ .ExtraVars }}' | base64 -d >${ANSIBLE_DIR}/vars.json
+{{- if .Galaxy }}
+echo '{{ .Galaxy }}' | base64 -d >${ANSIBLE_DIR}/requirements.yml
+sfRetry 5m 3 "ansible-galaxy install -r ${ANSIBLE_DIR}/requirements.yml -p ${ANSIBLE_DIR}/roles" || sfFail 190
+{{- end }}
+chmod -R go-rwx ${ANSIBLE_DIR}
+{{- if .User }}
+chown -R {{ .User }} ${ANSIBLE_DIR}
+{{- end }}
+
+cd ${ANSIBLE_DIR}
+{{ if .User }}sudo -u {{ .User }} -H {{ end }}env ANSIBLE_HOST_KEY_CHECKING=False ANSIBLE_RETRY_FILES_ENABLED=False ANSIBLE_ROLES_PATH=${ANSIBLE_DIR}/roles:/etc/ansible/roles \
+    ansible-playbook -i ${ANSIBLE_DIR}/inventory -e @${ANSIBLE_DIR}/vars.json ${ANSIBLE_DIR}/playbook.yml
+rc=$?
+rm -f ${ANSIBLE_DIR}/vars.json
+[ $rc -ne 0 ] && sfFail $rc
+sfExit
+`
+)
+
+// ansibleInstaller is an installer using Ansible playbooks or roles to add and remove a feature
+type ansibleInstaller struct{}
+
+func (i *ansibleInstaller) GetName() string {
+	return "ansible"
+}
+
+// Check checks if the feature is installed, running the playbooks of the check steps
+func (i *ansibleInstaller) Check(f *Feature, t Target, v Variables, s Settings) (Results, error) {
+	return i.proceed(f, t, action.Check, v, s)
+}
+
+// Add installs the feature running the playbooks of the add steps
+func (i *ansibleInstaller) Add(f *Feature, t Target, v Variables, s Settings) (Results, error) {
+	return i.proceed(f, t, action.Add, v, s)
+}
+
+// Remove uninstalls the feature running the playbooks of the remove steps
+func (i *ansibleInstaller) Remove(f *Feature, t Target, v Variables, s Settings) (Results, error) {
+	return i.proceed(f, t, action.Remove, v, s)
+}
+
+// Upgrade upgrades the feature running the playbooks of the upgrade steps
+func (i *ansibleInstaller) Upgrade(f *Feature, t Target, v Variables, s Settings) (Results, error) {
+	return i.proceed(f, t, action.Upgrade, v, s)
+}
+
+func (i *ansibleInstaller) proceed(f *Feature, t Target, a action.Enum, v Variables, s Settings) (Results, error) {
+	worker, err := newWorker(f, t, method.Ansible, a, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = worker.CanProceed(s)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, err
+	}
+	return worker.Proceed(v, s)
+}
+
+// NewAnsibleInstaller creates a new instance of Installer using Ansible
+func NewAnsibleInstaller() Installer {
+	return &ansibleInstaller{}
+}
+
+// ansiblePlaybookInstalled tells if ansible-playbook is available on the host
+func ansiblePlaybookInstalled(host *pb.Host) (bool, error) {
+	retcode, _, _, err := client.New().SSH.Run(host.Name, "command -v ansible-playbook", outputs.COLLECT, temporal.GetConnectionTimeout(), temporal.GetExecutionTimeout())
+	if err != nil {
+		return false, err
+	}
+	return retcode == 0, nil
+}
+
+// ansibleStepScript builds the script running the playbook of a step from the Ansible controller,
+// against an inventory made of the hosts selected by the 'targets' of the step
+func (w *worker) ansibleStepScript(stepName, stepKey string, stepMap map[string]interface{}, hosts []*pb.Host, controller *pb.Host, v Variables) (string, error) {
+	playbook, err := w.ansiblePlaybook(stepKey, stepMap)
+	if err != nil {
+		return "", err
+	}
+
+	realizedV, err := realizeVariables(v)
+	if err != nil {
+		return "", err
+	}
+	extraVars, err := json.Marshal(realizedV)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert variables of step '%s' to Ansible extra vars: %s", stepName, err.Error())
+	}
+
+	user := ""
+	if w.cluster != nil {
+		if anon, ok := v["ClusterAdminUsername"].(string); ok {
+			user = anon
+		}
+	}
+
+	galaxy := ""
+	if anon, ok := stepMap[yamlGalaxyKeyword]; ok {
+		content, ok := anon.(string)
+		if !ok {
+			msg := `syntax error in feature '%s' specification file (%s): '%s.%s' must be a string`
+			return "", fmt.Errorf(msg, w.feature.DisplayName(), w.feature.DisplayFilename(), stepKey, yamlGalaxyKeyword)
+		}
+		galaxy = base64.StdEncoding.EncodeToString([]byte(content))
+	}
+
+	tmpl, err := template.New("ansible_step").Parse(ansibleStepScriptContent)
+	if err != nil {
+		return "", fmt.Errorf("error parsing Ansible step template: %s", err.Error())
+	}
+	dataBuffer := bytes.NewBufferString("")
+	err = tmpl.Execute(dataBuffer, map[string]interface{}{
+		"Dir":       fmt.Sprintf("%s/ansible/%s.%s_%s", utils.TempFolder, w.feature.DisplayName(), strings.ToLower(w.action.String()), stepName),
+		"Inventory": base64.StdEncoding.EncodeToString([]byte(w.ansibleInventory(hosts, controller, user))),
+		"Playbook":  base64.StdEncoding.EncodeToString([]byte(playbook)),
+		"ExtraVars": base64.StdEncoding.EncodeToString(extraVars),
+		"Galaxy":    galaxy,
+		"User":      user,
+	})
+	if err != nil {
+		return "", err
+	}
+	return dataBuffer.String(), nil
+}
+
+// ansiblePlaybook returns the playbook of the step, either given as is with key 'playbook',
+// or built from the list of roles given with key 'roles'
+func (w *worker) ansiblePlaybook(stepKey string, stepMap map[string]interface{}) (string, error) {
+	if anon, ok := stepMap[yamlPlaybookKeyword]; ok {
+		if playbook, ok := anon.(string); ok && strings.TrimSpace(playbook) != "" {
+			return playbook, nil
+		}
+		msg := `syntax error in feature '%s' specification file (%s): '%s.%s' must be a non-empty string`
+		return "", fmt.Errorf(msg, w.feature.DisplayName(), w.feature.DisplayFilename(), stepKey, yamlPlaybookKeyword)
+	}
+
+	if anon, ok := stepMap[yamlRolesKeyword]; ok {
+		roles, ok := anon.([]interface{})
+		if ok && len(roles) > 0 {
+			playbook := "---\n- hosts: all\n  become: yes\n  roles:\n"
+			for _, r := range roles {
+				role, ok := r.(string)
+				if !ok || role == "" {
+					msg := `syntax error in feature '%s' specification file (%s): '%s.%s' must be a list of role names`
+					return "", fmt.Errorf(msg, w.feature.DisplayName(), w.feature.DisplayFilename(), stepKey, yamlRolesKeyword)
+				}
+				playbook += "    - " + role + "\n"
+			}
+			return playbook, nil
+		}
+		msg := `syntax error in feature '%s' specification file (%s): '%s.%s' must be a non-empty list`
+		return "", fmt.Errorf(msg, w.feature.DisplayName(), w.feature.DisplayFilename(), stepKey, yamlRolesKeyword)
+	}
+
+	msg := `syntax error in feature '%s' specification file (%s): no key '%s.%s' or '%s.%s' found`
+	return "", fmt.Errorf(msg, w.feature.DisplayName(), w.feature.DisplayFilename(), stepKey, yamlPlaybookKeyword, stepKey, yamlRolesKeyword)
+}
+
+// ansibleInventory generates the inventory (INI format) of the hosts, grouped by role in the cluster
+// ('masters', 'nodes', 'gateways'), or in group 'hosts' outside of a cluster.
+// The controller is reached with a local connection, other hosts through SSH with the cluster admin user.
+func (w *worker) ansibleInventory(hosts []*pb.Host, controller *pb.Host, user string) string {
+	groups := map[string][]string{}
+	masterIDs := map[string]bool{}
+	nodeIDs := map[string]bool{}
+	if w.cluster != nil {
+		for _, id := range w.cluster.ListMasterIDs(w.feature.task) {
+			masterIDs[id] = true
+		}
+		for _, id := range w.cluster.ListNodeIDs(w.feature.task) {
+			nodeIDs[id] = true
+		}
+	}
+
+	for _, h := range hosts {
+		group := targetHosts
+		if w.cluster != nil {
+			switch {
+			case masterIDs[h.Id]:
+				group = targetMasters
+			case nodeIDs[h.Id]:
+				group = targetNodes
+			default:
+				group = targetGateways
+			}
+		}
+		line := h.Name
+		if controller != nil && h.Id == controller.Id {
+			line += " ansible_connection=local"
+		} else {
+			line += " ansible_host=" + h.PrivateIp
+		}
+		groups[group] = append(groups[group], line)
+	}
+
+	var names []string
+	for k := range groups {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	inventory := ""
+	for _, g := range names {
+		inventory += "[" + g + "]\n" + strings.Join(groups[g], "\n") + "\n\n"
+	}
+	inventory += "[all:vars]\nansible_become=true\n"
+	if user != "" {
+		inventory += fmt.Sprintf("ansible_user=%s\nansible_ssh_private_key_file=~%s/.ssh/id_rsa\n", user, user)
+		inventory += "ansible_ssh_common_args='-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null'\n"
+	}
+	return inventory
+}
@@ -0,0 +1,112 @@
+package install
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/CS-SI/SafeScale/lib"
+	clusterapi "github.com/CS-SI/SafeScale/lib/server/cluster/api"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+)
+
+// inventoryCluster is a cluster knowing only its masters and nodes, enough to build an inventory
+type inventoryCluster struct {
+	clusterapi.Cluster
+	masters []string
+	nodes   []string
+}
+
+func (c *inventoryCluster) ListMasterIDs(concurrency.Task) []string {
+	return c.masters
+}
+
+func (c *inventoryCluster) ListNodeIDs(concurrency.Task) []string {
+	return c.nodes
+}
+
+func TestAnsibleInventory(t *testing.T) {
+	feature := &Feature{displayName: "test", fileName: "test.yml", task: concurrency.RootTask()}
+	host := &pb.Host{Id: "h1", Name: "host1", PrivateIp: "10.0.0.1"}
+	master := &pb.Host{Id: "m1", Name: "cl-master-1", PrivateIp: "10.0.0.11"}
+	node1 := &pb.Host{Id: "n1", Name: "cl-node-1", PrivateIp: "10.0.0.21"}
+	node2 := &pb.Host{Id: "n2", Name: "cl-node-2", PrivateIp: "10.0.0.22"}
+	gateway := &pb.Host{Id: "g1", Name: "gw-cl", PrivateIp: "10.0.0.254"}
+	cluster := &inventoryCluster{masters: []string{"m1"}, nodes: []string{"n1", "n2"}}
+
+	cases := []struct {
+		name       string
+		cluster    clusterapi.Cluster
+		hosts      []*pb.Host
+		controller *pb.Host
+		user       string
+		expected   string
+	}{
+		{
+			name:       "single host",
+			hosts:      []*pb.Host{host},
+			controller: host,
+			expected:   "[hosts]\nhost1 ansible_connection=local\n\n[all:vars]\nansible_become=true\n",
+		},
+		{
+			name:       "cluster",
+			cluster:    cluster,
+			hosts:      []*pb.Host{node2, master, gateway, node1},
+			controller: master,
+			user:       "cladm",
+			expected: "[gateways]\ngw-cl ansible_host=10.0.0.254\n\n" +
+				"[masters]\ncl-master-1 ansible_connection=local\n\n" +
+				"[nodes]\ncl-node-2 ansible_host=10.0.0.22\ncl-node-1 ansible_host=10.0.0.21\n\n" +
+				"[all:vars]\nansible_become=true\nansible_user=cladm\nansible_ssh_private_key_file=~cladm/.ssh/id_rsa\n" +
+				"ansible_ssh_common_args='-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null'\n",
+		},
+		{
+			name:       "cluster nodes only",
+			cluster:    cluster,
+			hosts:      []*pb.Host{node1},
+			controller: master,
+			user:       "cladm",
+			expected: "[nodes]\ncl-node-1 ansible_host=10.0.0.21\n\n" +
+				"[all:vars]\nansible_become=true\nansible_user=cladm\nansible_ssh_private_key_file=~cladm/.ssh/id_rsa\n" +
+				"ansible_ssh_common_args='-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null'\n",
+		},
+	}
+	for _, c := range cases {
+		w := &worker{feature: feature, cluster: c.cluster}
+		assert.Equal(t, c.expected, w.ansibleInventory(c.hosts, c.controller, c.user), c.name)
+	}
+}
+
+func TestAnsiblePlaybook(t *testing.T) {
+	w := &worker{feature: &Feature{displayName: "test", fileName: "test.yml", task: concurrency.RootTask()}}
+	cases := []struct {
+		name     string
+		step     map[string]interface{}
+		expected string
+		fails    bool
+	}{
+		{
+			name:     "playbook",
+			step:     map[string]interface{}{"playbook": "- hosts: all\n  tasks: []\n"},
+			expected: "- hosts: all\n  tasks: []\n",
+		},
+		{
+			name:     "roles",
+			step:     map[string]interface{}{"roles": []interface{}{"geerlingguy.docker", "common"}},
+			expected: "---\n- hosts: all\n  become: yes\n  roles:\n    - geerlingguy.docker\n    - common\n",
+		},
+		{name: "empty playbook", step: map[string]interface{}{"playbook": "  "}, fails: true},
+		{name: "empty roles", step: map[string]interface{}{"roles": []interface{}{}}, fails: true},
+		{name: "invalid role", step: map[string]interface{}{"roles": []interface{}{"common", 3}}, fails: true},
+		{name: "nothing", step: map[string]interface{}{"run": "true"}, fails: true},
+	}
+	for _, c := range cases {
+		playbook, err := w.ansiblePlaybook("feature.install.ansible.add.steps.s1", c.step)
+		if c.fails {
+			assert.Error(t, err, c.name)
+			continue
+		}
+		assert.NoError(t, err, c.name)
+		assert.Equal(t, c.expected, playbook, c.name)
+	}
+}
@@ -91,6 +91,31 @@ func (i *bashInstaller) Remove(f *Feature, t Target, v Variables, s Settings) (R
 	return worker.Proceed(v, s)
 }
 
+// Upgrade upgrades the feature using the upgrade script in Specs
+func (i *bashInstaller) Upgrade(f *Feature, t Target, v Variables, s Settings) (Results, error) {
+	if !f.specs.IsSet("feature.install.bash.upgrade") {
+		msg := `syntax error in feature '%s' specification file (%s):
+				no key 'feature.install.bash.upgrade' found`
+		return nil, fmt.Errorf(msg, f.DisplayName(), f.DisplayFilename())
+	}
+
+	worker, err := newWorker(f, t, method.Bash, action.Upgrade, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = worker.CanProceed(s)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, err
+	}
+	if !worker.ConcernsCluster() {
+		if _, ok := v["Username"]; !ok {
+			v["Username"] = "safescale"
+		}
+	}
+	return worker.Proceed(v, s)
+}
+
 // NewBashInstaller creates a new instance of Installer using script
 func NewBashInstaller() Installer {
 	return &bashInstaller{}
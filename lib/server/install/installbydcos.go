@@ -74,6 +74,24 @@ func (i *dcosInstaller) Remove(c *Feature, t Target, v Variables, s Settings) (R
 	return worker.Proceed(v, s)
 }
 
+// Upgrade upgrades the feature in a DCOS cluster
+func (i *dcosInstaller) Upgrade(c *Feature, t Target, v Variables, s Settings) (Results, error) {
+	worker, err := newWorker(c, t, method.DCOS, action.Upgrade, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = worker.CanProceed(s)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	// Replaces variables in normalized script
+	v["options"] = ""
+
+	return worker.Proceed(v, s)
+}
+
 // NewDcosInstaller creates a new instance of Installer using DCOS
 func NewDcosInstaller() Installer {
 	return &dcosInstaller{}
@@ -0,0 +1,427 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	pb "github.com/CS-SI/SafeScale/lib"
+	"github.com/CS-SI/SafeScale/lib/client"
+	"github.com/CS-SI/SafeScale/lib/server/install/enums/action"
+	"github.com/CS-SI/SafeScale/lib/server/install/enums/method"
+	"github.com/CS-SI/SafeScale/lib/utils"
+	"github.com/CS-SI/SafeScale/lib/utils/cli/enums/outputs"
+	"github.com/CS-SI/SafeScale/lib/utils/temporal"
+)
+
+const (
+	helmRootKey = "feature.install.helm"
+	// helmChecksumKey is the key of the value added to the release to keep track of the specification
+	// used for the last install or upgrade
+	helmChecksumKey = "safescaleChecksum"
+	// helmDeployed is the status of a release successfully deployed
+	helmDeployed = "DEPLOYED"
+)
+
+var (
+	helmStatusRegexp   = regexp.MustCompile(`(?m)^STATUS:\s*(\S+)`)
+	helmRevisionRegexp = regexp.MustCompile(`(?m)^(\d+)\s`)
+)
+
+// helmInstaller is an installer using Helm charts to add and remove a feature on a Kubernetes cluster
+type helmInstaller struct{}
+
+// helmRelease contains the specification of the release, with variables replaced
+type helmRelease struct {
+	Name      string
+	Chart     string
+	Version   string
+	Namespace string
+	RepoName  string
+	RepoURL   string
+	Values    string
+	Checksum  string
+	WallTime  time.Duration
+}
+
+func (i *helmInstaller) GetName() string {
+	return "helm"
+}
+
+// Check checks if the release of the feature is deployed, with the values currently specified
+func (i *helmInstaller) Check(f *Feature, t Target, v Variables, s Settings) (Results, error) {
+	w, master, release, err := i.prepare(f, t, action.Check, v, s)
+	if err != nil {
+		return nil, err
+	}
+
+	state, upToDate, err := i.status(master, release)
+	if err != nil {
+		return nil, err
+	}
+	result := stepResult{completed: true, output: fmt.Sprintf("release '%s' is %s", release.Name, state)}
+	switch {
+	case state == "":
+		result.err = fmt.Errorf("release '%s' not found", release.Name)
+	case state != helmDeployed:
+		result.err = fmt.Errorf("release '%s' is %s", release.Name, state)
+	case !upToDate:
+		result.err = fmt.Errorf("release '%s' is %s, but with an outdated specification", release.Name, state)
+	default:
+		result.success = true
+	}
+	log.Debugf("%s(%s): %s", w.action.String(), f.DisplayName(), result.output)
+	return Results{release.Name: StepResults{master.Name: result}}, nil
+}
+
+// Add installs the release of the feature, or upgrades it if it already exists
+func (i *helmInstaller) Add(f *Feature, t Target, v Variables, s Settings) (Results, error) {
+	return i.deploy(f, t, action.Add, v, s)
+}
+
+// Upgrade upgrades the release of the feature; Helm upgrades releases in place, the same way Add does
+func (i *helmInstaller) Upgrade(f *Feature, t Target, v Variables, s Settings) (Results, error) {
+	return i.deploy(f, t, action.Upgrade, v, s)
+}
+
+// deploy installs or upgrades the release of the feature with the current specification
+func (i *helmInstaller) deploy(f *Feature, t Target, a action.Enum, v Variables, s Settings) (Results, error) {
+	w, master, release, err := i.prepare(f, t, a, v, s)
+	if err != nil {
+		return nil, err
+	}
+	if s.DryRun != nil {
+		return i.dryRun(w, master, release)
+	}
+
+	if !s.SkipProxy {
+		err = w.setReverseProxy()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A release already deployed with the current specification is left as is, instead of creating a new revision
+	state, upToDate, err := i.status(master, release)
+	if err != nil {
+		return nil, err
+	}
+	if state == helmDeployed && upToDate {
+		output := fmt.Sprintf("release '%s' is %s with the current specification", release.Name, state)
+		if revision := i.revision(master, release); revision != "" {
+			output += fmt.Sprintf(" (revision %s)", revision)
+		}
+		log.Debugf("%s(%s): %s", w.action.String(), f.DisplayName(), output)
+		return Results{release.Name: StepResults{master.Name: stepResult{completed: true, success: true, output: output}}}, nil
+	}
+
+	if cmd := i.repoCommand(release); cmd != "" {
+		err = i.run(master, release.Name, cmd, temporal.GetExecutionTimeout())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	valuesFile, checksumFile := i.files(release)
+	if valuesFile != "" {
+		err = UploadStringToRemoteFile(release.Values, master, valuesFile, "cladm", "safescale", "ug+rw-x,o-rwx")
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = UploadStringToRemoteFile(fmt.Sprintf("%s: %s\n", helmChecksumKey, release.Checksum), master, checksumFile, "cladm", "safescale", "ug+rw-x,o-rwx")
+	if err != nil {
+		return nil, err
+	}
+
+	err = i.run(master, release.Name, i.upgradeCommand(release, valuesFile, checksumFile), release.WallTime)
+	if err != nil {
+		return Results{release.Name: StepResults{master.Name: stepResult{completed: true, err: err}}}, err
+	}
+
+	state, _, err = i.status(master, release)
+	if err != nil {
+		return nil, err
+	}
+	output := fmt.Sprintf("release '%s' is %s", release.Name, state)
+	if revision := i.revision(master, release); revision != "" {
+		output += fmt.Sprintf(" (revision %s)", revision)
+	}
+	result := stepResult{completed: true, success: state == helmDeployed, output: output}
+	if !result.success {
+		result.err = fmt.Errorf("release '%s' is %s", release.Name, state)
+		return Results{release.Name: StepResults{master.Name: result}}, result.err
+	}
+	return Results{release.Name: StepResults{master.Name: result}}, nil
+}
+
+// Remove deletes the release of the feature
+func (i *helmInstaller) Remove(f *Feature, t Target, v Variables, s Settings) (Results, error) {
+	_, master, release, err := i.prepare(f, t, action.Remove, v, s)
+	if err != nil {
+		return nil, err
+	}
+
+	err = i.run(master, release.Name, helmCommand("delete", "--purge", release.Name), release.WallTime)
+	if err != nil {
+		return Results{release.Name: StepResults{master.Name: stepResult{completed: true, err: err}}}, err
+	}
+	result := stepResult{completed: true, success: true, output: fmt.Sprintf("release '%s' deleted", release.Name)}
+	return Results{release.Name: StepResults{master.Name: result}}, nil
+}
+
+// prepare validates the context and returns the worker, the master used to run helm and the specification of the release
+func (i *helmInstaller) prepare(f *Feature, t Target, a action.Enum, v Variables, s Settings) (*worker, *pb.Host, *helmRelease, error) {
+	if !f.specs.IsSet(helmRootKey) {
+		msg := `syntax error in feature '%s' specification file (%s): no key '%s' found`
+		return nil, nil, nil, fmt.Errorf(msg, f.DisplayName(), f.DisplayFilename(), helmRootKey)
+	}
+	_, cT, _ := determineContext(t)
+	if cT == nil {
+		return nil, nil, nil, fmt.Errorf("feature '%s' uses Helm, and can only be installed on a Kubernetes cluster", f.DisplayName())
+	}
+
+	// values of a dry run are reported, secrets can't appear in them
+	if s.DryRun != nil {
+		v = maskSecrets(v)
+	}
+
+	w := &worker{
+		feature:   f,
+		target:    t,
+		method:    method.Helm,
+		action:    a,
+		cluster:   cT.cluster,
+		variables: v,
+		settings:  s,
+		rootKey:   helmRootKey,
+	}
+	err := w.CanProceed(s)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, nil, nil, err
+	}
+
+	release, err := i.release(f, v)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	master, err := w.identifyAvailableMaster()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return w, master, release, nil
+}
+
+// release builds the specification of the release from the feature, replacing variables
+func (i *helmInstaller) release(f *Feature, v Variables) (*helmRelease, error) {
+	realizedV, err := realizeVariables(v)
+	if err != nil {
+		return nil, err
+	}
+	get := func(key, defaultValue string) (string, error) {
+		value := strings.TrimSpace(f.specs.GetString(helmRootKey + "." + key))
+		if value == "" {
+			return defaultValue, nil
+		}
+		value, err := replaceVariablesInString(value, realizedV)
+		if err != nil {
+			return "", fmt.Errorf("invalid '%s.%s' in feature '%s': %s", helmRootKey, key, f.DisplayName(), err.Error())
+		}
+		return strings.TrimSpace(value), nil
+	}
+
+	release := helmRelease{WallTime: temporal.GetLongOperationTimeout()}
+	if release.Name, err = get("release", strings.Replace(f.DisplayName(), ".", "-", -1)); err != nil {
+		return nil, err
+	}
+	if release.Chart, err = get("chart", ""); err != nil {
+		return nil, err
+	}
+	if release.Chart == "" {
+		msg := `syntax error in feature '%s' specification file (%s): no key '%s.chart' found`
+		return nil, fmt.Errorf(msg, f.DisplayName(), f.DisplayFilename(), helmRootKey)
+	}
+	if release.Version, err = get("version", ""); err != nil {
+		return nil, err
+	}
+	if release.Namespace, err = get("namespace", "default"); err != nil {
+		return nil, err
+	}
+	if release.RepoName, err = get("repo.name", ""); err != nil {
+		return nil, err
+	}
+	if release.RepoURL, err = get("repo.url", ""); err != nil {
+		return nil, err
+	}
+	if release.RepoName != "" && !strings.Contains(release.Chart, "/") {
+		release.Chart = release.RepoName + "/" + release.Chart
+	}
+	// values are given as a YAML document, viper would lower the case of the keys of a map
+	if values := f.specs.GetString(helmRootKey + ".values"); values != "" {
+		release.Values, err = replaceVariablesInString(values, realizedV)
+		if err != nil {
+			return nil, fmt.Errorf("invalid '%s.values' in feature '%s': %s", helmRootKey, f.DisplayName(), err.Error())
+		}
+	}
+	if timeout := f.specs.GetString(helmRootKey + "." + yamlTimeoutKeyword); timeout != "" {
+		minutes, err := strconv.Atoi(timeout)
+		if err != nil {
+			log.Warningf("Invalid value '%s' for '%s.%s', ignored.", timeout, helmRootKey, yamlTimeoutKeyword)
+		} else {
+			release.WallTime = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{release.Chart, release.Version, release.Namespace, release.Values}, "\n")))
+	release.Checksum = fmt.Sprintf("%x", sum)
+	return &release, nil
+}
+
+// repoCommand returns the command registering the repository of the chart, if the feature defines one
+func (i *helmInstaller) repoCommand(release *helmRelease) string {
+	if release.RepoName == "" || release.RepoURL == "" {
+		return ""
+	}
+	return helmCommand("repo", "add", release.RepoName, release.RepoURL) + " && " + helmCommand("repo", "update")
+}
+
+// files returns the paths on the master of the values file and of the checksum file of the release; the path of the
+// values file is empty if the release uses the default values of the chart.
+// The files are put directly in utils.TempFolder, which exists on every host, so there is no folder to create
+func (i *helmInstaller) files(release *helmRelease) (string, string) {
+	prefix := fmt.Sprintf("%s/helm.%s", utils.TempFolder, release.Name)
+	valuesFile := ""
+	if release.Values != "" {
+		valuesFile = prefix + ".values.yaml"
+	}
+	return valuesFile, prefix + ".checksum.yaml"
+}
+
+// upgradeCommand returns the command installing or upgrading the release; 'valuesFile' is left out if empty
+func (i *helmInstaller) upgradeCommand(release *helmRelease, valuesFile, checksumFile string) string {
+	args := []string{"upgrade", "--install", release.Name, release.Chart, "--namespace", release.Namespace}
+	if valuesFile != "" {
+		args = append(args, "-f", valuesFile)
+	}
+	args = append(args, "-f", checksumFile)
+	if release.Version != "" {
+		args = append(args, "--version", release.Version)
+	}
+	return helmCommand(args...)
+}
+
+// dryRun records the commands Add or Upgrade would run on the master, without running them
+func (i *helmInstaller) dryRun(w *worker, master *pb.Host, release *helmRelease) (Results, error) {
+	valuesFile, checksumFile := i.files(release)
+	var lines []string
+	if cmd := i.repoCommand(release); cmd != "" {
+		lines = append(lines, cmd)
+	}
+	if valuesFile != "" {
+		lines = append(lines, fmt.Sprintf("cat >%s <<'EOF'\n%s\nEOF", valuesFile, strings.TrimRight(release.Values, "\n")))
+	}
+	lines = append(lines,
+		fmt.Sprintf("echo '%s: %s' >%s", helmChecksumKey, release.Checksum, checksumFile),
+		i.upgradeCommand(release, valuesFile, checksumFile),
+	)
+
+	entry := w.settings.DryRun.newFeature(w, []string{release.Name})
+	w.settings.DryRun.addStep(entry, &DryRunStep{
+		Name:  release.Name,
+		Hosts: []DryRunScript{{Host: master.Name, Script: strings.Join(lines, "\n") + "\n"}},
+	})
+	return Results{release.Name: StepResults{master.Name: stepResult{completed: true, success: true}}}, nil
+}
+
+// status returns the status of the release (empty string if the release doesn't exist), and tells if the release
+// has been deployed with the current specification
+func (i *helmInstaller) status(master *pb.Host, release *helmRelease) (string, bool, error) {
+	retcode, stdout, _, err := client.New().SSH.Run(master.Name, helmCommand("status", release.Name), outputs.COLLECT, temporal.GetConnectionTimeout(), temporal.GetExecutionTimeout())
+	if err != nil {
+		return "", false, err
+	}
+	if retcode != 0 {
+		return "", false, nil
+	}
+	state := ""
+	if match := helmStatusRegexp.FindStringSubmatch(stdout); len(match) > 1 {
+		state = strings.ToUpper(match[1])
+	}
+
+	retcode, stdout, _, err = client.New().SSH.Run(master.Name, helmCommand("get", "values", release.Name), outputs.COLLECT, temporal.GetConnectionTimeout(), temporal.GetExecutionTimeout())
+	if err != nil {
+		return "", false, err
+	}
+	upToDate := retcode == 0 && strings.Contains(stdout, fmt.Sprintf("%s: %s", helmChecksumKey, release.Checksum))
+	return state, upToDate, nil
+}
+
+// revision returns the revision of the last deployment of the release, empty if it cannot be determined
+func (i *helmInstaller) revision(master *pb.Host, release *helmRelease) string {
+	retcode, stdout, _, err := client.New().SSH.Run(master.Name, helmCommand("history", "--max", "1", release.Name), outputs.COLLECT, temporal.GetConnectionTimeout(), temporal.GetExecutionTimeout())
+	if err != nil || retcode != 0 {
+		return ""
+	}
+	if match := helmRevisionRegexp.FindStringSubmatch(stdout); len(match) > 1 {
+		return match[1]
+	}
+	return ""
+}
+
+// run executes a helm command on the master
+func (i *helmInstaller) run(master *pb.Host, releaseName, cmd string, wallTime time.Duration) error {
+	retcode, _, stderr, err := client.New().SSH.Run(master.Name, cmd, outputs.COLLECT, temporal.GetConnectionTimeout(), wallTime)
+	if err != nil {
+		return err
+	}
+	if retcode != 0 {
+		return fmt.Errorf("helm failed on release '%s' (retcode=%d): %s", releaseName, retcode, strings.TrimSpace(stderr))
+	}
+	return nil
+}
+
+// helmCommand returns the command line running helm with the cluster admin user, the same way sfHelm does; the
+// arguments are quoted, as they may come from the parameters of the feature
+func helmCommand(args ...string) string {
+	quoted := make([]string, 0, len(args))
+	for _, a := range args {
+		quoted = append(quoted, shellQuote(a))
+	}
+	cmd := "sudo -u cladm -i helm " + strings.Join(quoted, " ")
+	if len(args) > 0 && args[0] != "repo" && args[0] != "search" {
+		cmd += " --tls"
+	}
+	return cmd
+}
+
+// shellQuote quotes 's' to be passed as a single word to the shell
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// NewHelmInstaller creates a new instance of Installer using Helm
+func NewHelmInstaller() Installer {
+	return &helmInstaller{}
+}
@@ -0,0 +1,85 @@
+package install
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CS-SI/SafeScale/lib/utils"
+)
+
+const helmFeatureSpec = `
+feature:
+    suitableFor:
+        cluster: k8s
+    install:
+        helm:
+            repo:
+                name: codecentric
+                url: https://codecentric.github.io/helm-charts
+            chart: keycloak
+            version: 6.0.3
+            namespace: "{{ .Namespace }}"
+%s
+`
+
+func TestHelmRelease(t *testing.T) {
+	i := &helmInstaller{}
+
+	f := featureFromString(t, fmt.Sprintf(helmFeatureSpec, ""))
+	r, err := i.release(f, Variables{"Namespace": "auth"})
+	require.NoError(t, err)
+	assert.Equal(t, "test", r.Name)
+	assert.Equal(t, "codecentric/keycloak", r.Chart)
+	assert.Equal(t, "6.0.3", r.Version)
+	assert.Equal(t, "auth", r.Namespace)
+	assert.Empty(t, r.Values)
+	sameSpec, err := i.release(f, Variables{"Namespace": "auth"})
+	require.NoError(t, err)
+	assert.Equal(t, r.Checksum, sameSpec.Checksum)
+	otherNamespace, err := i.release(f, Variables{"Namespace": "other"})
+	require.NoError(t, err)
+	assert.NotEqual(t, r.Checksum, otherNamespace.Checksum)
+
+	withValues := "            release: \"{{ .Namespace }}-kc\"\n            values: |\n                replicas: {{ .Replicas }}\n"
+	f = featureFromString(t, fmt.Sprintf(helmFeatureSpec, withValues))
+	r2, err := i.release(f, Variables{"Namespace": "auth", "Replicas": "2"})
+	require.NoError(t, err)
+	assert.Equal(t, "auth-kc", r2.Name)
+	assert.Equal(t, "replicas: 2\n", r2.Values)
+	assert.NotEqual(t, r.Checksum, r2.Checksum)
+	r3, err := i.release(f, Variables{"Namespace": "auth", "Replicas": "3"})
+	require.NoError(t, err)
+	assert.NotEqual(t, r2.Checksum, r3.Checksum)
+
+	f = featureFromString(t, "feature:\n    install:\n        helm:\n            version: 1.0\n")
+	_, err = i.release(f, Variables{})
+	assert.Error(t, err)
+}
+
+func TestHelmUpgradeCommand(t *testing.T) {
+	i := &helmInstaller{}
+	cases := []struct {
+		release  helmRelease
+		expected string
+	}{
+		{
+			release:  helmRelease{Name: "kc", Chart: "codecentric/keycloak", Namespace: "default"},
+			expected: "sudo -u cladm -i helm 'upgrade' '--install' 'kc' 'codecentric/keycloak' '--namespace' 'default' '-f' '" + utils.TempFolder + "/helm.kc.checksum.yaml' --tls",
+		},
+		{
+			release:  helmRelease{Name: "kc", Chart: "keycloak", Namespace: "auth", Version: "6.0.3", Values: "replicas: 2\n"},
+			expected: "sudo -u cladm -i helm 'upgrade' '--install' 'kc' 'keycloak' '--namespace' 'auth' '-f' '" + utils.TempFolder + "/helm.kc.values.yaml' '-f' '" + utils.TempFolder + "/helm.kc.checksum.yaml' '--version' '6.0.3' --tls",
+		},
+		{
+			release:  helmRelease{Name: "kc", Chart: "keycloak", Namespace: "a'b; rm -rf /"},
+			expected: "sudo -u cladm -i helm 'upgrade' '--install' 'kc' 'keycloak' '--namespace' 'a'\\''b; rm -rf /' '-f' '" + utils.TempFolder + "/helm.kc.checksum.yaml' --tls",
+		},
+	}
+	for _, c := range cases {
+		valuesFile, checksumFile := i.files(&c.release)
+		assert.Equal(t, c.expected, i.upgradeCommand(&c.release, valuesFile, checksumFile))
+	}
+}
@@ -13,11 +13,12 @@ import (
 // genericPackager is an object implementing the OS package management
 // It handles package management on single host or entire cluster
 type genericPackager struct {
-	keyword       string
-	method        method.Enum
-	checkCommand  alterCommandCB
-	addCommand    alterCommandCB
-	removeCommand alterCommandCB
+	keyword        string
+	method         method.Enum
+	checkCommand   alterCommandCB
+	addCommand     alterCommandCB
+	removeCommand  alterCommandCB
+	upgradeCommand alterCommandCB
 }
 
 // Check checks if the feature is installed
@@ -85,6 +86,27 @@ func (g *genericPackager) Remove(f *Feature, t Target, v Variables, s Settings)
 	return worker.Proceed(v, s)
 }
 
+// Upgrade upgrades the packages of the feature
+func (g *genericPackager) Upgrade(f *Feature, t Target, v Variables, s Settings) (Results, error) {
+	yamlKey := "feature.install." + g.keyword + ".upgrade"
+	if !f.specs.IsSet(yamlKey) {
+		msg := `syntax error in feature '%s' specification file (%s):
+				no key '%s' found`
+		return nil, fmt.Errorf(msg, f.DisplayName(), f.DisplayFilename(), yamlKey)
+	}
+
+	worker, err := newWorker(f, t, g.method, action.Upgrade, g.upgradeCommand)
+	if err != nil {
+		return nil, err
+	}
+	err = worker.CanProceed(s)
+	if err != nil {
+		logrus.Println(err.Error())
+		return nil, err
+	}
+	return worker.Proceed(v, s)
+}
+
 // aptInstaller is an installer using script to add and remove a feature
 type aptInstaller struct {
 	genericPackager
@@ -105,6 +127,9 @@ func NewAptInstaller() Installer {
 			removeCommand: func(pkg string) string {
 				return fmt.Sprintf("sudo apt-get remove -y '%s'", pkg)
 			},
+			upgradeCommand: func(pkg string) string {
+				return fmt.Sprintf("sudo apt-get install --only-upgrade -y '%s'", pkg)
+			},
 		},
 	}
 }
@@ -129,6 +154,9 @@ func NewYumInstaller() Installer {
 			removeCommand: func(pkg string) string {
 				return fmt.Sprintf("sudo yum remove -y %s", pkg)
 			},
+			upgradeCommand: func(pkg string) string {
+				return fmt.Sprintf("sudo yum upgrade -y %s", pkg)
+			},
 		},
 	}
 }
@@ -153,6 +181,9 @@ func NewDnfInstaller() Installer {
 			removeCommand: func(pkg string) string {
 				return fmt.Sprintf("sudo dnf uninstall -y %s", pkg)
 			},
+			upgradeCommand: func(pkg string) string {
+				return fmt.Sprintf("sudo dnf upgrade -y %s", pkg)
+			},
 		},
 	}
 }
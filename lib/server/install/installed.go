@@ -0,0 +1,427 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	pb "github.com/CS-SI/SafeScale/lib"
+	"github.com/CS-SI/SafeScale/lib/client"
+	clusterapi "github.com/CS-SI/SafeScale/lib/server/cluster/api"
+	clusterpropsv1 "github.com/CS-SI/SafeScale/lib/server/cluster/control/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/cluster/enums/property"
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+	srvutils "github.com/CS-SI/SafeScale/lib/server/utils"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+	"github.com/CS-SI/SafeScale/lib/utils/temporal"
+)
+
+// InstalledFeatureStatus describes a feature installed on a host or a cluster, compared to its specification
+type InstalledFeatureStatus struct {
+	Feature    string   `json:"feature"`
+	Installed  string   `json:"installed_version,omitempty"`
+	Available  string   `json:"available_version,omitempty"`
+	Outdated   bool     `json:"outdated"`
+	Requires   []string `json:"requires,omitempty"`
+	RequiredBy []string `json:"required_by,omitempty"`
+}
+
+// installedRecord contains what is recorded in metadata about an installed feature
+type installedRecord struct {
+	version     string
+	requires    []string
+	requiredBy  []string
+	hostContext bool // for a host, tells if the feature has been installed for the host (opposed to for its cluster)
+	check       FeatureStatus
+}
+
+// ListInstalledFeatures lists the features recorded as installed on the target with their dependencies, telling which
+// ones are outdated, ie have a specification of a more recent version
+func ListInstalledFeatures(task concurrency.Task, t Target) ([]*InstalledFeatureStatus, error) {
+	if task == nil {
+		return nil, scerr.InvalidParameterError("task", "cannot be nil")
+	}
+	if t == nil {
+		return nil, scerr.InvalidParameterError("t", "cannot be nil")
+	}
+
+	list, err := listInstalled(task, t)
+	if err != nil {
+		return nil, err
+	}
+	statuses := []*InstalledFeatureStatus{}
+	for _, name := range sortedNames(list) {
+		record := list[name]
+		status := &InstalledFeatureStatus{
+			Feature:    name,
+			Installed:  record.version,
+			Requires:   record.requires,
+			RequiredBy: record.requiredBy,
+		}
+		feature, err := NewFeature(task, name)
+		if err != nil || feature.specs == nil {
+			logrus.Warnf("failed to find the specification of feature '%s' installed on %s '%s'", name, t.Type(), t.Name())
+		} else {
+			status.Available = feature.Version()
+			// without recorded version, nothing tells the feature is outdated
+			status.Outdated = status.Installed != "" && status.Available != "" && compareVersions(status.Installed, status.Available) < 0
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// registerInstallation records in the metadata of the target that the feature is installed, with its version
+func (f *Feature) registerInstallation(t Target) error {
+	hT, cT, nT := determineContext(t)
+	if cT != nil {
+		return updateClusterFeatures(f.task, cT.cluster, func(featuresV1 *clusterpropsv1.Features) error {
+			var previous []string
+			if former, ok := featuresV1.Installed[f.DisplayName()]; ok {
+				previous = former.Requires
+			}
+			installed := clusterpropsv1.NewInstalledFeature()
+			installed.Version = f.Version()
+			installed.Requires = f.requirements()
+			featuresV1.Installed[f.DisplayName()] = installed
+			clusterDependencyLinks(featuresV1.Installed).register(f.DisplayName(), previous)
+			// a disabled feature explicitly added is not disabled anymore
+			delete(featuresV1.Disabled, f.DisplayName())
+			return nil
+		})
+	}
+
+	var host *pb.Host
+	if nT != nil {
+		host = nT.host
+	}
+	if hT != nil {
+		host = hT.host
+	}
+	return updateHostFeatures(host, func(featuresV1 *propsv1.HostFeatures) error {
+		var previous []string
+		if former, ok := featuresV1.Installed[f.DisplayName()]; ok {
+			previous = former.Requires
+		}
+		installed := propsv1.NewHostInstalledFeature()
+		installed.HostContext = hT != nil
+		installed.Version = f.Version()
+		installed.Requires = f.requirements()
+		featuresV1.Installed[f.DisplayName()] = installed
+		hostDependencyLinks(featuresV1.Installed).register(f.DisplayName(), previous)
+		return nil
+	})
+}
+
+// unregisterInstallation removes the feature from the features installed recorded in the metadata of the target
+func (f *Feature) unregisterInstallation(t Target) error {
+	hT, cT, nT := determineContext(t)
+	if cT != nil {
+		return updateClusterFeatures(f.task, cT.cluster, func(featuresV1 *clusterpropsv1.Features) error {
+			clusterDependencyLinks(featuresV1.Installed).unregister(f.DisplayName())
+			delete(featuresV1.Installed, f.DisplayName())
+			return nil
+		})
+	}
+
+	var host *pb.Host
+	if nT != nil {
+		host = nT.host
+	}
+	if hT != nil {
+		host = hT.host
+	}
+	return updateHostFeatures(host, func(featuresV1 *propsv1.HostFeatures) error {
+		hostDependencyLinks(featuresV1.Installed).unregister(f.DisplayName())
+		delete(featuresV1.Installed, f.DisplayName())
+		return nil
+	})
+}
+
+// installedVersion returns the version of the feature recorded as installed on the target, and if the feature
+// is recorded at all
+func (f *Feature) installedVersion(t Target) (string, bool, error) {
+	list, err := listInstalled(f.task, t)
+	if err != nil {
+		return "", false, err
+	}
+	record, found := list[f.DisplayName()]
+	return record.version, found, nil
+}
+
+// removeDependents removes the features recorded as installed on the target that require the feature, if
+// s.RemoveDependents is set; otherwise fails if there are such features
+func (f *Feature) removeDependents(t Target, v Variables, s Settings) error {
+	removed := map[string]bool{}
+	for {
+		list, err := listInstalled(f.task, t)
+		if err != nil {
+			return err
+		}
+		dependents := dependentsOf(list, f.DisplayName())
+		if len(dependents) == 0 {
+			return nil
+		}
+		if !s.RemoveDependents {
+			return scerr.InvalidRequestError(fmt.Sprintf("feature '%s' is required by feature(s) '%s' installed on %s '%s'", f.DisplayName(), strings.Join(dependents, "', '"), t.Type(), t.Name()))
+		}
+
+		// each removal may remove other dependents (requiring the dependent), so the list is read again after it
+		name := ""
+		for _, d := range dependents {
+			if !removed[d] {
+				name = d
+				break
+			}
+		}
+		if name == "" {
+			return fmt.Errorf("feature(s) '%s' removed but still recorded as installed on %s '%s'", strings.Join(dependents, "', '"), t.Type(), t.Name())
+		}
+		removed[name] = true
+
+		dependent, err := NewFeature(f.task, name)
+		if err != nil {
+			return fmt.Errorf("failed to find feature '%s' requiring '%s': %s", name, f.DisplayName(), err.Error())
+		}
+		logrus.Infof("Removing feature '%s' requiring feature '%s' from %s '%s'...", name, f.DisplayName(), t.Type(), t.Name())
+		results, err := dependent.Remove(t, v, s)
+		if err != nil {
+			return fmt.Errorf("failed to remove feature '%s' requiring '%s': %s", name, f.DisplayName(), err.Error())
+		}
+		if !results.Successful() {
+			return fmt.Errorf("failed to remove feature '%s' requiring '%s':\n%s", name, f.DisplayName(), results.AllErrorMessages())
+		}
+	}
+}
+
+// updateClusterFeatures applies 'updatefn' to the features property of the cluster, and saves the metadata
+func updateClusterFeatures(task concurrency.Task, cluster clusterapi.Cluster, updatefn func(*clusterpropsv1.Features) error) error {
+	return cluster.UpdateMetadata(task, func() error {
+		return cluster.GetProperties(task).LockForWrite(property.FeaturesV1).ThenUse(func(clonable data.Clonable) error {
+			return updatefn(clonable.(*clusterpropsv1.Features))
+		})
+	})
+}
+
+// updateHostFeatures applies 'updatefn' to the features recorded as installed on the host, and saves them
+// through safescaled
+func updateHostFeatures(host *pb.Host, updatefn func(*propsv1.HostFeatures) error) error {
+	hostClient := client.New().Host
+	in, err := hostClient.GetFeatures(host.Id, temporal.GetExecutionTimeout())
+	if err != nil {
+		return err
+	}
+	featuresV1, err := srvutils.FromPBHostFeatures(in)
+	if err != nil {
+		return err
+	}
+	err = updatefn(featuresV1)
+	if err != nil {
+		return err
+	}
+	return hostClient.SetFeatures(srvutils.ToPBHostFeatures(&pb.Reference{Id: host.Id}, featuresV1), temporal.GetExecutionTimeout())
+}
+
+// listInstalled returns the features recorded as installed on the target
+func listInstalled(task concurrency.Task, t Target) (map[string]installedRecord, error) {
+	hT, cT, nT := determineContext(t)
+	switch {
+	case cT != nil:
+		return listInstalledOnCluster(task, cT.cluster)
+	case nT != nil:
+		return listInstalledOnHost(nT.host)
+	case hT != nil:
+		return listInstalledOnHost(hT.host)
+	}
+	return nil, scerr.InvalidParameterError("t", "must be a HostTarget, a NodeTarget or a ClusterTarget")
+}
+
+// listInstalledOnHost returns the features recorded as installed on the host
+func listInstalledOnHost(host *pb.Host) (map[string]installedRecord, error) {
+	in, err := client.New().Host.GetFeatures(host.Id, temporal.GetExecutionTimeout())
+	if err != nil {
+		return nil, err
+	}
+	featuresV1, err := srvutils.FromPBHostFeatures(in)
+	if err != nil {
+		return nil, err
+	}
+	list := map[string]installedRecord{}
+	for k, v := range featuresV1.Installed {
+		list[k] = installedRecord{
+			version:     v.Version,
+			requires:    v.Requires,
+			requiredBy:  v.RequiredBy,
+			hostContext: v.HostContext,
+			check: FeatureStatus{
+				Feature:      k,
+				Status:       v.CheckStatus,
+				Message:      v.CheckMessage,
+				CheckedAt:    v.CheckedAt,
+				RemediatedAt: v.RemediatedAt,
+			},
+		}
+	}
+	return list, nil
+}
+
+// listInstalledOnCluster returns the features recorded as installed on the cluster
+func listInstalledOnCluster(task concurrency.Task, cluster clusterapi.Cluster) (map[string]installedRecord, error) {
+	list := map[string]installedRecord{}
+	err := cluster.GetProperties(task).LockForRead(property.FeaturesV1).ThenUse(func(clonable data.Clonable) error {
+		for k, v := range clonable.(*clusterpropsv1.Features).Installed {
+			list[k] = installedRecord{
+				version:    v.Version,
+				requires:   v.Requires,
+				requiredBy: v.RequiredBy,
+				check: FeatureStatus{
+					Feature:      k,
+					Status:       v.CheckStatus,
+					Message:      v.CheckMessage,
+					CheckedAt:    v.CheckedAt,
+					RemediatedAt: v.RemediatedAt,
+				},
+			}
+		}
+		return nil
+	})
+	return list, err
+}
+
+// sortedNames returns the names of the features of the list, sorted
+func sortedNames(list map[string]installedRecord) []string {
+	names := make([]string, 0, len(list))
+	for k := range list {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// dependentsOf returns the names of the installed features requiring the feature 'name', sorted
+func dependentsOf(list map[string]installedRecord, name string) []string {
+	dependents := []string{}
+	for k, record := range list {
+		if k != name && containsName(record.requires, name) {
+			dependents = append(dependents, k)
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// dependencyLink gives access to the dependencies recorded in metadata for an installed feature
+type dependencyLink struct {
+	requires   *[]string
+	requiredBy *[]string
+}
+
+// dependencyLinks indexes the dependencies of the installed features on feature name, to maintain the fields
+// RequiredBy of host and cluster properties the same way
+type dependencyLinks map[string]dependencyLink
+
+// hostDependencyLinks returns the dependencies of the features installed on a host
+func hostDependencyLinks(installed map[string]*propsv1.HostInstalledFeature) dependencyLinks {
+	links := dependencyLinks{}
+	for k, v := range installed {
+		links[k] = dependencyLink{requires: &v.Requires, requiredBy: &v.RequiredBy}
+	}
+	return links
+}
+
+// clusterDependencyLinks returns the dependencies of the features installed on a cluster
+func clusterDependencyLinks(installed map[string]*clusterpropsv1.InstalledFeature) dependencyLinks {
+	links := dependencyLinks{}
+	for k, v := range installed {
+		links[k] = dependencyLink{requires: &v.Requires, requiredBy: &v.RequiredBy}
+	}
+	return links
+}
+
+// register updates the dependencies after the (re)installation of feature 'name', already recorded with its
+// requirements; 'previous' contains the requirements of the former installation, if any
+func (dl dependencyLinks) register(name string, previous []string) {
+	link, ok := dl[name]
+	if !ok {
+		return
+	}
+	for _, r := range previous {
+		if other, ok := dl[r]; ok {
+			*other.requiredBy = removeName(*other.requiredBy, name)
+		}
+	}
+	for _, r := range *link.requires {
+		if other, ok := dl[r]; ok {
+			*other.requiredBy = addName(*other.requiredBy, name)
+		}
+	}
+	// features installed before may already require this one
+	*link.requiredBy = []string{}
+	for k, other := range dl {
+		if k != name && containsName(*other.requires, name) {
+			*link.requiredBy = addName(*link.requiredBy, k)
+		}
+	}
+}
+
+// unregister removes feature 'name' from the dependencies of the features it requires
+func (dl dependencyLinks) unregister(name string) {
+	link, ok := dl[name]
+	if !ok {
+		return
+	}
+	for _, r := range *link.requires {
+		if other, ok := dl[r]; ok {
+			*other.requiredBy = removeName(*other.requiredBy, name)
+		}
+	}
+}
+
+func containsName(list []string, name string) bool {
+	for _, v := range list {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// addName adds 'name' to the sorted list if not already present
+func addName(list []string, name string) []string {
+	if containsName(list, name) {
+		return list
+	}
+	list = append(list, name)
+	sort.Strings(list)
+	return list
+}
+
+func removeName(list []string, name string) []string {
+	result := []string{}
+	for _, v := range list {
+		if v != name {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+package install
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+)
+
+func TestDependencyLinks(t *testing.T) {
+	installed := map[string]*propsv1.HostInstalledFeature{}
+	record := func(name string, requires ...string) []string {
+		var previous []string
+		if former, ok := installed[name]; ok {
+			previous = former.Requires
+		}
+		f := propsv1.NewHostInstalledFeature()
+		f.Requires = requires
+		installed[name] = f
+		hostDependencyLinks(installed).register(name, previous)
+		return previous
+	}
+
+	record("docker")
+	record("kubernetes", "docker")
+	record("helm", "kubernetes")
+	assert.Equal(t, []string{"kubernetes"}, installed["docker"].RequiredBy)
+	assert.Equal(t, []string{"helm"}, installed["kubernetes"].RequiredBy)
+	assert.Empty(t, installed["helm"].RequiredBy)
+
+	// a requirement recorded after the feature requiring it
+	record("proxycache")
+	record("docker", "proxycache")
+	assert.Equal(t, []string{"docker"}, installed["proxycache"].RequiredBy)
+	assert.Equal(t, []string{"kubernetes"}, installed["docker"].RequiredBy)
+
+	// an upgrade dropping a requirement
+	record("docker")
+	assert.Empty(t, installed["proxycache"].RequiredBy)
+
+	hostDependencyLinks(installed).unregister("helm")
+	delete(installed, "helm")
+	assert.Empty(t, installed["kubernetes"].RequiredBy)
+}
+
+func TestDependentsOf(t *testing.T) {
+	list := map[string]installedRecord{
+		"docker":        {},
+		"kubernetes":    {requires: []string{"docker"}},
+		"remotedesktop": {requires: []string{"docker", "proxycache"}},
+		"helm":          {requires: []string{"kubernetes"}},
+	}
+	assert.Equal(t, []string{"kubernetes", "remotedesktop"}, dependentsOf(list, "docker"))
+	assert.Equal(t, []string{"helm"}, dependentsOf(list, "kubernetes"))
+	assert.Empty(t, dependentsOf(list, "helm"))
+}
+
+func TestCheckedNames(t *testing.T) {
+	list := map[string]installedRecord{
+		"docker":     {hostContext: true},
+		"kubernetes": {},
+		"helm":       {hostContext: true},
+	}
+	// on a host, the features installed for its cluster are left to the cluster
+	assert.Equal(t, []string{"docker", "helm"}, checkedNames(&HostTarget{}, list))
+	assert.Equal(t, []string{"docker", "helm", "kubernetes"}, checkedNames(&ClusterTarget{}, list))
+}
@@ -207,7 +207,7 @@ func (k *KongController) Apply(rule map[interface{}]interface{}, values *Variabl
 		if err != nil {
 			return ruleName, fmt.Errorf("failed to apply proxy rule '%s': %s", ruleName, err.Error())
 		}
-		logrus.Debugf("successfully applied proxy rule '%s': %v", ruleName, content)
+		logrus.Debugf("successfully applied proxy rule '%s': %v", ruleName, maskSecretValues(content, secretValues(*values)))
 		return ruleName, k.addSourceControl(ruleName, url, ruleType, response["id"].(string), sourceControl, values)
 
 	case "route":
@@ -231,7 +231,7 @@ func (k *KongController) Apply(rule map[interface{}]interface{}, values *Variabl
 		if err != nil {
 			return ruleName, fmt.Errorf("failed to apply proxy rule '%s': %s", ruleName, err.Error())
 		}
-		logrus.Debugf("successfully applied proxy rule '%s': %v", ruleName, content)
+		logrus.Debugf("successfully applied proxy rule '%s': %v", ruleName, maskSecretValues(content, secretValues(*values)))
 		return ruleName, k.addSourceControl(ruleName, url, ruleType, response["id"].(string), sourceControl, values)
 
 	case "upstream":
@@ -269,7 +269,7 @@ func (k *KongController) Apply(rule map[interface{}]interface{}, values *Variabl
 		if err != nil {
 			return ruleName, fmt.Errorf("failed to apply proxy rule '%s': %s", ruleName, err.Error())
 		}
-		logrus.Debugf("successfully applied proxy rule '%s': %v", ruleName, content)
+		logrus.Debugf("successfully applied proxy rule '%s': %v", ruleName, maskSecretValues(content, secretValues(*values)))
 		return ruleName, nil
 
 	default:
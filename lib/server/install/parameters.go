@@ -0,0 +1,322 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	parameterString = "string"
+	parameterInt    = "int"
+	parameterBool   = "bool"
+	parameterEnum   = "enum"
+	parameterSecret = "secret"
+
+	// defaultSecretLength is the length of the secrets generated when the parameter doesn't define one
+	defaultSecretLength = 24
+	// secretAlphabet contains the characters used in generated secrets, chosen to be safe in scripts and URLs
+	secretAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// secretValue is the type of the value of a parameter declared as secret in Variables. Rendered in templates as
+// a string, it allows to recognize the secrets to mask whatever the name of the parameter.
+type secretValue string
+
+// parameter describes a parameter declared in the specification file of a feature
+type parameter struct {
+	name       string
+	kind       string
+	required   bool
+	hasDefault bool
+	value      string // default value
+	regex      *regexp.Regexp
+	values     []string // allowed values of an enum
+	length     int      // length of a generated secret
+}
+
+// parseParameters returns the parameters declared under 'feature.parameters', either as '<name>[=[<value>]]'
+// strings or as maps describing a typed parameter
+func parseParameters(specs *viper.Viper) ([]*parameter, error) {
+	anon := specs.Get("feature.parameters")
+	if anon == nil {
+		return nil, nil
+	}
+	list, ok := anon.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("'feature.parameters' must be a list")
+	}
+
+	params := make([]*parameter, 0, len(list))
+	found := map[string]bool{}
+	for _, item := range list {
+		var (
+			p   *parameter
+			err error
+		)
+		switch item := item.(type) {
+		case string:
+			p, err = parseUntypedParameter(item)
+		case map[interface{}]interface{}:
+			fields := map[string]interface{}{}
+			for k, v := range item {
+				fields[fmt.Sprintf("%v", k)] = v
+			}
+			p, err = parseTypedParameter(fields)
+		case map[string]interface{}:
+			p, err = parseTypedParameter(item)
+		default:
+			err = fmt.Errorf("invalid parameter '%v': must be a string or a map", item)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if found[p.name] {
+			return nil, fmt.Errorf("parameter '%s' declared more than once", p.name)
+		}
+		found[p.name] = true
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// parseUntypedParameter parses a parameter declared as '<name>[=[<value>]]'; without '=', a value is required
+func parseUntypedParameter(item string) (*parameter, error) {
+	splitted := strings.Split(item, "=")
+	p := &parameter{
+		name: strings.TrimSpace(splitted[0]),
+		kind: parameterString,
+	}
+	if p.name == "" {
+		return nil, fmt.Errorf("invalid parameter '%s': name is empty", item)
+	}
+	if len(splitted) == 1 {
+		p.required = true
+	} else {
+		p.hasDefault = true
+		p.value = strings.Join(splitted[1:], "=")
+	}
+	return p, nil
+}
+
+// parseTypedParameter parses a parameter declared as a map with the fields 'name', 'type', 'required', 'default',
+// 'regex', 'values' (for enums) and 'length' (for secrets)
+func parseTypedParameter(fields map[string]interface{}) (*parameter, error) {
+	p := &parameter{kind: parameterString}
+	if name, ok := fields["name"].(string); ok {
+		p.name = strings.TrimSpace(name)
+	}
+	if p.name == "" {
+		return nil, fmt.Errorf("invalid parameter %v: name is empty", fields)
+	}
+	if kind, ok := fields["type"]; ok {
+		p.kind = strings.ToLower(fmt.Sprintf("%v", kind))
+	}
+	switch p.kind {
+	case parameterString, parameterInt, parameterBool, parameterEnum, parameterSecret:
+	default:
+		return nil, fmt.Errorf("invalid parameter '%s': unknown type '%s'", p.name, p.kind)
+	}
+	if required, ok := fields["required"]; ok {
+		b, ok := required.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid parameter '%s': 'required' must be a boolean", p.name)
+		}
+		p.required = b
+	}
+	if regex, ok := fields["regex"]; ok {
+		if p.kind != parameterString && p.kind != parameterSecret {
+			return nil, fmt.Errorf("invalid parameter '%s': 'regex' only applies to types 'string' and 'secret'", p.name)
+		}
+		var err error
+		p.regex, err = regexp.Compile("^(?:" + fmt.Sprintf("%v", regex) + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameter '%s': invalid regex: %s", p.name, err.Error())
+		}
+	}
+	if values, ok := fields["values"].([]interface{}); ok {
+		for _, v := range values {
+			p.values = append(p.values, fmt.Sprintf("%v", v))
+		}
+	}
+	if p.kind == parameterEnum && len(p.values) == 0 {
+		return nil, fmt.Errorf("invalid parameter '%s': an enum needs 'values'", p.name)
+	}
+	if p.kind != parameterEnum && len(p.values) > 0 {
+		return nil, fmt.Errorf("invalid parameter '%s': 'values' only applies to type 'enum'", p.name)
+	}
+	if length, ok := fields["length"]; ok {
+		n, ok := length.(int)
+		if !ok || n <= 0 || p.kind != parameterSecret {
+			return nil, fmt.Errorf("invalid parameter '%s': 'length' must be a positive integer and only applies to type 'secret'", p.name)
+		}
+		p.length = n
+	}
+	if value, ok := fields["default"]; ok {
+		// a default secret would be known by every reader of the specification file
+		if p.kind == parameterSecret {
+			return nil, fmt.Errorf("invalid parameter '%s': a secret cannot have a default value", p.name)
+		}
+		p.hasDefault = true
+		p.value = fmt.Sprintf("%v", value)
+		if err := p.validate(p.value); err != nil {
+			return nil, fmt.Errorf("invalid default value of parameter '%s': %s", p.name, err.Error())
+		}
+	}
+	return p, nil
+}
+
+// validate checks the value against the type and the regex of the parameter; the message never contains the value
+// of a secret
+func (p *parameter) validate(value string) error {
+	// templated values are realized later, on the target
+	if strings.Contains(value, "{{") {
+		return nil
+	}
+	switch p.kind {
+	case parameterInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("value '%s' of parameter '%s' is not an integer", value, p.name)
+		}
+	case parameterBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("value '%s' of parameter '%s' is not a boolean", value, p.name)
+		}
+	case parameterEnum:
+		for _, v := range p.values {
+			if v == value {
+				return nil
+			}
+		}
+		return fmt.Errorf("value '%s' of parameter '%s' is not one of '%s'", value, p.name, strings.Join(p.values, "', '"))
+	}
+	if p.regex != nil && !p.regex.MatchString(value) {
+		if p.kind == parameterSecret {
+			return fmt.Errorf("value of secret parameter '%s' doesn't match '%s'", p.name, p.regex.String())
+		}
+		return fmt.Errorf("value '%s' of parameter '%s' doesn't match '%s'", value, p.name, p.regex.String())
+	}
+	return nil
+}
+
+// checkParameterValues validates the values of the declared parameters and sets the default ones.
+// Returns the secret parameters left without value, to be resolved by the caller
+func checkParameterValues(params []*parameter, v Variables) ([]*parameter, error) {
+	var unset []*parameter
+	for _, p := range params {
+		anon, ok := v[p.name]
+		if !ok || (p.kind == parameterSecret && fmt.Sprintf("%v", anon) == "") {
+			switch {
+			case p.kind == parameterSecret:
+				unset = append(unset, p)
+			case p.hasDefault:
+				v[p.name] = p.value
+			case p.required:
+				return nil, fmt.Errorf("missing value for parameter '%s'", p.name)
+			default:
+				v[p.name] = ""
+			}
+			continue
+		}
+		value := fmt.Sprintf("%v", anon)
+		if err := p.validate(value); err != nil {
+			return nil, err
+		}
+		if p.kind == parameterSecret {
+			v[p.name] = secretValue(value)
+		}
+	}
+	return unset, nil
+}
+
+// generateSecret returns a random secret of 'length' characters taken in secretAlphabet
+func generateSecret(length int) (string, error) {
+	if length <= 0 {
+		length = defaultSecretLength
+	}
+	max := big.NewInt(int64(len(secretAlphabet)))
+	secret := make([]byte, length)
+	for i := range secret {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate secret: %s", err.Error())
+		}
+		secret[i] = secretAlphabet[n.Int64()]
+	}
+	return string(secret), nil
+}
+
+// secretValues returns the values of the secrets contained in the variables, the longest first, so that
+// masking a secret doesn't leave the end of a longer one containing it
+func secretValues(v Variables) []string {
+	var list []string
+	for k, value := range v {
+		switch value := value.(type) {
+		case secretValue:
+			if value != "" {
+				list = append(list, string(value))
+			}
+		case string:
+			if value != "" && isSecretVariable(k) {
+				list = append(list, value)
+			}
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return len(list[i]) > len(list[j]) })
+	return list
+}
+
+// maskSecretValues replaces in 'text' the values of the secrets
+func maskSecretValues(text string, secrets []string) string {
+	for _, s := range secrets {
+		text = strings.Replace(text, s, maskedValue, -1)
+	}
+	return text
+}
+
+// hideSecrets returns the results and the error where the values of the secrets contained in 'v' are masked
+func hideSecrets(results Results, err error, v Variables) (Results, error) {
+	secrets := secretValues(v)
+	if len(secrets) == 0 {
+		return results, err
+	}
+	if err != nil {
+		if msg := maskSecretValues(err.Error(), secrets); msg != err.Error() {
+			err = fmt.Errorf("%s", msg)
+		}
+	}
+	for _, stepResults := range results {
+		for h, r := range stepResults {
+			if r.err != nil {
+				if msg := maskSecretValues(r.err.Error(), secrets); msg != r.err.Error() {
+					r.err = fmt.Errorf("%s", msg)
+				}
+			}
+			r.output = maskSecretValues(r.output, secrets)
+			stepResults[h] = r
+		}
+	}
+	return results, err
+}
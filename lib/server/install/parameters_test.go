@@ -0,0 +1,139 @@
+package install
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const typedParametersSpec = `
+feature:
+    parameters:
+        - Version=1.0
+        - Realm
+        - name: Port
+          type: int
+          default: 8080
+        - name: Debug
+          type: bool
+        - name: Edition
+          type: enum
+          values: [community, enterprise]
+          default: community
+        - name: Username
+          type: string
+          regex: '[a-z][a-z0-9_]*'
+          required: true
+        - name: AdminPassword
+          type: secret
+          length: 16
+`
+
+func TestParseParameters(t *testing.T) {
+	params, err := parseParameters(featureFromString(t, typedParametersSpec).specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, params, 7) {
+		return
+	}
+	assert.Equal(t, parameter{name: "Version", kind: parameterString, hasDefault: true, value: "1.0"}, *params[0])
+	assert.Equal(t, parameter{name: "Realm", kind: parameterString, required: true}, *params[1])
+	assert.Equal(t, parameter{name: "Port", kind: parameterInt, hasDefault: true, value: "8080"}, *params[2])
+	assert.Equal(t, parameterBool, params[3].kind)
+	assert.Equal(t, []string{"community", "enterprise"}, params[4].values)
+	assert.True(t, params[5].required)
+	assert.NotNil(t, params[5].regex)
+	assert.Equal(t, parameterSecret, params[6].kind)
+	assert.Equal(t, 16, params[6].length)
+
+	invalid := map[string]string{
+		"- name: X\n          type: float":                         "unknown type 'float'",
+		"- name: X\n          type: enum":                          "an enum needs 'values'",
+		"- name: X\n          type: int\n          default: abc":   "is not an integer",
+		"- name: X\n          type: secret\n          default: s":  "a secret cannot have a default value",
+		"- name: X\n          type: int\n          regex: '[0-9]'": "only applies to types 'string' and 'secret'",
+		"- X=1\n        - X=2":                                     "declared more than once",
+		"- =1":                                                     "name is empty",
+	}
+	for spec, msg := range invalid {
+		f := featureFromString(t, "feature:\n    parameters:\n        "+spec+"\n")
+		_, err := parseParameters(f.specs)
+		if assert.Error(t, err, spec) {
+			assert.Contains(t, err.Error(), msg)
+		}
+	}
+}
+
+func TestCheckParameterValues(t *testing.T) {
+	params, err := parseParameters(featureFromString(t, typedParametersSpec).specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := Variables{"Realm": "safescale", "Username": "admin", "Debug": "true"}
+	unset, err := checkParameterValues(params, v)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0", v["Version"])
+	assert.Equal(t, "8080", v["Port"])
+	assert.Equal(t, "community", v["Edition"])
+	if assert.Len(t, unset, 1) {
+		assert.Equal(t, "AdminPassword", unset[0].name)
+	}
+
+	v = Variables{"Realm": "safescale", "Username": "admin", "AdminPassword": "p4ssw0rd"}
+	unset, err = checkParameterValues(params, v)
+	assert.NoError(t, err)
+	assert.Empty(t, unset)
+	assert.Equal(t, secretValue("p4ssw0rd"), v["AdminPassword"])
+	assert.Equal(t, "", v["Debug"])
+
+	_, err = checkParameterValues(params, Variables{"Username": "admin"})
+	assert.EqualError(t, err, "missing value for parameter 'Realm'")
+	_, err = checkParameterValues(params, Variables{"Realm": "safescale", "Username": "Admin"})
+	assert.Error(t, err)
+	_, err = checkParameterValues(params, Variables{"Realm": "safescale", "Username": "admin", "Edition": "free"})
+	assert.Error(t, err)
+	_, err = checkParameterValues(params, Variables{"Realm": "safescale", "Username": "admin", "Port": "http"})
+	assert.Error(t, err)
+
+	// the value of a secret never appears in errors
+	params[6].regex = params[5].regex
+	_, err = checkParameterValues(params, Variables{"Realm": "safescale", "Username": "admin", "AdminPassword": "P4SSW0RD"})
+	if assert.Error(t, err) {
+		assert.NotContains(t, err.Error(), "P4SSW0RD")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	s1, err := generateSecret(0)
+	assert.NoError(t, err)
+	assert.Len(t, s1, defaultSecretLength)
+	s2, err := generateSecret(40)
+	assert.NoError(t, err)
+	assert.Len(t, s2, 40)
+	assert.NotEqual(t, s1, s2[:defaultSecretLength])
+	assert.Empty(t, strings.Trim(s2, secretAlphabet))
+}
+
+func TestHideSecrets(t *testing.T) {
+	v := Variables{
+		"Username":      "admin",
+		"DBPassword":    "p4ss",
+		"AdminPassword": secretValue("p4ssw0rd"),
+		"Credentials":   secretValue("s3cr3t"),
+	}
+	results := Results{
+		"install": StepResults{
+			"host-1": stepResult{err: fmt.Errorf("login admin:p4ssw0rd refused"), output: "token s3cr3t"},
+			"host-2": stepResult{success: true, output: "password p4ss set"},
+		},
+	}
+	results, err := hideSecrets(results, fmt.Errorf("failed with s3cr3t"), v)
+	assert.EqualError(t, err, "failed with "+maskedValue)
+	assert.EqualError(t, results["install"]["host-1"].err, "login admin:"+maskedValue+" refused")
+	assert.Equal(t, "token "+maskedValue, results["install"]["host-1"].output)
+	assert.Equal(t, "password "+maskedValue+" set", results["install"]["host-2"].output)
+}
@@ -0,0 +1,436 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	"github.com/CS-SI/SafeScale/lib/client"
+	"github.com/CS-SI/SafeScale/lib/utils"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+	"github.com/CS-SI/SafeScale/lib/utils/temporal"
+)
+
+const (
+	// sourceGit is a git repository containing specification files, fetched at a given ref
+	sourceGit = "git"
+	// sourceHTTP is an index, served over HTTP(S), listing specification files and their versions
+	sourceHTTP = "http"
+	// sourceBucket is a folder of a bucket in the Object Storage of the current tenant
+	sourceBucket = "bucket"
+)
+
+// featureNameRegexp validates the names of features coming from sources, used as file names
+var featureNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// FeatureSource describes a place where specification files of features are shared, as declared in
+// the file 'feature-sources.yml'
+type FeatureSource struct {
+	Name string `mapstructure:"name" json:"name"`
+	Type string `mapstructure:"type" json:"type"`
+	// URL is the URL of the git repository, or of the index for a source of type http
+	URL string `mapstructure:"url" json:"url,omitempty"`
+	// Ref is the branch, tag or commit of the git repository (default: HEAD)
+	Ref string `mapstructure:"ref" json:"ref,omitempty"`
+	// Bucket is the name of the bucket for a source of type bucket
+	Bucket string `mapstructure:"bucket" json:"bucket,omitempty"`
+	// Path is the folder containing the specification files in the git repository or in the bucket
+	Path string `mapstructure:"path" json:"path,omitempty"`
+}
+
+// RemoteFeature describes a feature available in a source
+type RemoteFeature struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Source  string `json:"source"`
+
+	source   *FeatureSource
+	location string // URL of the specification file, for a source of type http
+	checksum string // sha256 of the specification file, if given by the index of a source of type http
+	content  []byte // content of the specification file, if already read when indexing the source
+}
+
+// httpIndexEntry is an entry of the index of a source of type http
+type httpIndexEntry struct {
+	Name    string `mapstructure:"name"`
+	Version string `mapstructure:"version"`
+	URL     string `mapstructure:"url"`
+	Sha256  string `mapstructure:"sha256"`
+}
+
+// ListFeatureSources returns the sources of features declared in the file 'feature-sources.yml', searched in
+// $HOME/.safescale, $HOME/.config/safescale and /etc/safescale
+func ListFeatureSources() ([]*FeatureSource, error) {
+	v := viper.New()
+	v.AddConfigPath("$HOME/.safescale")
+	v.AddConfigPath("$HOME/.config/safescale")
+	v.AddConfigPath("/etc/safescale")
+	v.SetConfigName("feature-sources")
+
+	err := v.ReadInConfig()
+	if err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			return []*FeatureSource{}, nil
+		}
+		return nil, scerr.SyntaxError(fmt.Sprintf("failed to read the sources of features: %s", err.Error()))
+	}
+
+	var sources []*FeatureSource
+	err = v.UnmarshalKey("sources", &sources)
+	if err != nil {
+		return nil, scerr.SyntaxError(fmt.Sprintf("invalid content of '%s': %s", v.ConfigFileUsed(), err.Error()))
+	}
+	names := map[string]bool{}
+	for i, s := range sources {
+		s.Type = strings.ToLower(s.Type)
+		switch {
+		case s.Name == "":
+			return nil, scerr.SyntaxError(fmt.Sprintf("source #%d of '%s' has no name", i+1, v.ConfigFileUsed()))
+		case names[s.Name]:
+			return nil, scerr.SyntaxError(fmt.Sprintf("source '%s' is declared several times in '%s'", s.Name, v.ConfigFileUsed()))
+		case (s.Type == sourceGit || s.Type == sourceHTTP) && s.URL == "":
+			return nil, scerr.SyntaxError(fmt.Sprintf("source '%s' of type '%s' needs an 'url'", s.Name, s.Type))
+		case s.Type == sourceBucket && s.Bucket == "":
+			return nil, scerr.SyntaxError(fmt.Sprintf("source '%s' of type '%s' needs a 'bucket'", s.Name, s.Type))
+		case s.Type != sourceGit && s.Type != sourceHTTP && s.Type != sourceBucket:
+			return nil, scerr.SyntaxError(fmt.Sprintf("source '%s' has an invalid type '%s' (must be '%s', '%s' or '%s')", s.Name, s.Type, sourceGit, sourceHTTP, sourceBucket))
+		}
+		names[s.Name] = true
+	}
+	return sources, nil
+}
+
+// SearchFeatures lists the features available in the sources whose name contains 'term' (all the features if
+// 'term' is empty), sorted by name then from the most recent version.
+// If 'source' is not empty, only this source is searched.
+func SearchFeatures(task concurrency.Task, term, source string) ([]*RemoteFeature, error) {
+	if task == nil {
+		return nil, scerr.InvalidParameterError("task", "cannot be nil")
+	}
+
+	sources, err := selectSources(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*RemoteFeature
+	for _, s := range sources {
+		features, err := s.index()
+		if err != nil {
+			return nil, fmt.Errorf("failed to index source '%s': %s", s.Name, err.Error())
+		}
+		for _, f := range features {
+			if term == "" || strings.Contains(strings.ToLower(f.Name), strings.ToLower(term)) {
+				list = append(list, f)
+			}
+		}
+	}
+	// sort is stable to keep the order of the sources for identical versions
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return compareVersions(list[i].Version, list[j].Version) > 0
+	})
+	return list, nil
+}
+
+// PullFeature downloads the specification file of the feature 'name' from the sources into the folder of the
+// features of the user ($HOME/.safescale/features), where it supersedes the embedded feature of the same name.
+// If 'version' is empty, the most recent version available is pulled; if 'source' is not empty, only this source
+// is searched. An existing specification file is overwritten only if 'force' is true.
+// Returns the feature pulled and the path of the specification file
+func PullFeature(task concurrency.Task, name, version, source string, force bool) (*RemoteFeature, string, error) {
+	if task == nil {
+		return nil, "", scerr.InvalidParameterError("task", "cannot be nil")
+	}
+	if name == "" {
+		return nil, "", scerr.InvalidParameterError("name", "cannot be empty string")
+	}
+
+	candidates, err := SearchFeatures(task, name, source)
+	if err != nil {
+		return nil, "", err
+	}
+	var selected *RemoteFeature
+	for _, c := range candidates {
+		if c.Name == name && (version == "" || compareVersions(c.Version, version) == 0) {
+			selected = c
+			break
+		}
+	}
+	if selected == nil {
+		if version != "" {
+			return nil, "", scerr.NotFoundError(fmt.Sprintf("failed to find version '%s' of feature '%s' in the sources", version, name))
+		}
+		return nil, "", scerr.NotFoundError(fmt.Sprintf("failed to find feature '%s' in the sources", name))
+	}
+
+	content, err := selected.source.read(selected)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download feature '%s' from source '%s': %s", name, selected.Source, err.Error())
+	}
+
+	// The specification file must be valid and declare the version announced by the source
+	v := viper.New()
+	v.SetConfigType("yaml")
+	err = v.ReadConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, "", scerr.SyntaxError(fmt.Sprintf("invalid specification file of feature '%s' in source '%s': %s", name, selected.Source, err.Error()))
+	}
+	feature := &Feature{displayName: name, fileName: name + ".yml", specs: v, task: task}
+	if selected.Version != "" && compareVersions(feature.Version(), selected.Version) != 0 {
+		return nil, "", fmt.Errorf("feature '%s' in source '%s' declares version '%s' instead of '%s'", name, selected.Source, feature.Version(), selected.Version)
+	}
+	_, errs := feature.Validate()
+	if len(errs) > 0 {
+		var msgs []string
+		for _, e := range errs {
+			msgs = append(msgs, e.Error())
+		}
+		return nil, "", fmt.Errorf("invalid specification of feature '%s' in source '%s':\n%s", name, selected.Source, strings.Join(msgs, "\n"))
+	}
+
+	folder := utils.AbsPathify("$HOME/.safescale/features")
+	err = os.MkdirAll(folder, 0755)
+	if err != nil {
+		return nil, "", err
+	}
+	target := filepath.Join(folder, name+".yml")
+	if _, err := os.Stat(target); err == nil && !force {
+		return nil, "", scerr.DuplicateError(fmt.Sprintf("specification file '%s' already exists", target))
+	}
+	err = ioutil.WriteFile(target, content, 0644)
+	if err != nil {
+		return nil, "", err
+	}
+	logrus.Debugf("Feature '%s' version '%s' pulled from source '%s' in '%s'", name, selected.Version, selected.Source, target)
+	return selected, target, nil
+}
+
+// selectSources returns the source named 'name', or all the sources if 'name' is empty
+func selectSources(name string) ([]*FeatureSource, error) {
+	sources, err := ListFeatureSources()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return sources, nil
+	}
+	for _, s := range sources {
+		if s.Name == name {
+			return []*FeatureSource{s}, nil
+		}
+	}
+	return nil, scerr.NotFoundError(fmt.Sprintf("failed to find a source of features named '%s'", name))
+}
+
+// index lists the features available in the source
+func (fs *FeatureSource) index() ([]*RemoteFeature, error) {
+	switch fs.Type {
+	case sourceGit:
+		return fs.indexGit()
+	case sourceHTTP:
+		return fs.indexHTTP()
+	case sourceBucket:
+		return fs.indexBucket()
+	}
+	return nil, scerr.NotImplementedError(fmt.Sprintf("sources of type '%s' are not supported", fs.Type))
+}
+
+// read returns the content of the specification file of the feature
+func (fs *FeatureSource) read(rf *RemoteFeature) ([]byte, error) {
+	if rf.content != nil {
+		return rf.content, nil
+	}
+	content, err := httpGet(rf.location)
+	if err != nil {
+		return nil, err
+	}
+	if rf.checksum != "" {
+		if sum := fmt.Sprintf("%x", sha256.Sum256(content)); !strings.EqualFold(sum, rf.checksum) {
+			return nil, fmt.Errorf("checksum mismatch for '%s' (expected %s, got %s)", rf.location, rf.checksum, sum)
+		}
+	}
+	return content, nil
+}
+
+// newRemoteFeature builds the RemoteFeature corresponding to the content of a specification file; returns nil
+// if the name is invalid or the content isn't a specification of feature
+func (fs *FeatureSource) newRemoteFeature(filename string, content []byte) *RemoteFeature {
+	name := strings.TrimSuffix(path.Base(filename), path.Ext(filename))
+	if !featureNameRegexp.MatchString(name) {
+		logrus.Warnf("source '%s': invalid feature name '%s', ignored", fs.Name, name)
+		return nil
+	}
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil || !v.IsSet("feature") {
+		logrus.Warnf("source '%s': '%s' is not a specification file of feature, ignored", fs.Name, filename)
+		return nil
+	}
+	return &RemoteFeature{
+		Name:    name,
+		Version: strings.TrimSpace(v.GetString("feature.version")),
+		Source:  fs.Name,
+		source:  fs,
+		content: content,
+	}
+}
+
+// indexGit fetches the ref of the repository in the cache of the user, then reads the specification files
+func (fs *FeatureSource) indexGit() ([]*RemoteFeature, error) {
+	dir := utils.AbsPathify("$HOME/.safescale/cache/features/" + fs.Name)
+	git := func(args ...string) error {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			return nil, err
+		}
+		err = git("init", "-q")
+		if err != nil {
+			return nil, err
+		}
+	}
+	ref := fs.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	err := git("fetch", "-q", "--depth", "1", fs.URL, ref)
+	if err != nil {
+		return nil, err
+	}
+	err = git("checkout", "-q", "--force", "FETCH_HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, fs.Path, "*.yml"))
+	if err != nil {
+		return nil, err
+	}
+	var list []*RemoteFeature
+	for _, f := range files {
+		content, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if rf := fs.newRemoteFeature(f, content); rf != nil {
+			list = append(list, rf)
+		}
+	}
+	return list, nil
+}
+
+// indexHTTP reads the index of the source; the URLs of the specification files may be relative to the index
+func (fs *FeatureSource) indexHTTP() ([]*RemoteFeature, error) {
+	base, err := url.Parse(fs.URL)
+	if err != nil {
+		return nil, err
+	}
+	content, err := httpGet(fs.URL)
+	if err != nil {
+		return nil, err
+	}
+	var entries []httpIndexEntry
+	v := viper.New()
+	v.SetConfigType("yaml")
+	err = v.ReadConfig(bytes.NewReader(content))
+	if err == nil {
+		err = v.UnmarshalKey("features", &entries)
+	}
+	if err != nil {
+		return nil, scerr.SyntaxError(fmt.Sprintf("invalid index '%s': %s", fs.URL, err.Error()))
+	}
+
+	var list []*RemoteFeature
+	for _, f := range entries {
+		if !featureNameRegexp.MatchString(f.Name) || f.URL == "" {
+			logrus.Warnf("source '%s': invalid entry '%s' in index, ignored", fs.Name, f.Name)
+			continue
+		}
+		location, err := base.Parse(f.URL)
+		if err != nil {
+			logrus.Warnf("source '%s': invalid url '%s' for feature '%s', ignored", fs.Name, f.URL, f.Name)
+			continue
+		}
+		list = append(list, &RemoteFeature{
+			Name:     f.Name,
+			Version:  f.Version,
+			Source:   fs.Name,
+			source:   fs,
+			location: location.String(),
+			checksum: f.Sha256,
+		})
+	}
+	return list, nil
+}
+
+// indexBucket reads the specification files stored in the folder of the bucket, through safescaled
+func (fs *FeatureSource) indexBucket() ([]*RemoteFeature, error) {
+	objects, err := client.New().Bucket.ReadFolder(fs.Bucket, fs.Path, ".yml", temporal.GetExecutionTimeout())
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*RemoteFeature
+	for _, o := range objects.GetObjects() {
+		if rf := fs.newRemoteFeature(o.GetName(), o.GetContent()); rf != nil {
+			list = append(list, rf)
+		}
+	}
+	return list, nil
+}
+
+// httpGet returns the content found at 'location'
+func httpGet(location string) ([]byte, error) {
+	httpClient := http.Client{Timeout: temporal.GetExecutionTimeout()}
+	resp, err := httpClient.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get '%s': %s", location, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
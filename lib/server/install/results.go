@@ -47,6 +47,32 @@ func (r Results) AllErrorMessages() string {
 	return output
 }
 
+// AllRollbackMessages returns the results of the rollbacks of the steps, one per step and host
+func (r Results) AllRollbackMessages() string {
+	output := ""
+	for _, k := range r.Keys() {
+		for _, line := range strings.Split(strings.TrimSpace(r[k].RollbackMessages()), "\n") {
+			if line != "" {
+				output += k + "@" + line + "\n"
+			}
+		}
+	}
+	return output
+}
+
+// AllOutputs returns the outputs of all the steps (like the state of an Helm release), one per line
+func (r Results) AllOutputs() string {
+	output := ""
+	for _, step := range r {
+		for _, sr := range step {
+			if val := strings.TrimSpace(sr.Output()); val != "" {
+				output += val + "\n"
+			}
+		}
+	}
+	return output
+}
+
 // ErrorMessagesOfStep ...
 func (r Results) ErrorMessagesOfStep(name string) string {
 	if step, ok := r[name]; ok {
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	pb "github.com/CS-SI/SafeScale/lib"
+	"github.com/CS-SI/SafeScale/lib/client"
+	"github.com/CS-SI/SafeScale/lib/server/install/enums/action"
+	"github.com/CS-SI/SafeScale/lib/utils/temporal"
+)
+
+// secretsRef returns the reference of the secrets of the feature installed on the target, stored by safescaled in
+// the metadata of the tenant
+func secretsRef(f *Feature, t Target) *pb.FeatureSecretsRef {
+	return &pb.FeatureSecretsRef{
+		TargetType: t.Type(),
+		TargetName: t.Name(),
+		Feature:    f.DisplayName(),
+	}
+}
+
+// resolveSecrets sets the value of the secret parameters left without value: the value stored during a previous
+// action, or a new random one for Add and Upgrade. The secrets used by Add and Upgrade are stored, so the next
+// actions reuse them
+func (f *Feature) resolveSecrets(t Target, params []*parameter, unset []*parameter, v Variables, a action.Enum, s Settings) error {
+	// A dry run masks the secrets, no need to look for them
+	if s.DryRun != nil {
+		for _, p := range unset {
+			v[p.name] = secretValue(maskedValue)
+		}
+		return nil
+	}
+	store := a == action.Add || a == action.Upgrade
+	if !hasSecretParameter(params) || (len(unset) == 0 && !store) {
+		return nil
+	}
+
+	tenantClient := client.New().Tenant
+	ref := secretsRef(f, t)
+	stored, err := tenantClient.ReadFeatureSecrets(ref, temporal.GetExecutionTimeout())
+	if err != nil {
+		return err
+	}
+	if !stored.GetKeyDefined() {
+		if len(unset) == 0 {
+			logrus.Warnf("no metadata encryption key defined in tenant, secrets of feature '%s' are not stored", f.DisplayName())
+			return nil
+		}
+		var names []string
+		for _, p := range unset {
+			names = append(names, p.name)
+		}
+		return fmt.Errorf("no value for secret parameter(s) '%s' of feature '%s', and no metadata encryption key ('CryptKey' in section 'metadata' of the tenant) to store generated ones; pass values with -p",
+			strings.Join(names, "', '"), f.DisplayName())
+	}
+
+	secrets := map[string]string{}
+	for _, secret := range stored.GetSecrets() {
+		secrets[secret.GetName()] = secret.GetValue()
+	}
+	for _, p := range unset {
+		if value, ok := secrets[p.name]; ok {
+			v[p.name] = secretValue(value)
+			continue
+		}
+		if !store {
+			if p.required {
+				return fmt.Errorf("missing value for secret parameter '%s'", p.name)
+			}
+			v[p.name] = secretValue("")
+			continue
+		}
+		value, err := generateSecret(p.length)
+		if err != nil {
+			return err
+		}
+		v[p.name] = secretValue(value)
+	}
+	if !store {
+		return nil
+	}
+
+	changed := false
+	for _, p := range params {
+		if p.kind != parameterSecret {
+			continue
+		}
+		value := fmt.Sprintf("%v", v[p.name])
+		if secrets[p.name] != value {
+			secrets[p.name] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	updated := &pb.FeatureSecrets{Ref: ref, KeyDefined: true}
+	for name, value := range secrets {
+		updated.Secrets = append(updated.Secrets, &pb.FeatureSecret{Name: name, Value: value})
+	}
+	return tenantClient.WriteFeatureSecrets(updated, temporal.GetExecutionTimeout())
+}
+
+// deleteSecrets removes the secrets stored for the feature installed on the target
+func (f *Feature) deleteSecrets(t Target) error {
+	params, err := parseParameters(f.specs)
+	if err != nil || !hasSecretParameter(params) {
+		return err
+	}
+	return client.New().Tenant.DeleteFeatureSecrets(secretsRef(f, t), temporal.GetExecutionTimeout())
+}
+
+// hasSecretParameter tells if one of the parameters is a secret
+func hasSecretParameter(params []*parameter) bool {
+	for _, p := range params {
+		if p.kind == parameterSecret {
+			return true
+		}
+	}
+	return false
+}
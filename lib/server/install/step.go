@@ -42,9 +42,13 @@ const (
 )
 
 type stepResult struct {
-	completed bool  // if true, the script has been run to completion
-	success   bool  // if true, the script has been run successfully and the result is a success
-	err       error // if an error occured, contains the err
+	completed   bool   // if true, the script has been run to completion
+	success     bool   // if true, the script has been run successfully and the result is a success
+	err         error  // if an error occured, contains the err
+	output      string // if not empty, contains information about the result (like the state of an Helm release)
+	attempts    int    // number of runs of the script, more than 1 if the step has been retried
+	rolledBack  bool   // if true, the rollback script of the step has been run after the failure of the action
+	rollbackErr error  // if the rollback failed, contains the error
 }
 
 func (sr stepResult) Successful() bool {
@@ -59,6 +63,25 @@ func (sr stepResult) Error() error {
 	return sr.err
 }
 
+func (sr stepResult) Output() string {
+	return sr.output
+}
+
+// Attempts returns the number of runs of the script
+func (sr stepResult) Attempts() int {
+	return sr.attempts
+}
+
+// RolledBack tells if the rollback script of the step has been run on the host
+func (sr stepResult) RolledBack() bool {
+	return sr.rolledBack
+}
+
+// RollbackError returns the error of the rollback, if it failed
+func (sr stepResult) RollbackError() error {
+	return sr.rollbackErr
+}
+
 func (sr stepResult) ErrorMessage() string {
 	if sr.err != nil {
 		return sr.err.Error()
@@ -81,6 +104,22 @@ func (s StepResults) ErrorMessages() string {
 	return output
 }
 
+// RollbackMessages returns a string telling on which hosts the step has been rolled back, and the result
+func (s StepResults) RollbackMessages() string {
+	output := ""
+	for h, k := range s {
+		if !k.RolledBack() {
+			continue
+		}
+		if k.RollbackError() != nil {
+			output += h + ": rollback failed: " + k.RollbackError().Error() + "\n"
+		} else {
+			output += h + ": rolled back\n"
+		}
+	}
+	return output
+}
+
 // UncompletedEntries returns an array of string of all keys where the script
 // to run action wasn't completed
 func (s StepResults) UncompletedEntries() []string {
@@ -252,6 +291,10 @@ type step struct {
 	Script string
 	// WallTime contains the maximum time the step must run
 	WallTime time.Duration
+	// Retries is the number of times a failing script is run again
+	Retries int
+	// RetryDelay is the delay before running again a failing script
+	RetryDelay time.Duration
 	// YamlKey contains the root yaml key on the specification file
 	YamlKey string
 	// OptionsFileContent contains the "options file" if it exists (for DCOS cluster for now)
@@ -401,9 +444,16 @@ func (is *step) taskRunOnHost(t concurrency.Task, params concurrency.TaskParamet
 		}
 	}
 
+	// A script containing secrets is readable only by its owner, and removed once run
+	withSecrets := len(secretValues(variables)) > 0
+	rights := ""
+	if withSecrets {
+		rights = "u+rw-x,go-rwx"
+	}
+
 	// Uploads then executes command
 	filename := fmt.Sprintf("%s/feature.%s.%s_%s.sh", utils.TempFolder, is.Worker.feature.DisplayName(), strings.ToLower(is.Action.String()), is.Name)
-	err = UploadStringToRemoteFile(command, host, filename, "", "", "")
+	err = UploadStringToRemoteFile(command, host, filename, "", "", rights)
 	if err != nil {
 		return stepResult{err: err}, nil
 	}
@@ -411,15 +461,33 @@ func (is *step) taskRunOnHost(t concurrency.Task, params concurrency.TaskParamet
 	//command = fmt.Sprintf("sudo bash %s; rc=$?; if [[ rc -eq 0 ]]; then sudo rm -f %s %s/options.json; fi; exit $rc", filename, filename, srvutils.TempFolder)
 	command = fmt.Sprintf("sudo bash %s; rc=$?; exit $rc", filename)
 
-	// Executes the script on the remote host
-	retcode, _, _, err := client.New().SSH.Run(host.Name, command, outputs.COLLECT, temporal.GetConnectionTimeout(), is.WallTime)
+	// Executes the script on the remote host, again after a delay while it fails if the step allows retries
+	var retcode, attempts int
+	for {
+		attempts++
+		retcode, _, _, err = client.New().SSH.Run(host.Name, command, outputs.COLLECT, temporal.GetConnectionTimeout(), is.WallTime)
+		if (err == nil && retcode == 0) || attempts > is.Retries {
+			break
+		}
+		log.Warnf("step '%s' of feature '%s' failed on host '%s' (attempt %d/%d), retrying in %s",
+			is.Name, is.Worker.feature.DisplayName(), host.Name, attempts, is.Retries+1, temporal.FormatDuration(is.RetryDelay))
+		time.Sleep(is.RetryDelay)
+	}
+	if withSecrets {
+		_, _, _, rerr := client.New().SSH.Run(host.Name, "sudo rm -f "+filename, outputs.COLLECT, temporal.GetConnectionTimeout(), temporal.GetExecutionTimeout())
+		if rerr != nil {
+			log.Warnf("failed to remove script '%s' containing secrets from host '%s': %v", filename, host.Name, rerr)
+		}
+	}
 	if err != nil {
-		return stepResult{err: err}, nil
+		return stepResult{err: err, attempts: attempts}, nil
 	}
-	err = nil
 	ok = retcode == 0
 	if !ok {
 		err = fmt.Errorf("failure: retcode=%d", retcode)
+		if attempts > 1 {
+			err = fmt.Errorf("failure after %d attempts: retcode=%d", attempts, retcode)
+		}
 	}
-	return stepResult{success: ok, completed: true, err: err}, nil
+	return stepResult{success: ok, completed: true, err: err, attempts: attempts}, nil
 }
@@ -17,6 +17,8 @@
 package install
 
 import (
+	"github.com/sirupsen/logrus"
+
 	"github.com/CS-SI/SafeScale/lib/server/install/enums/method"
 	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
 	"github.com/CS-SI/SafeScale/lib/utils/scerr"
@@ -81,6 +83,8 @@ func createHostTarget(host *pb.Host) (*HostTarget, error) {
 	//}
 	index++
 	methods[index] = method.Bash
+	index++
+	methods[index] = method.Ansible
 	return &HostTarget{
 		host:    host,
 		methods: methods,
@@ -103,10 +107,14 @@ func (t *HostTarget) Methods() map[uint8]method.Enum {
 	return t.methods
 }
 
-// Installed returns a list of installed features
+// Installed returns a list of installed features, as recorded in the metadata of the host
 func (t *HostTarget) Installed() []string {
-	var list []string
-	return list
+	list, err := listInstalledOnHost(t.host)
+	if err != nil {
+		logrus.Warnf("failed to list the features installed on host '%s': %v", t.name, err)
+		return []string{}
+	}
+	return sortedNames(list)
 }
 
 // ClusterTarget defines a target of type Host, satisfying TargetAPI
@@ -130,8 +138,14 @@ func NewClusterTarget(task concurrency.Task, cluster clusterapi.Cluster) (Target
 		index++
 		methods[index] = method.DCOS
 	}
+	if identity.Flavor == flavor.K8S {
+		index++
+		methods[index] = method.Helm
+	}
 	index++
 	methods[index] = method.Bash
+	index++
+	methods[index] = method.Ansible
 	return &ClusterTarget{
 		cluster: cluster,
 		methods: methods,
@@ -154,10 +168,14 @@ func (t *ClusterTarget) Methods() map[uint8]method.Enum {
 	return t.methods
 }
 
-// Installed returns a list of installed feature
+// Installed returns a list of installed feature, as recorded in the metadata of the cluster
 func (t *ClusterTarget) Installed() []string {
-	var list []string
-	return list
+	list, err := listInstalledOnCluster(concurrency.RootTask(), t.cluster)
+	if err != nil {
+		logrus.Warnf("failed to list the features installed on cluster '%s': %v", t.name, err)
+		return []string{}
+	}
+	return sortedNames(list)
 }
 
 // NodeTarget defines a target of type Node of cluster, including a master
@@ -29,6 +29,7 @@ import (
 
 	pb "github.com/CS-SI/SafeScale/lib"
 	"github.com/CS-SI/SafeScale/lib/client"
+	"github.com/CS-SI/SafeScale/lib/server/install/enums/action"
 	"github.com/CS-SI/SafeScale/lib/system"
 	"github.com/CS-SI/SafeScale/lib/utils"
 	"github.com/CS-SI/SafeScale/lib/utils/cli/enums/outputs"
@@ -397,21 +398,18 @@ func determineContext(t Target) (hT *HostTarget, cT *ClusterTarget, nT *NodeTarg
 	return
 }
 
-// Check if required parameters defined in specification file have been set in 'v'
-func checkParameters(f *Feature, v Variables) error {
-	if f.specs.IsSet("feature.parameters") {
-		params := f.specs.GetStringSlice("feature.parameters")
-		for _, k := range params {
-			splitted := strings.Split(k, "=")
-			if _, ok := v[splitted[0]]; !ok {
-				if len(splitted) == 1 {
-					return fmt.Errorf("missing value for parameter '%s'", k)
-				}
-				v[splitted[0]] = strings.Join(splitted[1:], "=")
-			}
-		}
+// checkParameters validates the values of the parameters defined in specification file and sets the default
+// ones, then resolves the secrets left without value
+func (f *Feature) checkParameters(t Target, v Variables, a action.Enum, s Settings) error {
+	params, err := parseParameters(f.specs)
+	if err != nil {
+		return fmt.Errorf("syntax error in feature '%s' specification file (%s): %s", f.DisplayName(), f.DisplayFilename(), err.Error())
 	}
-	return nil
+	unset, err := checkParameterValues(params, v)
+	if err != nil {
+		return err
+	}
+	return f.resolveSecrets(t, params, unset, v, a, s)
 }
 
 func gatewayFromHost(host *pb.Host) *pb.Host {
@@ -0,0 +1,534 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/viper"
+
+	clusterpropsv1 "github.com/CS-SI/SafeScale/lib/server/cluster/control/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/cluster/enums/flavor"
+	"github.com/CS-SI/SafeScale/lib/server/install/enums/method"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+)
+
+var (
+	// validFeatureKeys lists the keys allowed directly under 'feature' (viper lowers the case of the keys)
+	validFeatureKeys = map[string]bool{
+		"version":      true,
+		"suitablefor":  true,
+		"requirements": true,
+		"parameters":   true,
+		"install":      true,
+		"proxy":        true,
+	}
+	// validStepKeys lists the keys allowed in a step
+	validStepKeys = map[string]bool{
+		yamlTargetsKeyword:    true,
+		yamlRunKeyword:        true,
+		yamlPackageKeyword:    true,
+		yamlOptionsKeyword:    true,
+		yamlTimeoutKeyword:    true,
+		yamlSerialKeyword:     true,
+		yamlRetriesKeyword:    true,
+		yamlPlaybookKeyword:   true,
+		yamlRolesKeyword:      true,
+		yamlGalaxyKeyword:     true,
+		yamlRetryDelayKeyword: true,
+		yamlRollbackKeyword:   true,
+	}
+	// validTargetKeys lists the keys allowed in 'targets'
+	validTargetKeys = map[string]bool{
+		targetHosts:    true,
+		targetMasters:  true,
+		targetNodes:    true,
+		targetGateways: true,
+	}
+	// implicitVariables lists the variables set by SafeScale, usable by the templates of any feature
+	implicitVariables = []string{
+		"ClusterName", "ClusterComplexity", "ClusterFlavor", "ClusterAdminUsername", "ClusterAdminPassword",
+		"PrimaryGatewayIP", "SecondaryGatewayIP", "DefaultRouteIP", "GatewayIP", "PrimaryPublicIP", "SecondaryPublicIP",
+		"EndpointIP", "PublicIP", "CIDR", "ControlplaneEndpointIP", "Username", "HostIP", "Hostname", "options",
+		"FeatureVersion",
+	}
+)
+
+// NewFeatureFromFile initializes a new Feature object with the content of the specification file 'path'
+func NewFeatureFromFile(task concurrency.Task, path string) (*Feature, error) {
+	if task == nil {
+		return nil, scerr.InvalidParameterError("task", "cannot be nil")
+	}
+	if path == "" {
+		return nil, scerr.InvalidParameterError("path", "cannot be empty string")
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	err := v.ReadInConfig()
+	if err != nil {
+		return nil, scerr.SyntaxError(fmt.Sprintf("failed to read the specification file '%s': %s", path, err.Error()))
+	}
+	base := filepath.Base(path)
+	return &Feature{
+		fileName:    base,
+		displayName: strings.TrimSuffix(base, filepath.Ext(base)),
+		specs:       v,
+		task:        task,
+	}, nil
+}
+
+// featureValidator accumulates the problems found in the specification of a feature
+type featureValidator struct {
+	feature   *Feature
+	variables Variables
+	errors    []error
+	warnings  []string
+}
+
+func (fv *featureValidator) errorf(format string, args ...interface{}) {
+	fv.errors = append(fv.errors, fmt.Errorf(format, args...))
+}
+
+func (fv *featureValidator) warnf(format string, args ...interface{}) {
+	fv.warnings = append(fv.warnings, fmt.Sprintf(format, args...))
+}
+
+// Validate checks the specification of the feature without installing anything: structure, consistency between
+// pace and steps, targets, requirements, and templates of the steps rendered with the declared parameters.
+// Returns the warnings and the errors found.
+func (f *Feature) Validate() ([]string, []error) {
+	if f == nil {
+		return nil, []error{scerr.InvalidInstanceError()}
+	}
+	fv := &featureValidator{feature: f}
+
+	if !f.specs.IsSet("feature") {
+		fv.errorf("the specification file must begin with 'feature:'")
+		return fv.warnings, fv.errors
+	}
+	for k := range f.specs.GetStringMap("feature") {
+		if !validFeatureKeys[k] {
+			fv.warnf("unknown key 'feature.%s', ignored", k)
+		}
+	}
+
+	fv.validateVersion()
+	fv.validateSuitableFor()
+	fv.validateRequirements()
+	fv.validateParameters()
+	fv.variables = fv.sampleVariables()
+	fv.validateInstall()
+	fv.validateProxy()
+
+	return fv.warnings, fv.errors
+}
+
+func (fv *featureValidator) validateVersion() {
+	if !fv.feature.specs.IsSet("feature.version") {
+		return
+	}
+	// YAML reads 1.10 as the number 1.1
+	if _, ok := fv.feature.specs.Get("feature.version").(string); !ok {
+		fv.warnf("'feature.version' should be quoted to be read as a string (read as '%s')", fv.feature.specs.GetString("feature.version"))
+	}
+	if strings.ContainsAny(fv.feature.Version(), " \t") {
+		fv.errorf("invalid value '%s' for 'feature.version'", fv.feature.Version())
+	}
+}
+
+func (fv *featureValidator) validateSuitableFor() {
+	specs := fv.feature.specs
+	if !specs.IsSet("feature.suitableFor") {
+		fv.errorf("missing key 'feature.suitableFor'")
+		return
+	}
+	if specs.IsSet("feature.suitableFor.host") {
+		switch strings.ToLower(specs.GetString("feature.suitableFor.host")) {
+		case "ok", "yes", "true", "1", "no", "false", "0":
+		default:
+			fv.errorf("invalid value '%s' for 'feature.suitableFor.host'", specs.GetString("feature.suitableFor.host"))
+		}
+	}
+	if specs.IsSet("feature.suitableFor.cluster") {
+		for _, k := range strings.Split(specs.GetString("feature.suitableFor.cluster"), ",") {
+			k = strings.ToLower(strings.TrimSpace(k))
+			if k == "all" || k == "no" || k == "false" {
+				continue
+			}
+			if _, err := flavor.Parse(k); err != nil {
+				fv.errorf("invalid value '%s' for 'feature.suitableFor.cluster'", k)
+			}
+		}
+	}
+}
+
+func (fv *featureValidator) validateRequirements() {
+	for _, requirement := range fv.feature.specs.GetStringSlice("feature.requirements.features") {
+		if requirement == fv.feature.DisplayName() {
+			fv.errorf("feature requires itself")
+			continue
+		}
+		if _, err := NewFeature(concurrency.RootTask(), requirement); err != nil {
+			fv.errorf("required feature '%s' not found", requirement)
+		}
+	}
+}
+
+func (fv *featureValidator) validateParameters() {
+	if _, err := parseParameters(fv.feature.specs); err != nil {
+		fv.errorf("%s", err.Error())
+	}
+}
+
+// sampleVariables returns variables with a sample value for the implicit variables and the declared parameters
+// (their default value when there is one), to render the templates
+func (fv *featureValidator) sampleVariables() Variables {
+	v := Variables{}
+	for _, k := range implicitVariables {
+		v[k] = "sample" + k
+	}
+	v["ControlplaneUsesVIP"] = true
+	for _, k := range []string{"ClusterMasters", "ClusterNodes"} {
+		v[k] = []*clusterpropsv1.Node{{ID: "sampleID", Name: "sampleName", PublicIP: "192.0.2.1", PrivateIP: "10.0.0.1"}}
+	}
+	for _, k := range []string{"ClusterMasterNames", "ClusterMasterIDs", "ClusterMasterIPs", "ClusterNodeNames", "ClusterNodeIDs", "ClusterNodeIPs"} {
+		v[k] = []string{"sample" + k}
+	}
+	params, _ := parseParameters(fv.feature.specs)
+	for _, p := range params {
+		switch {
+		case p.hasDefault:
+			v[p.name] = p.value
+		case p.kind == parameterSecret:
+			v[p.name] = secretValue("sample" + p.name)
+		case p.kind == parameterInt:
+			v[p.name] = "0"
+		case p.kind == parameterBool:
+			v[p.name] = "false"
+		case p.kind == parameterEnum:
+			v[p.name] = p.values[0]
+		default:
+			v[p.name] = "sample" + p.name
+		}
+	}
+	// Each proxy rule of type 'service' defines a variable named as the rule
+	if rules, ok := fv.feature.specs.Get("feature.proxy.rules").([]interface{}); ok {
+		for _, r := range rules {
+			if rule, ok := r.(map[interface{}]interface{}); ok {
+				if name, ok := rule["name"].(string); ok && rule["type"] == "service" {
+					v[name] = "sampleServiceID"
+				}
+			}
+		}
+	}
+	if realized, err := realizeVariables(v); err == nil {
+		return realized
+	}
+	return v
+}
+
+// validateTemplate renders the template 'content' with sample variables, failing on undefined variables
+func (fv *featureValidator) validateTemplate(key, content string) {
+	// '}}}}' or '{{{{' is not a syntax error for text/template, but is always a typo in a feature
+	if strings.Contains(content, "}}}}") || strings.Contains(content, "{{{{") {
+		fv.errorf("'%s': stray template delimiters ('{{{{' or '}}}}')", key)
+	}
+	tmpl, err := template.New(key).Option("missingkey=error").Parse(content)
+	if err != nil {
+		fv.errorf("'%s': failed to parse template: %s", key, err.Error())
+		return
+	}
+	err = tmpl.Execute(bytes.NewBufferString(""), fv.variables)
+	if err != nil {
+		fv.errorf("'%s': failed to render template: %s", key, err.Error())
+	}
+}
+
+func (fv *featureValidator) validateInstall() {
+	specs := fv.feature.specs
+	installers := specs.GetStringMap("feature.install")
+	if len(installers) == 0 {
+		fv.errorf("'feature.install' defines no method")
+		return
+	}
+
+	var keys []string
+	for k := range installers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		m, err := method.Parse(k)
+		if err != nil {
+			fv.errorf("unknown install method '%s'", k)
+			continue
+		}
+		if m == method.Helm {
+			fv.validateHelm()
+			continue
+		}
+		rootKey := "feature.install." + k
+		actions, ok := installers[k].(map[string]interface{})
+		if !ok {
+			fv.errorf("'%s' must define actions 'check', 'add' and 'remove'", rootKey)
+			continue
+		}
+		for a := range actions {
+			switch a {
+			case "check", "add", "remove", "upgrade":
+			default:
+				fv.errorf("unknown action '%s.%s'", rootKey, a)
+			}
+		}
+		for _, a := range []string{"check", "add", "remove"} {
+			if _, ok := actions[a]; !ok {
+				if a == "remove" {
+					fv.warnf("no action '%s.%s' defined, feature will not be removable", rootKey, a)
+				} else {
+					fv.errorf("missing action '%s.%s'", rootKey, a)
+				}
+				continue
+			}
+			fv.validateAction(m, rootKey+"."+a)
+		}
+		if _, ok := actions["upgrade"]; ok {
+			fv.validateUpgrade(rootKey + ".upgrade")
+			// InstalledVersion is only defined during an upgrade
+			fv.variables["InstalledVersion"] = "sampleInstalledVersion"
+			fv.validateAction(m, rootKey+".upgrade")
+			delete(fv.variables, "InstalledVersion")
+		}
+	}
+}
+
+// validateUpgrade checks the version constraints of the action 'upgrade'
+func (fv *featureValidator) validateUpgrade(upgradeKey string) {
+	if fv.feature.Version() == "" {
+		fv.warnf("'%s' is defined but 'feature.version' is not, installed versions cannot be compared", upgradeKey)
+	}
+	for _, k := range []string{yamlFromKeyword, yamlToKeyword} {
+		constraint := strings.TrimSpace(fv.feature.specs.GetString(upgradeKey + "." + k))
+		if constraint == "" {
+			continue
+		}
+		if _, err := matchVersion(constraint, "0"); err != nil {
+			fv.errorf("'%s.%s': %s", upgradeKey, k, err.Error())
+			continue
+		}
+		if k == yamlToKeyword && fv.feature.Version() != "" {
+			if ok, _ := matchVersion(constraint, fv.feature.Version()); !ok {
+				fv.errorf("'%s.%s' ('%s') is not satisfied by 'feature.version' ('%s')", upgradeKey, k, constraint, fv.feature.Version())
+			}
+		}
+	}
+}
+
+func (fv *featureValidator) validateAction(m method.Enum, actionKey string) {
+	specs := fv.feature.specs
+	if _, ok := specs.Get(actionKey).(map[string]interface{}); !ok {
+		fv.errorf("'%s' must define '%s' and '%s'", actionKey, yamlPaceKeyword, yamlStepsKeyword)
+		return
+	}
+
+	pace := strings.TrimSpace(specs.GetString(actionKey + "." + yamlPaceKeyword))
+	if pace == "" {
+		fv.errorf("missing or empty key '%s.%s'", actionKey, yamlPaceKeyword)
+	}
+	steps := specs.GetStringMap(actionKey + "." + yamlStepsKeyword)
+	if len(steps) == 0 {
+		fv.errorf("missing or empty key '%s.%s'", actionKey, yamlStepsKeyword)
+		return
+	}
+
+	inPace := map[string]bool{}
+	if pace != "" {
+		for _, s := range strings.Split(pace, ",") {
+			s = strings.ToLower(strings.TrimSpace(s))
+			if _, ok := steps[s]; !ok {
+				fv.errorf("step '%s' listed in '%s.%s' is not defined in '%s.%s'", s, actionKey, yamlPaceKeyword, actionKey, yamlStepsKeyword)
+			}
+			inPace[s] = true
+		}
+	}
+
+	var names []string
+	for s := range steps {
+		names = append(names, s)
+	}
+	sort.Strings(names)
+	for _, s := range names {
+		stepKey := actionKey + "." + yamlStepsKeyword + "." + s
+		if !inPace[s] {
+			fv.warnf("step '%s' is not listed in '%s.%s', it will never be run", stepKey, actionKey, yamlPaceKeyword)
+		}
+		stepMap, ok := steps[s].(map[string]interface{})
+		if !ok {
+			fv.errorf("'%s' must be a map", stepKey)
+			continue
+		}
+		fv.validateStep(m, stepKey, stepMap)
+	}
+}
+
+func (fv *featureValidator) validateStep(m method.Enum, stepKey string, stepMap map[string]interface{}) {
+	for k := range stepMap {
+		if !validStepKeys[k] {
+			fv.warnf("unknown key '%s.%s', ignored", stepKey, k)
+		}
+	}
+
+	// targets
+	anon, ok := stepMap[yamlTargetsKeyword]
+	if !ok {
+		fv.errorf("missing key '%s.%s'", stepKey, yamlTargetsKeyword)
+	} else if targets, ok := anon.(map[string]interface{}); !ok {
+		fv.errorf("'%s.%s' must be a map", stepKey, yamlTargetsKeyword)
+	} else {
+		stepT := stepTargets{}
+		for k, v := range targets {
+			if !validTargetKeys[k] {
+				fv.errorf("unknown target '%s' in '%s.%s'", k, stepKey, yamlTargetsKeyword)
+				continue
+			}
+			switch v := v.(type) {
+			case bool:
+				stepT[k] = strconv.FormatBool(v)
+			case string:
+				stepT[k] = v
+			case int:
+				stepT[k] = strconv.Itoa(v)
+			default:
+				fv.errorf("invalid value '%v' for target '%s' in '%s.%s'", v, k, stepKey, yamlTargetsKeyword)
+			}
+		}
+		if _, _, _, _, err := stepT.parse(); err != nil {
+			fv.errorf("'%s.%s': %s", stepKey, yamlTargetsKeyword, err.Error())
+		}
+	}
+
+	// content of the step
+	switch m {
+	case method.Ansible:
+		_, hasPlaybook := stepMap[yamlPlaybookKeyword]
+		_, hasRoles := stepMap[yamlRolesKeyword]
+		if !hasPlaybook && !hasRoles {
+			fv.errorf("missing key '%s.%s' or '%s.%s'", stepKey, yamlPlaybookKeyword, stepKey, yamlRolesKeyword)
+		}
+	default:
+		keyword := yamlRunKeyword
+		switch m {
+		case method.Apt, method.Yum, method.Dnf:
+			keyword = yamlPackageKeyword
+		}
+		anon, ok := stepMap[keyword]
+		if !ok {
+			fv.errorf("missing key '%s.%s'", stepKey, keyword)
+		} else if content, ok := anon.(string); !ok {
+			fv.errorf("'%s.%s' must be a string", stepKey, keyword)
+		} else {
+			fv.validateTemplate(stepKey+"."+keyword, content)
+		}
+	}
+
+	if anon, ok := stepMap[yamlTimeoutKeyword]; ok {
+		if _, err := stepDuration(anon, time.Minute); err != nil {
+			fv.errorf("invalid value '%v' for '%s.%s', must be a number of minutes or a duration (like '90s')", anon, stepKey, yamlTimeoutKeyword)
+		}
+	}
+	if anon, ok := stepMap[yamlRetriesKeyword]; ok {
+		if _, err := stepRetries(anon); err != nil {
+			fv.errorf("invalid value '%v' for '%s.%s', must be a positive number", anon, stepKey, yamlRetriesKeyword)
+		}
+	}
+	if anon, ok := stepMap[yamlRetryDelayKeyword]; ok {
+		if _, err := stepDuration(anon, time.Second); err != nil {
+			fv.errorf("invalid value '%v' for '%s.retryDelay', must be a number of seconds or a duration (like '2m')", anon, stepKey)
+		}
+	}
+	if anon, ok := stepMap[yamlRollbackKeyword]; ok {
+		if content, ok := anon.(string); !ok {
+			fv.errorf("'%s.%s' must be a string", stepKey, yamlRollbackKeyword)
+		} else {
+			fv.validateTemplate(stepKey+"."+yamlRollbackKeyword, content)
+		}
+	}
+}
+
+func (fv *featureValidator) validateHelm() {
+	specs := fv.feature.specs
+	if specs.GetString(helmRootKey+".chart") == "" {
+		fv.errorf("missing key '%s.chart'", helmRootKey)
+	}
+	if specs.IsSet(helmRootKey + ".values") {
+		if _, ok := specs.Get(helmRootKey + ".values").(string); !ok {
+			fv.errorf("'%s.values' must be a string containing a YAML document", helmRootKey)
+		}
+	}
+	for _, k := range []string{"release", "chart", "version", "namespace", "repo.name", "repo.url", "values"} {
+		if content := specs.GetString(helmRootKey + "." + k); content != "" {
+			fv.validateTemplate(helmRootKey+"."+k, content)
+		}
+	}
+	if specs.IsSet(helmRootKey + ".upgrade") {
+		fv.validateUpgrade(helmRootKey + ".upgrade")
+	}
+}
+
+func (fv *featureValidator) validateProxy() {
+	anon := fv.feature.specs.Get("feature.proxy.rules")
+	if anon == nil {
+		return
+	}
+	rules, ok := anon.([]interface{})
+	if !ok {
+		fv.errorf("'feature.proxy.rules' must be a list")
+		return
+	}
+	for i, r := range rules {
+		ruleKey := fmt.Sprintf("feature.proxy.rules[%d]", i)
+		rule, ok := r.(map[interface{}]interface{})
+		if !ok {
+			fv.errorf("'%s' must be a map", ruleKey)
+			continue
+		}
+		if name, ok := rule["name"].(string); !ok || name == "" {
+			fv.errorf("missing key '%s.name'", ruleKey)
+		} else {
+			ruleKey = fmt.Sprintf("feature.proxy.rules[%s]", name)
+		}
+		switch rule["type"] {
+		case "service", "route", "upstream":
+		default:
+			fv.errorf("invalid value '%v' for '%s.type'", rule["type"], ruleKey)
+		}
+		if content, ok := rule["content"].(string); !ok {
+			fv.errorf("missing key '%s.content'", ruleKey)
+		} else {
+			fv.validateTemplate(ruleKey+".content", content)
+		}
+	}
+}
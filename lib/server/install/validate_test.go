@@ -0,0 +1,191 @@
+package install
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+)
+
+// legacyFeatures lists the embedded features still written with the obsolete format (scripts without pace and steps)
+var legacyFeatures = map[string]bool{
+	"apache-ignite":     true,
+	"mpich-build":       true,
+	"mpich-ospkg":       true,
+	"ohpc-slurm-master": true,
+	"ohpc-slurm-node":   true,
+}
+
+func featureFromString(t *testing.T, content string) *Feature {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	err := v.ReadConfig(bytes.NewBufferString(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Feature{displayName: "test", fileName: "test.yml", specs: v, task: concurrency.RootTask()}
+}
+
+func errorsToString(errs []error) string {
+	var msgs []string
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+const validFeatureSpec = `
+feature:
+    suitableFor:
+        host: yes
+        cluster: all
+    parameters:
+        - Version=1.0
+    install:
+        bash:
+            check:
+                pace: pkg
+                steps:
+                    pkg:
+                        targets:
+                            hosts: yes
+                            masters: all
+                        run: |
+                            test -f /opt/test-{{ .Version }} || sfFail 1
+            add:
+                pace: pkg
+                steps:
+                    pkg:
+                        targets:
+                            hosts: yes
+                            masters: all
+                        run: |
+                            touch /opt/test-{{ .Version }} && echo {{ .HostIP }}
+            remove:
+                pace: pkg
+                steps:
+                    pkg:
+                        targets:
+                            hosts: yes
+                            masters: all
+                        run: |
+                            rm -f /opt/test-{{ .Version }}
+`
+
+func TestFeature_Validate_Embedded(t *testing.T) {
+	for name, f := range allEmbeddedMap {
+		if legacyFeatures[name] {
+			continue
+		}
+		feat := *f
+		feat.task = concurrency.RootTask()
+		_, errs := feat.Validate()
+		assert.Empty(t, errs, "embedded feature '%s' is invalid:\n%s", name, errorsToString(errs))
+	}
+}
+
+func TestFeature_Validate_Valid(t *testing.T) {
+	warnings, errs := featureFromString(t, validFeatureSpec).Validate()
+	assert.Empty(t, errs)
+	assert.Empty(t, warnings)
+}
+
+func TestFeature_Validate_StrayDelimiters(t *testing.T) {
+	spec := strings.Replace(validFeatureSpec, "touch /opt/test-{{ .Version }}", "touch /opt/test-{{ .Version }}}}", 1)
+	_, errs := featureFromString(t, spec).Validate()
+	if assert.NotEmpty(t, errs) {
+		assert.Contains(t, errorsToString(errs), "stray template delimiters")
+	}
+}
+
+func TestFeature_Validate_UndeclaredParameter(t *testing.T) {
+	spec := strings.Replace(validFeatureSpec, "rm -f /opt/test-{{ .Version }}", "rm -f /opt/test-{{ .Release }}", 1)
+	_, errs := featureFromString(t, spec).Validate()
+	if assert.NotEmpty(t, errs) {
+		assert.Contains(t, errorsToString(errs), "Release")
+	}
+}
+
+func TestFeature_Validate_PaceWithoutStep(t *testing.T) {
+	spec := strings.Replace(validFeatureSpec, "pace: pkg\n                steps:\n                    pkg:\n                        targets:\n                            hosts: yes\n                            masters: all\n                        run: |\n                            touch", "pace: pkgs\n                steps:\n                    pkg:\n                        targets:\n                            hosts: yes\n                            masters: all\n                        run: |\n                            touch", 1)
+	warnings, errs := featureFromString(t, spec).Validate()
+	if assert.NotEmpty(t, errs) {
+		assert.Contains(t, errorsToString(errs), "'pkgs'")
+	}
+	assert.NotEmpty(t, warnings)
+}
+
+func TestFeature_Validate_InvalidTarget(t *testing.T) {
+	spec := strings.Replace(validFeatureSpec, "masters: all", "masters: some", 1)
+	spec = strings.Replace(spec, "hosts: yes", "host: yes", 1)
+	_, errs := featureFromString(t, spec).Validate()
+	if assert.NotEmpty(t, errs) {
+		assert.Contains(t, errorsToString(errs), "invalid value 'some'")
+		assert.Contains(t, errorsToString(errs), "unknown target 'host'")
+	}
+}
+
+func TestFeature_Validate_MissingRequirement(t *testing.T) {
+	spec := strings.Replace(validFeatureSpec, "    parameters:", "    requirements:\n        features:\n            - not-a-feature\n    parameters:", 1)
+	_, errs := featureFromString(t, spec).Validate()
+	if assert.NotEmpty(t, errs) {
+		assert.Contains(t, errorsToString(errs), "'not-a-feature'")
+	}
+}
+
+func TestFeature_Validate_Version(t *testing.T) {
+	f := featureFromString(t, strings.Replace(validFeatureSpec, "feature:\n", "feature:\n    version: 1.10\n", 1))
+	warnings, errs := f.Validate()
+	assert.Empty(t, errs, errorsToString(errs))
+	assert.Contains(t, strings.Join(warnings, "\n"), "should be quoted")
+
+	f = featureFromString(t, strings.Replace(validFeatureSpec, "feature:\n", "feature:\n    version: '1.10'\n", 1))
+	warnings, errs = f.Validate()
+	assert.Empty(t, errs, errorsToString(errs))
+	assert.Empty(t, warnings)
+	assert.Equal(t, "1.10", f.Version())
+}
+
+const upgradeSpec = `            upgrade:
+                from: '>=1.0, <2.0'
+                to: '2.1'
+                pace: pkg
+                steps:
+                    pkg:
+                        targets:
+                            hosts: yes
+                            masters: all
+                        run: |
+                            mv /opt/test-{{ .InstalledVersion }} /opt/test-{{ .Version }}
+`
+
+func TestFeature_Validate_Upgrade(t *testing.T) {
+	spec := strings.Replace(validFeatureSpec, "feature:\n", "feature:\n    version: '2.1.0'\n", 1) + upgradeSpec
+	warnings, errs := featureFromString(t, spec).Validate()
+	assert.Empty(t, errs, errorsToString(errs))
+	assert.Empty(t, warnings)
+
+	// 'to' must be satisfied by the version of the specification
+	spec = strings.Replace(validFeatureSpec, "feature:\n", "feature:\n    version: '3.0'\n", 1) + upgradeSpec
+	_, errs = featureFromString(t, spec).Validate()
+	if assert.NotEmpty(t, errs) {
+		assert.Contains(t, errorsToString(errs), "is not satisfied by 'feature.version'")
+	}
+
+	spec = strings.Replace(validFeatureSpec, "feature:\n", "feature:\n    version: '2.1.0'\n", 1) + strings.Replace(upgradeSpec, "'>=1.0, <2.0'", "'=>1.0'", 1)
+	_, errs = featureFromString(t, spec).Validate()
+	if assert.NotEmpty(t, errs) {
+		assert.Contains(t, errorsToString(errs), "invalid version constraint")
+	}
+
+	// InstalledVersion is only defined for the upgrade
+	spec = strings.Replace(validFeatureSpec, "rm -f /opt/test-{{ .Version }}", "rm -f /opt/test-{{ .InstalledVersion }}", 1)
+	_, errs = featureFromString(t, spec).Validate()
+	if assert.NotEmpty(t, errs) {
+		assert.Contains(t, errorsToString(errs), "InstalledVersion")
+	}
+}
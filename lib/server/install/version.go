@@ -0,0 +1,103 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package install
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// compareVersions compares 2 versions made of components separated by '.' or '-' (like "1.10.2", "v2.0" or
+// "3.1.0-rc1"); components are compared numerically when both are numbers, lexically otherwise, and missing
+// components count as 0 ("1.2" equals "1.2.0").
+// Returns -1 if a < b, 0 if a == b, 1 if a > b
+func compareVersions(a, b string) int {
+	split := func(v string) []string {
+		v = strings.TrimPrefix(strings.TrimSpace(strings.ToLower(v)), "v")
+		return strings.FieldsFunc(v, func(r rune) bool { return r == '.' || r == '-' })
+	}
+	ca, cb := split(a), split(b)
+	for i := 0; i < len(ca) || i < len(cb); i++ {
+		pa, pb := "0", "0"
+		if i < len(ca) {
+			pa = ca[i]
+		}
+		if i < len(cb) {
+			pb = cb[i]
+		}
+		na, erra := strconv.Atoi(pa)
+		nb, errb := strconv.Atoi(pb)
+		switch {
+		case erra == nil && errb == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case pa != pb:
+			if pa < pb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionOperators lists the comparison operators of version constraints, the longest ones first
+var versionOperators = []string{"==", "!=", "<=", ">=", "=", "<", ">"}
+
+// matchVersion tells if 'version' satisfies 'constraint', made of comparisons separated by commas that all have
+// to be satisfied (like ">=1.2, <2.0"). Operators are '=' (or '=='), '!=', '<', '<=', '>' and '>='; a comparison
+// without operator means equality.
+// Returns an error if the constraint is malformed
+func matchVersion(constraint, version string) (bool, error) {
+	match := true
+	for _, c := range strings.Split(constraint, ",") {
+		c = strings.TrimSpace(c)
+		op := ""
+		for _, o := range versionOperators {
+			if strings.HasPrefix(c, o) {
+				op = o
+				break
+			}
+		}
+		ref := strings.TrimSpace(strings.TrimPrefix(c, op))
+		if ref == "" || strings.ContainsAny(ref, "<>=! \t") {
+			return false, fmt.Errorf("invalid version constraint '%s'", constraint)
+		}
+
+		cmp := compareVersions(version, ref)
+		switch op {
+		case "", "=", "==":
+			match = match && cmp == 0
+		case "!=":
+			match = match && cmp != 0
+		case "<":
+			match = match && cmp < 0
+		case "<=":
+			match = match && cmp <= 0
+		case ">":
+			match = match && cmp > 0
+		case ">=":
+			match = match && cmp >= 0
+		}
+	}
+	return match, nil
+}
@@ -0,0 +1,40 @@
+package install
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, 0, compareVersions("1.2.0", "1.2.0"))
+	assert.Equal(t, 0, compareVersions("1.2", "v1.2.0"))
+	assert.Equal(t, -1, compareVersions("1.2.0", "1.10.0"))
+	assert.Equal(t, 1, compareVersions("2.0", "1.99.99"))
+	assert.Equal(t, -1, compareVersions("", "0.1"))
+	assert.Equal(t, -1, compareVersions("3.1.0-rc1", "3.1.0-rc2"))
+}
+
+func TestMatchVersion(t *testing.T) {
+	ok, err := matchVersion(">=1.2, <2.0", "1.10.3")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = matchVersion(">=1.2, <2.0", "2.0.0")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = matchVersion("1.2", "1.2.0")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = matchVersion("!=1.3.1", "1.3.1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = matchVersion(">=1.2,", "1.2")
+	assert.Error(t, err)
+
+	_, err = matchVersion("=>1.2", "1.2")
+	assert.Error(t, err)
+}
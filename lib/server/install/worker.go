@@ -51,6 +51,13 @@ const (
 	yamlOptionsKeyword = "options"
 	yamlTimeoutKeyword = "timeout"
 	yamlSerialKeyword  = "serialized"
+	yamlRetriesKeyword = "retries"
+	yamlFromKeyword    = "from"
+	yamlToKeyword      = "to"
+
+	// viper lowercases the keys, 'retryDelay' in specification files
+	yamlRetryDelayKeyword = "retrydelay"
+	yamlRollbackKeyword   = "rollback"
 )
 
 type alterCommandCB func(string) string
@@ -80,11 +87,30 @@ type worker struct {
 	concernedNodes    []*pb.Host
 	concernedGateways []*pb.Host
 
+	// host running Ansible for the steps of method Ansible
+	ansibleController *pb.Host
+	// for a check, tells that Ansible is not installed on the controller, so the feature cannot be installed either
+	ansibleMissing bool
+
+	// entry of the dry run report receiving the rendered steps, if the action is a dry run
+	dryRun *DryRunFeature
+
+	// rollback scripts of the steps launched so far, in pace order
+	rollbacks []*rollbackStep
+
 	rootKey string
 	// function to alter the content of 'run' key of specification file
 	commandCB alterCommandCB
 }
 
+// rollbackStep is the rollback script of a step, to run on the hosts of the step if the action fails
+type rollbackStep struct {
+	of        string // name of the step rolled back
+	step      step
+	hosts     []*pb.Host
+	variables Variables
+}
+
 // newWorker ...
 // alterCmdCB is used to change the content of keys 'run' or 'package' before executing
 // the requested action. If not used, must be nil
@@ -178,6 +204,52 @@ func (w *worker) identifyAvailableNode() (*pb.Host, error) {
 	return w.availableNode, nil
 }
 
+// identifyAnsibleController finds the host running Ansible for the whole action (an available master for a cluster,
+// the host itself otherwise), and installs Ansible on it with feature 'ansible' if needed.
+// A check doesn't install anything: it only records in w.ansibleMissing that Ansible is not there
+func (w *worker) identifyAnsibleController() (*pb.Host, error) {
+	if w.ansibleController != nil {
+		return w.ansibleController, nil
+	}
+
+	controller := w.host
+	if w.cluster != nil {
+		var err error
+		controller, err = w.identifyAvailableMaster()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if controller == nil {
+		return nil, resources.ResourceNotAvailableError("host", "")
+	}
+
+	if w.action == action.Check {
+		found, err := ansiblePlaybookInstalled(controller)
+		if err != nil {
+			return nil, err
+		}
+		w.ansibleMissing = !found
+		w.ansibleController = controller
+		return controller, nil
+	}
+
+	feat, err := NewEmbeddedFeature(w.feature.task, "ansible")
+	if err != nil {
+		return nil, err
+	}
+	target, err := NewNodeTarget(controller)
+	if err != nil {
+		return nil, err
+	}
+	_, err = feat.Add(target, Variables{}, Settings{SkipProxy: true, DryRun: w.settings.DryRun})
+	if err != nil {
+		return nil, fmt.Errorf("failed to install Ansible on host '%s': %s", controller.Name, err.Error())
+	}
+	w.ansibleController = controller
+	return controller, nil
+}
+
 // identifyConcernedMasters returns a list of all the hosts acting as masters and keep this list
 // during all the install session
 func (w *worker) identifyConcernedMasters() ([]*pb.Host, error) {
@@ -398,6 +470,7 @@ func (w *worker) identifyAllGateways() ([]*pb.Host, error) {
 func (w *worker) Proceed(v Variables, s Settings) (results Results, err error) {
 	w.variables = v
 	w.settings = s
+	w.rollbacks = nil
 
 	results = Results{}
 
@@ -415,8 +488,13 @@ func (w *worker) Proceed(v Variables, s Settings) (results Results, err error) {
 	}
 	order := strings.Split(pace, ",")
 
-	// Applies reverseproxy rules to make it functional (feature may need it during the install)
-	if w.action == action.Add && !s.SkipProxy {
+	if s.DryRun != nil {
+		w.dryRun = s.DryRun.newFeature(w, order)
+	}
+
+	// Applies reverseproxy rules to make it functional (feature may need it during the install);
+	// an upgrade applies them again, as they may have changed with the version
+	if (w.action == action.Add || w.action == action.Upgrade) && !s.SkipProxy && s.DryRun == nil {
 		if w.cluster != nil {
 			err := w.setReverseProxy()
 			if err != nil {
@@ -454,15 +532,70 @@ func (w *worker) Proceed(v Variables, s Settings) (results Results, err error) {
 		tr, err := subtask.Wait()
 		if tr != nil {
 			result = tr.(*StepResults)
-			results[k] = *result
+			if result != nil {
+				results[k] = *result
+			}
 		}
 		if err != nil {
+			if w.rollback(results) {
+				err = fmt.Errorf("%s\nrollback:\n%s", strings.TrimSpace(err.Error()), results.AllRollbackMessages())
+			}
 			return results, err
 		}
 	}
 	return results, nil
 }
 
+// rollback runs the rollback scripts of the steps launched so far, in reverse pace order, and reports their results
+// in the results of the steps rolled back. Returns true if there was something to roll back
+func (w *worker) rollback(results Results) bool {
+	if w.action == action.Check || w.dryRun != nil || len(w.rollbacks) == 0 {
+		return false
+	}
+	for i := len(w.rollbacks) - 1; i >= 0; i-- {
+		rb := w.rollbacks[i]
+		logrus.Warnf("rolling back step '%s::%s' of feature '%s' on %s '%s'...", w.action.String(), rb.of, w.feature.DisplayName(), w.target.Type(), w.target.Name())
+		r, err := rb.step.Run(rb.hosts, rb.variables, w.settings)
+		if err != nil {
+			logrus.Errorf("failed to roll back step '%s::%s' of feature '%s': %v", w.action.String(), rb.of, w.feature.DisplayName(), err)
+		}
+		stepResults, ok := results[rb.of]
+		if !ok {
+			continue
+		}
+		for _, h := range rb.hosts {
+			sr, ok := stepResults[h.Name]
+			if !ok {
+				continue
+			}
+			sr.rolledBack = true
+			sr.rollbackErr = err
+			if rr, ok := r[h.Name]; ok && !rr.Successful() {
+				sr.rollbackErr = rr.Error()
+				if sr.rollbackErr == nil {
+					sr.rollbackErr = fmt.Errorf("rollback failed")
+				}
+			}
+			stepResults[h.Name] = sr
+		}
+	}
+	return true
+}
+
+// failedStepResults completes the results of a step whose run failed with err, with a failure for each of the hosts
+// it has no result for
+func failedStepResults(r StepResults, hosts []*pb.Host, err error) StepResults {
+	if r == nil {
+		r = StepResults{}
+	}
+	for _, h := range hosts {
+		if _, ok := r[h.Name]; !ok {
+			r[h.Name] = stepResult{err: err}
+		}
+	}
+	return r
+}
+
 // taskLaunchStep starts the step
 func (w *worker) taskLaunchStep(task concurrency.Task, params concurrency.TaskParameters) (_ concurrency.TaskResult, err error) {
 	if w == nil {
@@ -514,6 +647,9 @@ func (w *worker) taskLaunchStep(task concurrency.Task, params concurrency.TaskPa
 	if vars == nil {
 		return nil, scerr.InvalidParameterError("params[variables]", "cannot be nil")
 	}
+	if w.dryRun != nil {
+		vars = maskSecrets(vars)
+	}
 
 	defer scerr.OnExitLogError(fmt.Sprintf("executed step '%s::%s'", w.action.String(), stepName), &err)()
 	defer temporal.NewStopwatch().OnExitLogWithLevel(
@@ -560,27 +696,50 @@ func (w *worker) taskLaunchStep(task concurrency.Task, params concurrency.TaskPa
 	if len(hostsList) == 0 {
 		return nil, nil
 	}
+	// the rollback is run on the hosts targeted by the step, even for Ansible
+	targetedHosts := hostsList
 
 	// Get the content of the action based on method
-	keyword := yamlRunKeyword
-	switch w.method {
-	case method.Apt:
-		fallthrough
-	case method.Yum:
-		fallthrough
-	case method.Dnf:
-		keyword = yamlPackageKeyword
-	}
-	anon, ok = stepMap[keyword]
-	if ok {
-		runContent = anon.(string)
-		// If 'run' content has to be altered, do it
-		if w.commandCB != nil {
-			runContent = w.commandCB(runContent)
+	if w.method == method.Ansible {
+		// Ansible steps are run once, from the controller, against the hosts selected by 'targets'
+		controller, err := w.identifyAnsibleController()
+		if err != nil {
+			return nil, err
 		}
+		if w.ansibleMissing {
+			// without Ansible, the feature cannot have been installed
+			r := StepResults{}
+			for _, h := range targetedHosts {
+				r[h.Name] = stepResult{completed: true, err: fmt.Errorf("ansible-playbook not found on '%s'", controller.Name)}
+			}
+			return &r, nil
+		}
+		runContent, err = w.ansibleStepScript(stepName, stepKey, stepMap, hostsList, controller, vars)
+		if err != nil {
+			return nil, err
+		}
+		hostsList = []*pb.Host{controller}
 	} else {
-		msg := `syntax error in feature '%s' specification file (%s): no key '%s.%s' found`
-		return nil, fmt.Errorf(msg, w.feature.DisplayName(), w.feature.DisplayFilename(), stepKey, yamlRunKeyword)
+		keyword := yamlRunKeyword
+		switch w.method {
+		case method.Apt:
+			fallthrough
+		case method.Yum:
+			fallthrough
+		case method.Dnf:
+			keyword = yamlPackageKeyword
+		}
+		anon, ok = stepMap[keyword]
+		if ok {
+			runContent = anon.(string)
+			// If 'run' content has to be altered, do it
+			if w.commandCB != nil {
+				runContent = w.commandCB(runContent)
+			}
+		} else {
+			msg := `syntax error in feature '%s' specification file (%s): no key '%s.%s' found`
+			return nil, fmt.Errorf(msg, w.feature.DisplayName(), w.feature.DisplayFilename(), stepKey, yamlRunKeyword)
+		}
 	}
 
 	// If there is an options file (for now specific to DCOS), upload it to the remote host
@@ -620,15 +779,32 @@ func (w *worker) taskLaunchStep(task concurrency.Task, params concurrency.TaskPa
 	wallTime := temporal.GetLongOperationTimeout()
 	anon, ok = stepMap[yamlTimeoutKeyword]
 	if ok {
-		if _, ok := anon.(int); ok {
-			wallTime = time.Duration(anon.(int)) * time.Minute
+		duration, inner := stepDuration(anon, time.Minute)
+		if inner != nil {
+			logrus.Warningf("Invalid value '%v' for '%s.%s', ignored.", anon, stepKey, yamlTimeoutKeyword)
 		} else {
-			wallTimeConv, inner := strconv.Atoi(anon.(string))
-			if inner != nil {
-				logrus.Warningf("Invalid value '%s' for '%s.%s', ignored.", anon.(string), w.rootKey, yamlTimeoutKeyword)
-			} else {
-				wallTime = time.Duration(wallTimeConv) * time.Minute
-			}
+			wallTime = duration
+		}
+	}
+
+	// A failing script may be run again, after a delay
+	retries := 0
+	anon, ok = stepMap[yamlRetriesKeyword]
+	if ok {
+		var inner error
+		retries, inner = stepRetries(anon)
+		if inner != nil {
+			logrus.Warningf("Invalid value '%v' for '%s.%s', ignored.", anon, stepKey, yamlRetriesKeyword)
+		}
+	}
+	retryDelay := temporal.GetDefaultDelay()
+	anon, ok = stepMap[yamlRetryDelayKeyword]
+	if ok {
+		duration, inner := stepDuration(anon, time.Second)
+		if inner != nil {
+			logrus.Warningf("Invalid value '%v' for '%s.retryDelay', ignored.", anon, stepKey)
+		} else {
+			retryDelay = duration
 		}
 	}
 
@@ -661,14 +837,66 @@ func (w *worker) taskLaunchStep(task concurrency.Task, params concurrency.TaskPa
 		Targets:            stepT,
 		Script:             templateCommand,
 		WallTime:           wallTime,
+		Retries:            retries,
+		RetryDelay:         retryDelay,
 		OptionsFileContent: optionsFileContent,
 		YamlKey:            stepKey,
 		Serial:             serial,
 	}
+
+	// A dry run stops here, recording what would be run on each host
+	if w.dryRun != nil {
+		rendered, err := stepInstance.dryRun(hostsList, vars)
+		if err != nil {
+			return nil, err
+		}
+		w.settings.DryRun.addStep(w.dryRun, rendered)
+		r := StepResults{}
+		for _, h := range hostsList {
+			r[h.Name] = stepResult{completed: true, success: true}
+		}
+		return &r, nil
+	}
+
+	// The rollback is registered before running the step, a failing step may have to be rolled back too
+	if anon, ok = stepMap[yamlRollbackKeyword]; ok && w.action != action.Check {
+		rollbackContent, ok := anon.(string)
+		if !ok {
+			msg := `syntax error in feature '%s' specification file (%s): '%s.%s' must be a string`
+			return nil, fmt.Errorf(msg, w.feature.DisplayName(), w.feature.DisplayFilename(), stepKey, yamlRollbackKeyword)
+		}
+		rollbackCommand, err := normalizeScript(Variables{
+			"reserved_Name":    w.feature.DisplayName(),
+			"reserved_Content": rollbackContent,
+			"reserved_Action":  strings.ToLower(w.action.String()),
+			"reserved_Step":    stepName + "_rollback",
+		})
+		if err != nil {
+			return nil, err
+		}
+		w.rollbacks = append(w.rollbacks, &rollbackStep{
+			of: stepName,
+			step: step{
+				Worker:   w,
+				Name:     stepName + "_rollback",
+				Action:   w.action,
+				Targets:  stepT,
+				Script:   rollbackCommand,
+				WallTime: wallTime,
+				YamlKey:  stepKey + "." + yamlRollbackKeyword,
+				Serial:   serial,
+			},
+			hosts:     targetedHosts,
+			variables: vars,
+		})
+	}
+
 	r, err := stepInstance.Run(hostsList, vars, w.settings)
-	// If an error occurred, don't do the remaining steps, fail immediately
+	// If an error occurred, don't do the remaining steps, fail immediately; the step is still reported, so is its
+	// rollback
 	if err != nil {
-		return nil, err
+		r = failedStepResults(r, targetedHosts, err)
+		return &r, err
 	}
 
 	if !r.Successful() {
@@ -923,6 +1151,8 @@ func (w *worker) identifyHosts(targets stepTargets) ([]*pb.Host, error) {
 		all       []*pb.Host
 	)
 
+	// A dry run doesn't run the checks identifying the hosts concerned by an addition,
+	// it selects all the running ones
 	if w.cluster == nil {
 		if hostT != "" {
 			hostsList = append(hostsList, w.host)
@@ -938,7 +1168,7 @@ func (w *worker) identifyHosts(targets stepTargets) ([]*pb.Host, error) {
 		}
 		hostsList = append(hostsList, host)
 	case "*":
-		if w.action == action.Add {
+		if w.action == action.Add && w.dryRun == nil {
 			all, err = w.identifyConcernedMasters()
 		} else {
 			all, err = w.identifyAllRunningMasters()
@@ -957,7 +1187,7 @@ func (w *worker) identifyHosts(targets stepTargets) ([]*pb.Host, error) {
 		}
 		hostsList = append(hostsList, host)
 	case "*":
-		if w.action == action.Add {
+		if w.action == action.Add && w.dryRun == nil {
 			all, err = w.identifyConcernedNodes()
 		} else {
 			all, err = w.identifyAllRunningNodes()
@@ -976,7 +1206,7 @@ func (w *worker) identifyHosts(targets stepTargets) ([]*pb.Host, error) {
 		}
 		hostsList = append(hostsList, host)
 	case "*":
-		if w.action == action.Add {
+		if w.action == action.Add && w.dryRun == nil {
 			all, err = w.identifyConcernedGateways()
 		} else {
 			all, err = w.identifyAllGateways()
@@ -988,3 +1218,37 @@ func (w *worker) identifyHosts(targets stepTargets) ([]*pb.Host, error) {
 	}
 	return hostsList, nil
 }
+
+// stepDuration converts the value of a duration in a step, either a number of 'unit' or a duration like '90s' or '5m'
+func stepDuration(anon interface{}, unit time.Duration) (time.Duration, error) {
+	switch value := anon.(type) {
+	case int:
+		if value >= 0 {
+			return time.Duration(value) * unit, nil
+		}
+	case string:
+		if n, err := strconv.Atoi(value); err == nil {
+			if n >= 0 {
+				return time.Duration(n) * unit, nil
+			}
+		} else if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid duration '%v'", anon)
+}
+
+// stepRetries converts the number of retries of a step
+func stepRetries(anon interface{}) (int, error) {
+	switch value := anon.(type) {
+	case int:
+		if value >= 0 {
+			return value, nil
+		}
+	case string:
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid number of retries '%v'", anon)
+}
@@ -0,0 +1,79 @@
+package install
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/CS-SI/SafeScale/lib"
+)
+
+func TestStepDuration(t *testing.T) {
+	valid := map[interface{}]time.Duration{
+		5:     5 * time.Minute,
+		"10":  10 * time.Minute,
+		"90s": 90 * time.Second,
+		"1h":  time.Hour,
+		0:     0,
+	}
+	for value, expected := range valid {
+		d, err := stepDuration(value, time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, d, "value: %v", value)
+	}
+	for _, value := range []interface{}{-1, "-5", "-1m", "soon", true} {
+		_, err := stepDuration(value, time.Minute)
+		assert.Error(t, err, "value: %v", value)
+	}
+}
+
+func TestStepRetries(t *testing.T) {
+	n, err := stepRetries(3)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	n, err = stepRetries("2")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	for _, value := range []interface{}{-1, "many", 1.5} {
+		_, err = stepRetries(value)
+		assert.Error(t, err, "value: %v", value)
+	}
+}
+
+func TestAllRollbackMessages(t *testing.T) {
+	results := Results{
+		"install": StepResults{
+			"host-1": stepResult{completed: true, err: errors.New("failure: retcode=1"), rolledBack: true},
+		},
+		"configure": StepResults{
+			"host-1": stepResult{completed: true, success: true},
+		},
+	}
+	assert.Equal(t, "install@host-1: rolled back\n", results.AllRollbackMessages())
+
+	results["install"]["host-1"] = stepResult{rolledBack: true, rollbackErr: errors.New("failure: retcode=2")}
+	assert.Equal(t, "install@host-1: rollback failed: failure: retcode=2\n", results.AllRollbackMessages())
+}
+
+func TestFailedStepResults(t *testing.T) {
+	failure := errors.New("failed to upload script")
+	hosts := []*pb.Host{{Name: "host-1"}, {Name: "host-2"}}
+
+	r := failedStepResults(nil, hosts, failure)
+	assert.Len(t, r, 2)
+	assert.Equal(t, failure, r["host-2"].err)
+	assert.False(t, r["host-2"].Successful())
+
+	r = failedStepResults(StepResults{"host-1": stepResult{completed: true, success: true}}, hosts, failure)
+	assert.True(t, r["host-1"].Successful())
+	assert.Equal(t, failure, r["host-2"].err)
+
+	// The failure of the step is reported with its rollback
+	results := Results{"install": r}
+	sr := results["install"]["host-2"]
+	sr.rolledBack = true
+	results["install"]["host-2"] = sr
+	assert.Equal(t, "install@host-2: rolled back\n", results.AllRollbackMessages())
+}
@@ -19,6 +19,7 @@ package listeners
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	googleprotobuf "github.com/golang/protobuf/ptypes/empty"
 	"github.com/sirupsen/logrus"
@@ -161,6 +162,43 @@ func (s *BucketListener) Inspect(ctx context.Context, in *pb.Bucket) (bmp *pb.Bu
 	return conv.ToPBBucketMountPoint(resp), nil
 }
 
+// ReadFolder returns the content of the objects stored in a folder of a bucket
+func (s *BucketListener) ReadFolder(ctx context.Context, in *pb.BucketFolder) (list *pb.BucketObjectList, err error) {
+	bucketName := in.GetBucket()
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s')", bucketName, in.GetPath()), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Bucket Read Folder : "+bucketName+"/"+in.GetPath()); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		logrus.Info("Cannot read bucket folder: no tenant set")
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot read bucket folder: no tenant set")
+	}
+
+	handler := BucketHandler(tenant.Service)
+	objects, err := handler.ReadFolder(ctx, bucketName, in.GetPath(), in.GetSuffix())
+	if err != nil {
+		tbr := scerr.Wrap(err, "cannot read bucket folder")
+		return nil, status.Errorf(codes.Internal, tbr.Error())
+	}
+
+	names := make([]string, 0, len(objects))
+	for k := range objects {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	list = &pb.BucketObjectList{}
+	for _, k := range names {
+		list.Objects = append(list.Objects, &pb.BucketObject{Name: k, Content: objects[k]})
+	}
+	return list, nil
+}
+
 // Mount a bucket on the filesystem of the host
 func (s *BucketListener) Mount(ctx context.Context, in *pb.BucketMountingPoint) (empty *googleprotobuf.Empty, err error) {
 	bucketName := in.GetBucket()
@@ -19,6 +19,7 @@ package listeners
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"google.golang.org/grpc/status"
 
@@ -221,6 +222,14 @@ func (s *HostListener) Create(ctx context.Context, in *pb.HostDefinition) (h *pb
 	}
 	name := in.GetName()
 
+	// The network providing the default route comes first, it cannot be left out when others are requested
+	var nets []string
+	if in.GetNetwork() != "" {
+		nets = append([]string{in.GetNetwork()}, in.GetNetworks()...)
+	} else if len(in.GetNetworks()) > 0 {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("network", "cannot be empty string when networks are given").Error())
+	}
+
 	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", name), true).WithStopwatch().GoingIn()
 	defer tracer.OnExitTrace()()
 	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
@@ -236,6 +245,14 @@ func (s *HostListener) Create(ctx context.Context, in *pb.HostDefinition) (h *pb
 		return nil, status.Errorf(codes.FailedPrecondition, "cannot create host: no tenant set")
 	}
 
+	var expiresAt time.Time
+	if in.GetExpiresAt() != "" {
+		expiresAt, err = time.Parse(time.RFC3339, in.GetExpiresAt())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("expires_at", "must be a RFC3339 date").Error())
+		}
+	}
+
 	var sizing *resources.SizingRequirements
 	if in.Sizing == nil {
 		sizing = &resources.SizingRequirements{
@@ -255,11 +272,14 @@ func (s *HostListener) Create(ctx context.Context, in *pb.HostDefinition) (h *pb
 	handler := HostHandler(tenant.Service)
 	host, err := handler.Create(ctx,
 		name,
-		in.GetNetwork(),
+		nets,
 		in.GetImageId(),
 		in.GetPublic(),
 		sizing,
 		in.Force,
+		in.GetUserData(),
+		in.GetPostInstallScript(),
+		expiresAt,
 	)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
@@ -455,3 +475,312 @@ func (s *HostListener) SSH(ctx context.Context, in *pb.Reference) (sc *pb.SshCon
 	}
 	return srvutils.ToPBSshConfig(sshConfig), nil
 }
+
+// Extend changes the expiration date of a host
+func (s *HostListener) Extend(ctx context.Context, in *pb.HostExtendRequest) (h *pb.Host, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in.GetHost())
+	if ref == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot extend host: neither name nor id given as reference")
+	}
+	var expiresAt time.Time
+	if in.GetExpiresAt() != "" {
+		expiresAt, err = time.Parse(time.RFC3339, in.GetExpiresAt())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("expires_at", "must be a RFC3339 date").Error())
+		}
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s')", ref, in.GetExpiresAt()), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Extend Host "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		log.Info("Can't extend host: no tenant set")
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot extend host: no tenant set")
+	}
+
+	handler := HostHandler(tenant.Service)
+	host, err := handler.Extend(ctx, ref, expiresAt)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	log.Infof("Expiration of host '%s' set to '%s'", ref, in.GetExpiresAt())
+	return srvutils.ToPBHost(host), nil
+}
+
+// Import registers in SafeScale a host created outside of it
+func (s *HostListener) Import(ctx context.Context, in *pb.HostImportRequest) (h *pb.Host, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	id := in.GetId()
+	if id == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot import host: no provider id given")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', <private key>, '%s', '%s')", id, in.GetUser(), in.GetNetwork()), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Import Host "+id); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		log.Info("Can't import host: no tenant set")
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot import host: no tenant set")
+	}
+
+	handler := HostHandler(tenant.Service)
+	host, err := handler.Import(ctx, id, in.GetPrivateKey(), in.GetUser(), in.GetNetwork())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	log.Infof("Host '%s' imported", host.Name)
+	return srvutils.ToPBHost(host), nil
+}
+
+// AddUser creates on a host a personal account authorized to log in with its own SSH keys
+func (s *HostListener) AddUser(ctx context.Context, in *pb.HostUserRequest) (empty *googleprotobuf.Empty, err error) {
+	empty = &googleprotobuf.Empty{}
+	if s == nil {
+		return empty, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return empty, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in.GetHost())
+	if ref == "" {
+		return empty, status.Errorf(codes.FailedPrecondition, "cannot add user: neither name nor id given as reference of host")
+	}
+	name := in.GetUser().GetName()
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s')", ref, name), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Add User "+name+" on Host "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		log.Info("Can't add user: no tenant set")
+		return empty, status.Errorf(codes.FailedPrecondition, "cannot add user: no tenant set")
+	}
+
+	handler := HostHandler(tenant.Service)
+	err = handler.AddUser(ctx, ref, name, in.GetUser().GetPublicKeys(), in.GetUser().GetSudo())
+	if err != nil {
+		return empty, status.Errorf(codes.Internal, err.Error())
+	}
+	log.Infof("User '%s' added on host '%s' (sudo: %v, %d key(s))", name, ref, in.GetUser().GetSudo(), len(in.GetUser().GetPublicKeys()))
+	return empty, nil
+}
+
+// RemoveUser deletes from a host a personal account previously added
+func (s *HostListener) RemoveUser(ctx context.Context, in *pb.HostUserRequest) (empty *googleprotobuf.Empty, err error) {
+	empty = &googleprotobuf.Empty{}
+	if s == nil {
+		return empty, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return empty, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in.GetHost())
+	if ref == "" {
+		return empty, status.Errorf(codes.FailedPrecondition, "cannot remove user: neither name nor id given as reference of host")
+	}
+	name := in.GetUser().GetName()
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s')", ref, name), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Remove User "+name+" from Host "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		log.Info("Can't remove user: no tenant set")
+		return empty, status.Errorf(codes.FailedPrecondition, "cannot remove user: no tenant set")
+	}
+
+	handler := HostHandler(tenant.Service)
+	err = handler.RemoveUser(ctx, ref, name)
+	if err != nil {
+		return empty, status.Errorf(codes.Internal, err.Error())
+	}
+	log.Infof("User '%s' removed from host '%s'", name, ref)
+	return empty, nil
+}
+
+// ListUsers lists the personal accounts added on a host
+func (s *HostListener) ListUsers(ctx context.Context, in *pb.Reference) (list *pb.HostUserList, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in)
+	if ref == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot list users: neither name nor id given as reference of host")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "List Users of Host "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		log.Info("Can't list users: no tenant set")
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot list users: no tenant set")
+	}
+
+	handler := HostHandler(tenant.Service)
+	users, err := handler.ListUsers(ctx, ref)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return srvutils.ToPBHostUserList(users), nil
+}
+
+// RotateKey replaces the keypair used to connect to a host by a new one
+func (s *HostListener) RotateKey(ctx context.Context, in *pb.Reference) (h *pb.Host, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in)
+	if ref == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot rotate key: neither name nor id given as reference")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Rotate key of Host "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		log.Info("Can't rotate key: no tenant set")
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot rotate key: no tenant set")
+	}
+
+	handler := HostHandler(tenant.Service)
+	host, err := handler.RotateKey(ctx, ref)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	log.Infof("Key of host '%s' rotated", ref)
+	return srvutils.ToPBHost(host), nil
+}
+
+// GetFeatures returns the features recorded as installed on a host
+func (s *HostListener) GetFeatures(ctx context.Context, in *pb.Reference) (features *pb.HostFeatures, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in)
+	if ref == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot get features: neither name nor id given as reference of host")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Get features of Host "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		log.Info("Can't get features: no tenant set")
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot get features: no tenant set")
+	}
+
+	handler := HostHandler(tenant.Service)
+	installed, err := handler.GetFeatures(ctx, ref)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return srvutils.ToPBHostFeatures(in, installed), nil
+}
+
+// SetFeatures replaces the features recorded as installed on a host
+func (s *HostListener) SetFeatures(ctx context.Context, in *pb.HostFeatures) (empty *googleprotobuf.Empty, err error) {
+	empty = &googleprotobuf.Empty{}
+	if s == nil {
+		return empty, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return empty, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in.GetHost())
+	if ref == "" {
+		return empty, status.Errorf(codes.FailedPrecondition, "cannot set features: neither name nor id given as reference of host")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Set features of Host "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		log.Info("Can't set features: no tenant set")
+		return empty, status.Errorf(codes.FailedPrecondition, "cannot set features: no tenant set")
+	}
+
+	installed, err := srvutils.FromPBHostFeatures(in)
+	if err != nil {
+		return empty, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+	handler := HostHandler(tenant.Service)
+	err = handler.SetFeatures(ctx, ref, installed)
+	if err != nil {
+		return empty, status.Errorf(codes.Internal, err.Error())
+	}
+	return empty, nil
+}
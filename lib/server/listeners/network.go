@@ -98,11 +98,17 @@ func (s *NetworkListener) Create(ctx context.Context, in *pb.NetworkDefinition)
 		gwName = in.GetGateway().GetName()
 	}
 
+	ipVersion := ipversion.IPv4
+	if in.GetIpv6() {
+		ipVersion = ipversion.IPv6
+	}
+
 	handler := NetworkHandler(tenant.Service)
 	network, err := handler.Create(ctx,
 		networkName,
 		in.GetCidr(),
-		ipversion.IPv4,
+		ipVersion,
+		in.GetCidrV6(),
 		*sizing,
 		gwImageID,
 		gwName,
@@ -234,3 +240,344 @@ func (s *NetworkListener) Delete(ctx context.Context, in *pb.Reference) (buf *go
 	log.Infof("Network '%s' successfully deleted.", ref)
 	return &googleprotobuf.Empty{}, nil
 }
+
+// Import registers in SafeScale a network created outside of it
+func (s *NetworkListener) Import(ctx context.Context, in *pb.NetworkImportRequest) (net *pb.Network, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	id := in.GetId()
+	if id == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot import network: no provider id given")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s', <private key>, '%s')", id, in.GetGateway(), in.GetUser()), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Import network "+id); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		log.Info("Can't import network: no tenant set")
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot import network: no tenant set")
+	}
+
+	handler := NetworkHandler(tenant.Service)
+	network, err := handler.Import(ctx, id, in.GetGateway(), in.GetPrivateKey(), in.GetUser())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	log.Infof("Network '%s' imported", network.Name)
+	return conv.ToPBNetwork(network), nil
+}
+
+// Peer sets up a VPN between two networks, possibly of different tenants
+func (s *NetworkListener) Peer(ctx context.Context, in *pb.NetworkPeerRequest) (buf *googleprotobuf.Empty, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in.GetNetwork())
+	peerRef := srvutils.GetReference(in.GetPeerNetwork())
+	if ref == "" || peerRef == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot peer networks: neither name nor id given as reference")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s@%s', '%s@%s')", ref, in.GetTenant(), peerRef, in.GetPeerTenant()), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Peer network "+ref+" with "+peerRef); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant, err := tenantByName(in.GetTenant())
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot peer networks: %s", err.Error())
+	}
+	peerTenant, err := tenantByName(in.GetPeerTenant())
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot peer networks: %s", err.Error())
+	}
+
+	handler := NetworkHandler(tenant.Service)
+	err = handler.Peer(ctx, ref, tenant.name, peerTenant.Service, peerRef, peerTenant.name)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	log.Infof("Network '%s@%s' peered with network '%s@%s'", ref, tenant.name, peerRef, peerTenant.name)
+	return &googleprotobuf.Empty{}, nil
+}
+
+// AddForward forwards a public port of the gateway(s) of a network to a port of one of its hosts
+func (s *NetworkListener) AddForward(ctx context.Context, in *pb.NetworkForwardRequest) (fw *pb.NetworkForward, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in.GetNetwork())
+	if ref == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot add forwarding rule: neither name nor id given as reference of network")
+	}
+	forward := in.GetForward()
+	hostRef := srvutils.GetReference(forward.GetHost())
+	if hostRef == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot add forwarding rule: neither name nor id given as reference of host")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', %s/%d, '%s', %d)", ref, forward.GetProtocol(), forward.GetPublicPort(), hostRef, forward.GetPort()), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Add forwarding rule to network "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot add forwarding rule: no tenant set")
+	}
+
+	handler := NetworkHandler(tenant.Service)
+	added, err := handler.AddForward(ctx, ref, forward.GetProtocol(), int(forward.GetPublicPort()), hostRef, int(forward.GetPort()))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	log.Infof("Port %s/%d of network '%s' forwarded to port %d of host '%s'", added.Protocol, added.PublicPort, ref, added.Port, added.HostName)
+	return conv.ToPBNetworkForward(added), nil
+}
+
+// ListForwards lists the forwarding rules of a network
+func (s *NetworkListener) ListForwards(ctx context.Context, in *pb.Reference) (list *pb.NetworkForwardList, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in)
+	if ref == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot list forwarding rules: neither name nor id given as reference")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "List forwarding rules of network "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot list forwarding rules: no tenant set")
+	}
+
+	handler := NetworkHandler(tenant.Service)
+	forwards, err := handler.ListForwards(ctx, ref)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return conv.ToPBNetworkForwardList(forwards), nil
+}
+
+// DeleteForward removes a forwarding rule of a network
+func (s *NetworkListener) DeleteForward(ctx context.Context, in *pb.NetworkForwardRequest) (buf *googleprotobuf.Empty, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in.GetNetwork())
+	if ref == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot delete forwarding rule: neither name nor id given as reference")
+	}
+	forward := in.GetForward()
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', %s/%d)", ref, forward.GetProtocol(), forward.GetPublicPort()), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Delete forwarding rule of network "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot delete forwarding rule: no tenant set")
+	}
+
+	handler := NetworkHandler(tenant.Service)
+	err = handler.DeleteForward(ctx, ref, forward.GetProtocol(), int(forward.GetPublicPort()))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	log.Infof("Forwarding rule %s/%d of network '%s' deleted", forward.GetProtocol(), forward.GetPublicPort(), ref)
+	return &googleprotobuf.Empty{}, nil
+}
+
+// ReplaceGateway replaces the gateway(s) of a network by new ones
+func (s *NetworkListener) ReplaceGateway(ctx context.Context, in *pb.NetworkGatewayReplaceRequest) (buf *googleprotobuf.Empty, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in.GetNetwork())
+	if ref == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot replace gateway: neither name nor id given as reference")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Replace gateway of network "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot replace gateway: no tenant set")
+	}
+
+	var sizing *resources.SizingRequirements
+	if in.GetSizing() != nil {
+		s := srvutils.FromPBHostSizing(*in.GetSizing())
+		sizing = &s
+	}
+
+	handler := NetworkHandler(tenant.Service)
+	err = handler.ReplaceGateway(ctx, ref, sizing, in.GetImageId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	log.Infof("Gateway(s) of network '%s' replaced", ref)
+	return &googleprotobuf.Empty{}, nil
+}
+
+// EnableFailover adds a secondary gateway and a VIP to a network
+func (s *NetworkListener) EnableFailover(ctx context.Context, in *pb.NetworkFailoverRequest) (buf *googleprotobuf.Empty, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in.GetNetwork())
+	if ref == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot enable failover: neither name nor id given as reference")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Enable failover on network "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot enable failover: no tenant set")
+	}
+
+	handler := NetworkHandler(tenant.Service)
+	err = handler.EnableFailover(ctx, ref, in.GetImageId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	log.Infof("Failover enabled on network '%s'", ref)
+	return &googleprotobuf.Empty{}, nil
+}
+
+// DisableFailover removes the secondary gateway and the VIP of a network
+func (s *NetworkListener) DisableFailover(ctx context.Context, in *pb.Reference) (buf *googleprotobuf.Empty, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in)
+	if ref == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot disable failover: neither name nor id given as reference")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Disable failover on network "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot disable failover: no tenant set")
+	}
+
+	handler := NetworkHandler(tenant.Service)
+	err = handler.DisableFailover(ctx, ref)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	log.Infof("Failover disabled on network '%s'", ref)
+	return &googleprotobuf.Empty{}, nil
+}
+
+// RotateKeys rotates the keypairs of the gateway(s) and hosts of a network
+func (s *NetworkListener) RotateKeys(ctx context.Context, in *pb.Reference) (buf *googleprotobuf.Empty, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := srvutils.GetReference(in)
+	if ref == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot rotate keys: neither name nor id given as reference")
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s')", ref), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Rotate keys of network "+ref); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot rotate keys: no tenant set")
+	}
+
+	handler := NetworkHandler(tenant.Service)
+	err = handler.RotateKeys(ctx, ref)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	log.Infof("Keys of hosts of network '%s' rotated", ref)
+	return &googleprotobuf.Empty{}, nil
+}
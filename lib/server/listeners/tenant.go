@@ -26,12 +26,16 @@ import (
 	"google.golang.org/grpc/status"
 
 	pb "github.com/CS-SI/SafeScale/lib"
+	"github.com/CS-SI/SafeScale/lib/server/handlers"
 	"github.com/CS-SI/SafeScale/lib/server/iaas"
 	srvutils "github.com/CS-SI/SafeScale/lib/server/utils"
 	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
 	"github.com/CS-SI/SafeScale/lib/utils/scerr"
 )
 
+// TenantHandler ...
+var TenantHandler = handlers.NewTenantHandler
+
 // Tenant structure to handle name and clientAPI for a tenant
 type Tenant struct {
 	name    string
@@ -65,6 +69,22 @@ func getCurrentTenant() *Tenant {
 	return currentTenant
 }
 
+// tenantByName returns the tenant named name, the current tenant if name is empty
+func tenantByName(name string) (*Tenant, error) {
+	current := GetCurrentTenant()
+	if name == "" || (current != nil && current.name == name) {
+		if current == nil {
+			return nil, fmt.Errorf("no tenant set")
+		}
+		return current, nil
+	}
+	service, err := iaas.UseService(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to use tenant '%s': %s", name, err.Error())
+	}
+	return &Tenant{name: name, Service: service}, nil
+}
+
 // TenantListener server is used to implement SafeScale.safescale.
 type TenantListener struct{}
 
@@ -160,6 +180,164 @@ func (s *TenantListener) Set(ctx context.Context, in *pb.TenantName) (empty *goo
 	return empty, nil
 }
 
+// GC reports (and optionally fixes) the drifts between provider resources and SafeScale metadata of the current tenant
+func (s *TenantListener) GC(ctx context.Context, in *pb.TenantGCRequest) (report *pb.TenantGCReport, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("(%v)", in.GetApply()), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Tenant GC"); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		log.Info("Can't run garbage collection: no tenant set")
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot run garbage collection: no tenant set")
+	}
+
+	handler := TenantHandler(tenant.Service)
+	entries, err := handler.GC(ctx, handlers.GCOptions{
+		Apply:         in.GetApply(),
+		DeleteOrphans: in.GetDeleteOrphans(),
+		ImportOrphans: in.GetImportOrphans(),
+		PurgeDangling: in.GetPurgeDangling(),
+		PrivateKey:    in.GetPrivateKey(),
+		User:          in.GetUser(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	report = &pb.TenantGCReport{}
+	for _, e := range entries {
+		report.Entries = append(report.Entries, &pb.TenantGCEntry{
+			Kind:   e.Kind,
+			Id:     e.ID,
+			Name:   e.Name,
+			Drift:  e.Drift,
+			Action: e.Action,
+		})
+	}
+	return report, nil
+}
+
+// ReadFeatureSecrets returns the secrets stored for a feature installed on a host or a cluster
+func (s *TenantListener) ReadFeatureSecrets(ctx context.Context, in *pb.FeatureSecretsRef) (secrets *pb.FeatureSecrets, err error) {
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return nil, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s', '%s')", in.GetTargetType(), in.GetTargetName(), in.GetFeature()), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Read secrets of feature "+in.GetFeature()); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		log.Info("Can't read feature secrets: no tenant set")
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot read feature secrets: no tenant set")
+	}
+
+	handler := TenantHandler(tenant.Service)
+	stored, keyDefined, err := handler.ReadFeatureSecrets(ctx, in.GetTargetType(), in.GetTargetName(), in.GetFeature())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+
+	secrets = &pb.FeatureSecrets{Ref: in, KeyDefined: keyDefined}
+	for k, v := range stored {
+		secrets.Secrets = append(secrets.Secrets, &pb.FeatureSecret{Name: k, Value: v})
+	}
+	return secrets, nil
+}
+
+// WriteFeatureSecrets stores the secrets of a feature installed on a host or a cluster
+func (s *TenantListener) WriteFeatureSecrets(ctx context.Context, in *pb.FeatureSecrets) (empty *googleprotobuf.Empty, err error) {
+	empty = &googleprotobuf.Empty{}
+	if s == nil {
+		return empty, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return empty, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+	ref := in.GetRef()
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s', '%s')", ref.GetTargetType(), ref.GetTargetName(), ref.GetFeature()), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Write secrets of feature "+ref.GetFeature()); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		log.Info("Can't write feature secrets: no tenant set")
+		return empty, status.Errorf(codes.FailedPrecondition, "cannot write feature secrets: no tenant set")
+	}
+
+	secrets := map[string]string{}
+	for _, secret := range in.GetSecrets() {
+		secrets[secret.GetName()] = secret.GetValue()
+	}
+	handler := TenantHandler(tenant.Service)
+	err = handler.WriteFeatureSecrets(ctx, ref.GetTargetType(), ref.GetTargetName(), ref.GetFeature(), secrets)
+	if err != nil {
+		return empty, status.Errorf(codes.Internal, err.Error())
+	}
+	return empty, nil
+}
+
+// DeleteFeatureSecrets removes the secrets stored for a feature installed on a host or a cluster
+func (s *TenantListener) DeleteFeatureSecrets(ctx context.Context, in *pb.FeatureSecretsRef) (empty *googleprotobuf.Empty, err error) {
+	empty = &googleprotobuf.Empty{}
+	if s == nil {
+		return empty, status.Errorf(codes.FailedPrecondition, scerr.InvalidInstanceError().Error())
+	}
+	if in == nil {
+		return empty, status.Errorf(codes.InvalidArgument, scerr.InvalidParameterError("in", "cannot be nil").Error())
+	}
+
+	tracer := concurrency.NewTracer(nil, fmt.Sprintf("('%s', '%s', '%s')", in.GetTargetType(), in.GetTargetName(), in.GetFeature()), true).WithStopwatch().GoingIn()
+	defer tracer.OnExitTrace()()
+	defer scerr.OnExitLogError(tracer.TraceMessage(""), &err)()
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	if err := srvutils.JobRegister(ctx, cancelFunc, "Delete secrets of feature "+in.GetFeature()); err == nil {
+		defer srvutils.JobDeregister(ctx)
+	}
+
+	tenant := GetCurrentTenant()
+	if tenant == nil {
+		log.Info("Can't delete feature secrets: no tenant set")
+		return empty, status.Errorf(codes.FailedPrecondition, "cannot delete feature secrets: no tenant set")
+	}
+
+	handler := TenantHandler(tenant.Service)
+	err = handler.DeleteFeatureSecrets(ctx, in.GetTargetType(), in.GetTargetName(), in.GetFeature())
+	if err != nil {
+		return empty, status.Errorf(codes.Internal, err.Error())
+	}
+	return empty, nil
+}
+
 //StorageTenants strcture handle tenants names and storages services for a group of storage tenants
 type StorageTenants struct {
 	names           []string
@@ -0,0 +1,221 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package reaper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/CS-SI/SafeScale/lib/server/cluster"
+	"github.com/CS-SI/SafeScale/lib/server/cluster/control"
+	"github.com/CS-SI/SafeScale/lib/server/cluster/enums/clusterstate"
+	"github.com/CS-SI/SafeScale/lib/server/handlers"
+	"github.com/CS-SI/SafeScale/lib/server/iaas"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/hostproperty"
+	"github.com/CS-SI/SafeScale/lib/server/iaas/resources/enums/hoststate"
+	propsv1 "github.com/CS-SI/SafeScale/lib/server/iaas/resources/properties/v1"
+	"github.com/CS-SI/SafeScale/lib/server/listeners"
+	"github.com/CS-SI/SafeScale/lib/utils/concurrency"
+	"github.com/CS-SI/SafeScale/lib/utils/data"
+)
+
+const (
+	// Interval is the delay between two inspections of the resources
+	Interval = 5 * time.Minute
+	// WarningDelay is how long before expiration a resource starts to be reported as expiring
+	WarningDelay = time.Hour
+	// GracePeriod is how long an expired resource stays stopped before being deleted
+	GracePeriod = 24 * time.Hour
+)
+
+// verdict tells what to do with a resource, given its expiration date
+type verdict int
+
+const (
+	keep verdict = iota
+	warn
+	stop
+	remove
+)
+
+func judge(expiresAt, now time.Time) verdict {
+	switch {
+	case expiresAt.IsZero():
+		return keep
+	case now.After(expiresAt.Add(GracePeriod)):
+		return remove
+	case now.After(expiresAt):
+		return stop
+	case now.After(expiresAt.Add(-WarningDelay)):
+		return warn
+	default:
+		return keep
+	}
+}
+
+// warned keeps track of the resources already reported as expiring, to warn only once
+var warned = map[string]time.Time{}
+
+// Run inspects periodically the hosts and clusters of the current tenant, warns about the
+// ones about to expire, stops the expired ones and deletes them once the grace period is over
+// Run never returns and is intended to be started as a goroutine
+func Run() {
+	ticker := time.NewTicker(Interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		tenant := listeners.GetCurrentTenant()
+		if tenant == nil {
+			continue
+		}
+		reapHosts(tenant.Service)
+		reapClusters(tenant.Service)
+	}
+}
+
+// reapHosts handles the expiration of hosts
+func reapHosts(svc iaas.Service) {
+	ctx := context.Background()
+	handler := handlers.NewHostHandler(svc)
+	hosts, err := handler.List(ctx, false)
+	if err != nil {
+		log.Errorf("reaper: failed to list hosts: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, host := range hosts {
+		if !host.Properties.Lookup(hostproperty.LifecycleV1) {
+			continue
+		}
+		var expiresAt time.Time
+		err = host.Properties.LockForRead(hostproperty.LifecycleV1).ThenUse(func(clonable data.Clonable) error {
+			expiresAt = clonable.(*propsv1.HostLifecycle).ExpiresAt
+			return nil
+		})
+		if err != nil {
+			log.Errorf("reaper: failed to read expiration of host '%s': %v", host.Name, err)
+			continue
+		}
+
+		key := "host:" + host.ID
+		switch judge(expiresAt, now) {
+		case warn:
+			if warned[key] != expiresAt {
+				log.Warnf("reaper: host '%s' expires at %s and will be stopped, unless extended", host.Name, expiresAt.Format(time.RFC3339))
+				warned[key] = expiresAt
+			}
+		case stop:
+			inspected, err := handler.Inspect(ctx, host.ID)
+			if err != nil {
+				log.Errorf("reaper: failed to inspect expired host '%s': %v", host.Name, err)
+				continue
+			}
+			if inspected.LastState != hoststate.STARTED {
+				continue
+			}
+			log.Warnf("reaper: host '%s' expired at %s, stopping it (it will be deleted after %s)", host.Name, expiresAt.Format(time.RFC3339), expiresAt.Add(GracePeriod).Format(time.RFC3339))
+			if err = handler.Stop(ctx, host.ID); err != nil {
+				log.Errorf("reaper: failed to stop expired host '%s': %v", host.Name, err)
+			}
+		case remove:
+			log.Warnf("reaper: host '%s' expired at %s, deleting it", host.Name, expiresAt.Format(time.RFC3339))
+			if err = handler.Delete(ctx, host.ID); err != nil {
+				log.Errorf("reaper: failed to delete expired host '%s': %v", host.Name, err)
+				continue
+			}
+			delete(warned, key)
+		}
+	}
+}
+
+// reapClusters handles the expiration of clusters
+func reapClusters(svc iaas.Service) {
+	task := concurrency.RootTask()
+	m, err := control.NewMetadata(svc)
+	if err != nil {
+		log.Errorf("reaper: failed to access cluster metadata: %v", err)
+		return
+	}
+
+	expirations := map[string]time.Time{}
+	err = m.Browse(func(controller *control.Controller) error {
+		if !controller.Identity.OK() {
+			return nil
+		}
+		expiresAt, err := controller.GetExpiration(task)
+		if err != nil {
+			return err
+		}
+		if !expiresAt.IsZero() {
+			expirations[controller.Identity.Name] = expiresAt
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("reaper: failed to browse clusters: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for name, expiresAt := range expirations {
+		key := "cluster:" + name
+		switch judge(expiresAt, now) {
+		case warn:
+			if warned[key] != expiresAt {
+				log.Warnf("reaper: cluster '%s' expires at %s and will be stopped, unless extended", name, expiresAt.Format(time.RFC3339))
+				warned[key] = expiresAt
+			}
+		case stop:
+			err = stopCluster(task, name)
+			if err != nil {
+				log.Errorf("reaper: failed to stop expired cluster '%s': %v", name, err)
+			}
+		case remove:
+			log.Warnf("reaper: cluster '%s' expired at %s, deleting it", name, expiresAt.Format(time.RFC3339))
+			if err = cluster.Delete(task, name); err != nil {
+				log.Errorf("reaper: failed to delete expired cluster '%s': %v", name, err)
+				continue
+			}
+			delete(warned, key)
+		}
+	}
+}
+
+// stopCluster stops the cluster named 'name' if it's running
+func stopCluster(task concurrency.Task, name string) error {
+	instance, err := cluster.Load(task, name)
+	if err != nil {
+		return err
+	}
+	state, err := instance.GetState(task)
+	if err != nil {
+		return err
+	}
+	if state != clusterstate.Nominal && state != clusterstate.Degraded {
+		return nil
+	}
+	log.Warnf("reaper: cluster '%s' expired, stopping it", name)
+	err = instance.Stop(task)
+	if err != nil {
+		return fmt.Errorf("failed to stop cluster: %v", err)
+	}
+	return nil
+}
@@ -18,6 +18,8 @@ package utils
 
 import (
 	"math"
+	"sort"
+	"time"
 
 	pb "github.com/CS-SI/SafeScale/lib"
 	"github.com/CS-SI/SafeScale/lib/server/iaas/resources"
@@ -181,6 +183,18 @@ func ToPBHost(in *resources.Host) *pb.Host {
 	if err != nil {
 		return nil
 	}
+	expiresAt := ""
+	if in.Properties.Lookup(hostproperty.LifecycleV1) {
+		err = in.Properties.LockForRead(hostproperty.LifecycleV1).ThenUse(func(clonable data.Clonable) error {
+			if hostLifecycleV1 := clonable.(*propsv1.HostLifecycle); !hostLifecycleV1.ExpiresAt.IsZero() {
+				expiresAt = hostLifecycleV1.ExpiresAt.Format(time.RFC3339)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil
+		}
+	}
 	return &pb.Host{
 		Cpu:                 int32(hostSizingV1.AllocatedSize.Cores),
 		Disk:                int32(hostSizingV1.AllocatedSize.DiskSize),
@@ -188,12 +202,15 @@ func ToPBHost(in *resources.Host) *pb.Host {
 		Id:                  in.ID,
 		PublicIp:            in.GetPublicIP(),
 		PrivateIp:           in.GetPrivateIP(),
+		PublicIpv6:          hostNetworkV1.PublicIPv6,
+		PrivateIpv6:         in.GetPrivateIPv6(),
 		Name:                in.Name,
 		PrivateKey:          in.PrivateKey,
 		Password:            in.Password,
 		Ram:                 hostSizingV1.AllocatedSize.RAMSize,
 		State:               pb.HostState(in.LastState),
 		AttachedVolumeNames: volumes,
+		ExpiresAt:           expiresAt,
 	}
 }
 
@@ -282,7 +299,110 @@ func ToPBNetwork(in *resources.Network) *pb.Network {
 		SecondaryGatewayId: in.SecondaryGatewayID,
 		VirtualIp:          &pbVIP,
 		Failover:           in.SecondaryGatewayID != "",
+		CidrV6:             in.CIDRv6,
+	}
+}
+
+// ToPBNetworkForward converts a forwarding rule of a network to protocolbuffer format
+func ToPBNetworkForward(in *propsv1.NetworkForward) *pb.NetworkForward {
+	return &pb.NetworkForward{
+		Protocol:   in.Protocol,
+		PublicPort: int32(in.PublicPort),
+		Host:       &pb.Reference{Id: in.HostID, Name: in.HostName},
+		HostIp:     in.HostIP,
+		Port:       int32(in.Port),
+	}
+}
+
+// ToPBNetworkForwardList converts a list of forwarding rules of a network to protocolbuffer format
+func ToPBNetworkForwardList(in []*propsv1.NetworkForward) *pb.NetworkForwardList {
+	list := &pb.NetworkForwardList{}
+	for _, f := range in {
+		list.Forwards = append(list.Forwards, ToPBNetworkForward(f))
+	}
+	return list
+}
+
+// ToPBHostUser converts a personal account of a host to protocolbuffer format
+func ToPBHostUser(in *propsv1.HostUser) *pb.HostUser {
+	out := &pb.HostUser{
+		Name: in.Name,
+		Sudo: in.Sudo,
+	}
+	out.PublicKeys = make([]string, len(in.PublicKeys))
+	copy(out.PublicKeys, in.PublicKeys)
+	if !in.Created.IsZero() {
+		out.Created = in.Created.Format(time.RFC3339)
+	}
+	return out
+}
+
+// ToPBHostUserList converts a list of personal accounts of a host to protocolbuffer format
+func ToPBHostUserList(in []*propsv1.HostUser) *pb.HostUserList {
+	list := &pb.HostUserList{}
+	for _, u := range in {
+		list.Users = append(list.Users, ToPBHostUser(u))
+	}
+	return list
+}
+
+// ToPBHostFeatures converts the features installed on a host to protocolbuffer format
+func ToPBHostFeatures(host *pb.Reference, in *propsv1.HostFeatures) *pb.HostFeatures {
+	out := &pb.HostFeatures{Host: host}
+	names := make([]string, 0, len(in.Installed))
+	for k := range in.Installed {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		v := in.Installed[k]
+		f := &pb.HostInstalledFeature{
+			Name:         k,
+			Version:      v.Version,
+			HostContext:  v.HostContext,
+			CheckStatus:  v.CheckStatus,
+			CheckMessage: v.CheckMessage,
+		}
+		f.Requires = make([]string, len(v.Requires))
+		copy(f.Requires, v.Requires)
+		f.RequiredBy = make([]string, len(v.RequiredBy))
+		copy(f.RequiredBy, v.RequiredBy)
+		if !v.CheckedAt.IsZero() {
+			f.CheckedAt = v.CheckedAt.Format(time.RFC3339)
+		}
+		if !v.RemediatedAt.IsZero() {
+			f.RemediatedAt = v.RemediatedAt.Format(time.RFC3339)
+		}
+		out.Installed = append(out.Installed, f)
+	}
+	return out
+}
+
+// FromPBHostFeatures converts the features installed on a host from protocolbuffer format
+func FromPBHostFeatures(in *pb.HostFeatures) (*propsv1.HostFeatures, error) {
+	out := propsv1.NewHostFeatures()
+	for _, f := range in.GetInstalled() {
+		v := propsv1.NewHostInstalledFeature()
+		v.Version = f.GetVersion()
+		v.HostContext = f.GetHostContext()
+		v.CheckStatus = f.GetCheckStatus()
+		v.CheckMessage = f.GetCheckMessage()
+		v.Requires = append(v.Requires, f.GetRequires()...)
+		v.RequiredBy = append(v.RequiredBy, f.GetRequiredBy()...)
+		var err error
+		if f.GetCheckedAt() != "" {
+			if v.CheckedAt, err = time.Parse(time.RFC3339, f.GetCheckedAt()); err != nil {
+				return nil, err
+			}
+		}
+		if f.GetRemediatedAt() != "" {
+			if v.RemediatedAt, err = time.Parse(time.RFC3339, f.GetRemediatedAt()); err != nil {
+				return nil, err
+			}
+		}
+		out.Installed[f.GetName()] = v
 	}
+	return out, nil
 }
 
 // ToPBFileList convert a list of file names from api to protocolbuffer FileList format
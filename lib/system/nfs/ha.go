@@ -0,0 +1,159 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package nfs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/CS-SI/SafeScale/lib/utils/scerr"
+)
+
+const (
+	haBasePort     = 7788
+	haBaseRouterID = 100
+	haBaseFSID     = 1000
+)
+
+// HAPeer describes one of the two servers exporting a share in high availability
+type HAPeer struct {
+	Server    *Server
+	PrivateIP string
+}
+
+// HAServer pairs two NFS servers: the block device is replicated by DRBD from the active server to the passive one,
+// and keepalived moves the VIP (and the export with it) to the passive server when the active one fails
+type HAServer struct {
+	Primary   HAPeer
+	Secondary HAPeer
+	Resource  string // name of the DRBD resource
+	Minor     int    // index of the replicated share on both servers
+	Device    string // block device to replicate, present on both servers
+	VIP       string // private IP of the VIP used as export address
+	CIDR      string // CIDR of the network hosting the VIP
+}
+
+// NewHAServer instantiates a new nfs.HAServer struct
+func NewHAServer(primary, secondary HAPeer, resource string, minor int, device, vip, cidr string) (*HAServer, error) {
+	if primary.Server == nil {
+		return nil, scerr.InvalidParameterError("primary.Server", "cannot be nil")
+	}
+	if secondary.Server == nil {
+		return nil, scerr.InvalidParameterError("secondary.Server", "cannot be nil")
+	}
+	if resource == "" {
+		return nil, scerr.InvalidParameterError("resource", "cannot be empty string")
+	}
+	if device == "" {
+		return nil, scerr.InvalidParameterError("device", "cannot be empty string")
+	}
+	if vip == "" {
+		return nil, scerr.InvalidParameterError("vip", "cannot be empty string")
+	}
+
+	return &HAServer{
+		Primary:   primary,
+		Secondary: secondary,
+		Resource:  resource,
+		Minor:     minor,
+		Device:    device,
+		VIP:       vip,
+		CIDR:      cidr,
+	}, nil
+}
+
+// GetHost returns the address clients have to use to reach the shares of the nfs.HAServer
+func (s *HAServer) GetHost() string {
+	return s.VIP
+}
+
+// AddShare installs replication and failover on both servers, then exports path from the active one
+func (s *HAServer) AddShare(path string, secutityModes []string, readOnly, rootSquash, secure, async, noHide, crossMount, subtreeCheck bool) error {
+	share, err := s.Primary.Server.newShare(path, secutityModes, readOnly, rootSquash, secure, async, noHide, crossMount, subtreeCheck)
+	if err != nil {
+		return err
+	}
+	// newShare builds a single ACL, formatted as 'clients(options)'
+	rights := share.accessRights()
+	idx := strings.Index(rights, "(")
+	if idx < 0 {
+		return fmt.Errorf("unexpected format of access rights '%s'", rights)
+	}
+	clients := rights[:idx]
+	// fsid has to be the same on both servers for the file handles held by clients to survive a failover
+	options := strings.TrimSuffix(rights[idx+1:], ")") + fmt.Sprintf(",fsid=%d", haBaseFSID+s.Minor)
+
+	// The passive server is prepared first, for the active one to be able to connect its replica right away
+	for _, primary := range []bool{false, true} {
+		local, peer := s.Secondary, s.Primary
+		if primary {
+			local, peer = s.Primary, s.Secondary
+		}
+		data := map[string]interface{}{
+			"Path":          share.Path,
+			"ExportClients": clients,
+			"ExportOptions": options,
+			"Resource":      s.Resource,
+			"Minor":         s.Minor,
+			"Device":        s.Device,
+			"Port":          haBasePort + s.Minor,
+			"RouterID":      haBaseRouterID + s.Minor,
+			"AuthPass":      s.authPass(),
+			"LocalIP":       local.PrivateIP,
+			"PeerIP":        peer.PrivateIP,
+			"VIP":           s.VIP,
+			"CIDR":          s.CIDR,
+			"Primary":       primary,
+		}
+		retcode, stdout, stderr, err := executeScript(*local.Server.SSHConfig, "nfs_server_ha_install.sh", data)
+		err = handleExecuteScriptReturn(retcode, stdout, stderr, err, fmt.Sprintf("Error executing script to install highly available nfs server on '%s'", local.PrivateIP))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveShare stops the export of path, then removes failover and replication from both servers
+func (s *HAServer) RemoveShare(path string) error {
+	var errs []error
+	// The passive server is cleaned first, to prevent it from taking over while the active one is stopped
+	for _, peer := range []HAPeer{s.Secondary, s.Primary} {
+		data := map[string]interface{}{
+			"Path":     path,
+			"Resource": s.Resource,
+		}
+		retcode, stdout, stderr, err := executeScript(*peer.Server.SSHConfig, "nfs_server_ha_remove.sh", data)
+		err = handleExecuteScriptReturn(retcode, stdout, stderr, err, fmt.Sprintf("Error executing script to remove highly available nfs share from '%s'", peer.PrivateIP))
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return scerr.ErrListError(errs)
+	}
+	return nil
+}
+
+// authPass returns the password used by keepalived instances to authenticate each other (8 characters max)
+func (s *HAServer) authPass() string {
+	pass := strings.Replace(s.Resource, "-", "", -1)
+	if len(pass) > 8 {
+		pass = pass[len(pass)-8:]
+	}
+	return pass
+}
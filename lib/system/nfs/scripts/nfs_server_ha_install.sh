@@ -0,0 +1,185 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# nfs_server_ha_install.sh
+#
+# Installs and configures one of the two servers of a highly available NFS share:
+# - the block device is replicated between the servers with DRBD
+# - keepalived holds the VIP on the active server, and mounts/exports the replicated filesystem there
+
+{{.BashHeader}}
+
+function print_error {
+    read line file <<<$(caller)
+    echo "An error occurred in line $line of file $file:" "{"`sed "${line}q;d" "$file"`"}" >&2
+}
+trap print_error ERR
+
+function dns_fallback {
+    grep nameserver /etc/resolv.conf && return 0
+    echo -e "nameserver 1.1.1.1\n" > /tmp/resolv.conf
+    sudo cp /tmp/resolv.conf /etc/resolv.conf
+    return 0
+}
+
+dns_fallback
+
+{{.reserved_BashLibrary}}
+
+echo "Install highly available NFS server"
+
+case $LINUX_KIND in
+    debian|ubuntu)
+        export DEBIAN_FRONTEND=noninteractive
+        sfApt update
+        sfApt install -qqy nfs-common nfs-kernel-server drbd-utils keepalived
+        NFS_SERVICE=nfs-kernel-server
+        ;;
+
+    rhel|centos)
+        yum makecache fast
+        rpm -q elrepo-release &>/dev/null || yum install -y https://www.elrepo.org/elrepo-release-7.el7.elrepo.noarch.rpm
+        yum install -y nfs-utils drbd90-utils kmod-drbd90 keepalived
+        NFS_SERVICE=nfs-server
+        systemctl enable rpcbind
+
+        setsebool -P use_nfs_home_dirs 1
+        sfFirewallAdd --zone=trusted --add-service=nfs
+        sfFirewallAdd --zone=trusted --add-service=mountd
+        sfFirewallAdd --zone=trusted --add-service=rpc-bind
+        sfFirewallAdd --zone=trusted --add-port={{.Port}}/tcp
+        sfFirewallAdd --zone=trusted --add-protocol=vrrp
+        sfFirewallReload
+        systemctl restart rpcbind
+        ;;
+
+    *)
+        echo "Unsupported operating system '$LINUX_KIND'"
+        exit 1
+        ;;
+esac
+
+systemctl enable $NFS_SERVICE
+systemctl start $NFS_SERVICE
+
+# Configures the replication of the block device; servers are identified by their IP address
+modprobe drbd
+cat >/etc/drbd.d/{{.Resource}}.res <<-EOF
+resource {{.Resource}} {
+    protocol C;
+    net {
+        after-sb-0pri discard-zero-changes;
+        after-sb-1pri discard-secondary;
+        after-sb-2pri disconnect;
+    }
+    floating {{.LocalIP}}:{{.Port}} {
+        device /dev/drbd{{.Minor}};
+        disk {{.Device}};
+        meta-disk internal;
+    }
+    floating {{.PeerIP}}:{{.Port}} {
+        device /dev/drbd{{.Minor}};
+        disk {{.Device}};
+        meta-disk internal;
+    }
+}
+EOF
+drbdadm create-md --force {{.Resource}}
+drbdadm up {{.Resource}}
+
+mkdir -p "{{.Path}}"
+
+{{- if .Primary }}
+# The active server owns the data at creation; initial synchronization to the passive server happens in background
+drbdadm primary --force {{.Resource}}
+mkfs.ext4 -F /dev/drbd{{.Minor}}
+mount /dev/drbd{{.Minor}} "{{.Path}}"
+chmod a+rwx "{{.Path}}"
+umount "{{.Path}}"
+drbdadm secondary {{.Resource}}
+{{- end }}
+
+# Script called by keepalived on state change, moving the export along with the VIP
+NOTIFY=/usr/local/bin/safescale_nfs_ha_{{.Resource}}.sh
+cat >$NOTIFY <<-EOF
+#!/bin/bash
+case "\$1" in
+    master)
+        for i in \$(seq 1 10); do
+            drbdadm primary {{.Resource}} && break
+            sleep 2
+        done
+        mountpoint -q "{{.Path}}" || mount /dev/drbd{{.Minor}} "{{.Path}}" || exit 1
+        systemctl start $NFS_SERVICE
+        exportfs -o {{.ExportOptions}} "{{.ExportClients}}:{{.Path}}"
+        ;;
+    backup|fault|stop)
+        exportfs -u "{{.ExportClients}}:{{.Path}}" || true
+        mountpoint -q "{{.Path}}" && umount -f "{{.Path}}"
+        drbdadm secondary {{.Resource}} || true
+        ;;
+esac
+exit 0
+EOF
+chmod u+rx,go-rwx $NOTIFY
+
+# Configures failover of the VIP; each share has its own VRRP instance
+mkdir -p /etc/keepalived/conf.d
+[ -f /etc/keepalived/keepalived.conf ] || touch /etc/keepalived/keepalived.conf
+grep -q "^include /etc/keepalived/conf.d/\*.conf" /etc/keepalived/keepalived.conf || echo "include /etc/keepalived/conf.d/*.conf" >>/etc/keepalived/keepalived.conf
+
+IF=$(ip -o -4 addr show | awk '$4 ~ /^{{.LocalIP}}\// {print $2}' | head -n 1)
+[ -z "$IF" ] && echo "Failed to find the network interface owning IP {{.LocalIP}}" && exit 1
+NETMASK=$(echo {{.CIDR}} | cut -d/ -f2)
+
+cat >/etc/keepalived/conf.d/{{.Resource}}.conf <<-EOF
+vrrp_instance vrrp_{{.Resource}} {
+    state BACKUP
+    interface ${IF}
+    virtual_router_id {{.RouterID}}
+    priority {{ if .Primary }}151{{ else }}100{{ end }}
+    nopreempt
+    advert_int 2
+    authentication {
+        auth_type PASS
+        auth_pass {{.AuthPass}}
+    }
+    unicast_src_ip {{.LocalIP}}
+    unicast_peer {
+        {{.PeerIP}}
+    }
+    virtual_ipaddress {
+        {{.VIP}}/${NETMASK}
+    }
+    notify_master "$NOTIFY master"
+    notify_backup "$NOTIFY backup"
+    notify_fault "$NOTIFY fault"
+    notify_stop "$NOTIFY stop"
+}
+EOF
+
+systemctl enable keepalived
+systemctl restart keepalived
+
+{{- if .Primary }}
+# Waits for this server to hold the VIP and export the share
+for i in $(seq 1 30); do
+    exportfs | grep -q "^{{.Path}}" && exit 0
+    sleep 2
+done
+echo "Share '{{.Path}}' still not exported after 60s"
+exit 1
+{{- end }}
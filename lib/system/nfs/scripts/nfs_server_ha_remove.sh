@@ -0,0 +1,39 @@
+#!/usr/bin/env bash
+#
+# Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# nfs_server_ha_remove.sh
+#
+# Unexports a highly available NFS share and removes its failover and replication configuration
+
+{{.BashHeader}}
+
+function print_error {
+    read line file <<<$(caller)
+    echo "An error occurred in line $line of file $file:" "{"`sed "${line}q;d" "$file"`"}" >&2
+}
+trap print_error ERR
+
+rm -f /etc/keepalived/conf.d/{{.Resource}}.conf
+systemctl restart keepalived || true
+
+exportfs -s 2>/dev/null | awk '$1 == "{{.Path}}" {print $2}' | sed 's/(.*//' | while read clients; do
+    exportfs -u "${clients}:{{.Path}}" || true
+done
+mountpoint -q "{{.Path}}" && umount -f "{{.Path}}"
+
+drbdadm down {{.Resource}} || true
+rm -f /etc/drbd.d/{{.Resource}}.res /usr/local/bin/safescale_nfs_ha_{{.Resource}}.sh
+exit 0
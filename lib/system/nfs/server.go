@@ -54,9 +54,18 @@ func (s *Server) Install() error {
 
 // AddShare configures a local path to be exported by NFS
 func (s *Server) AddShare(path string, secutityModes []string, readOnly, rootSquash, secure, async, noHide, crossMount, subtreeCheck bool) error {
+	share, err := s.newShare(path, secutityModes, readOnly, rootSquash, secure, async, noHide, crossMount, subtreeCheck)
+	if err != nil {
+		return err
+	}
+	return share.Add()
+}
+
+// newShare prepares the export of path with a single ACL built from the options
+func (s *Server) newShare(path string, secutityModes []string, readOnly, rootSquash, secure, async, noHide, crossMount, subtreeCheck bool) (*Share, error) {
 	share, err := NewShare(s, path)
 	if err != nil {
-		return fmt.Errorf("failed to create the share : %s", err.Error())
+		return nil, fmt.Errorf("failed to create the share : %s", err.Error())
 	}
 
 	acl := ExportACL{
@@ -87,13 +96,12 @@ func (s *Server) AddShare(path string, secutityModes []string, readOnly, rootSqu
 		case "krb5p":
 			acl.SecurityModes = append(acl.SecurityModes, securityflavor.Krb5p)
 		default:
-			return fmt.Errorf("cannot add the share, %s is not a valid security mode", securityMode)
+			return nil, fmt.Errorf("cannot add the share, %s is not a valid security mode", securityMode)
 		}
 	}
 
 	share.AddACL(acl)
-
-	return share.Add()
+	return share, nil
 }
 
 // RemoveShare stops export of a local mount point by NFS on the remote server
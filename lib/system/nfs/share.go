@@ -80,6 +80,17 @@ func (s *Share) AddACL(acl ExportACL) {
 
 //Add configures and exports the share
 func (s *Share) Add() error {
+	data := map[string]interface{}{
+		"Path":         s.Path,
+		"AccessRights": s.accessRights(),
+	}
+
+	retcode, stdout, stderr, err := executeScript(*s.Server.SSHConfig, "nfs_server_path_export.sh", data)
+	return handleExecuteScriptReturn(retcode, stdout, stderr, err, "Error executing script to export a shared directory")
+}
+
+// accessRights returns the ACLs of the share formatted as expected in /etc/exports
+func (s *Share) accessRights() string {
 	var acls string
 	for _, a := range s.ACLs {
 		acl := a.Host + "("
@@ -136,11 +147,5 @@ func (s *Share) Add() error {
 
 		acls += acl + " "
 	}
-	data := map[string]interface{}{
-		"Path":         s.Path,
-		"AccessRights": strings.TrimSpace(acls),
-	}
-
-	retcode, stdout, stderr, err := executeScript(*s.Server.SSHConfig, "nfs_server_path_export.sh", data)
-	return handleExecuteScriptReturn(retcode, stdout, stderr, err, "Error executing script to export a shared directory")
+	return strings.TrimSpace(acls)
 }
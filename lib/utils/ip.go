@@ -17,6 +17,7 @@
 package utils
 
 import (
+	"crypto/rand"
 	"fmt"
 	"net"
 	"strconv"
@@ -106,6 +107,35 @@ func IsCIDRRoutable(cidr string) (bool, error) {
 	return true, nil
 }
 
+// ulaNetwork is the range of IPv6 unique local addresses (RFC 4193), the IPv6 counterpart of RFC 1918 ranges
+var ulaNetwork = &net.IPNet{IP: net.ParseIP("fc00::"), Mask: net.CIDRMask(7, 128)}
+
+// IsIPv6CIDRLocal tells if the IPv6 network is made of unique local addresses, not routable on Internet
+func IsIPv6CIDRLocal(cidr string) (bool, error) {
+	ip, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false, scerr.InvalidParameterError("cidr", "Not a valid CIDR")
+	}
+	if ip.To4() != nil {
+		return false, scerr.InvalidParameterError("cidr", "Not an IPv6 CIDR")
+	}
+	ones, _ := ipnet.Mask.Size()
+	return ones >= 7 && ulaNetwork.Contains(ipnet.IP), nil
+}
+
+// GenerateULAPrefix returns a /64 IPv6 network made of unique local addresses, with a random global ID as
+// recommended by RFC 4193
+func GenerateULAPrefix() (string, error) {
+	ip := make(net.IP, net.IPv6len)
+	ip[0] = 0xfd
+	// 40 bits of global ID and 16 bits of subnet ID
+	_, err := rand.Read(ip[1:8])
+	if err != nil {
+		return "", err
+	}
+	return (&net.IPNet{IP: ip, Mask: net.CIDRMask(64, 128)}).String(), nil
+}
+
 func init() {
 	notRoutables := []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
 
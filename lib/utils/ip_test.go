@@ -0,0 +1,60 @@
+/*
+ * Copyright 2018-2020, CS Systemes d'Information, http://www.c-s.fr
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"testing"
+)
+
+func TestIsIPv6CIDRLocal(t *testing.T) {
+	tests := []struct {
+		cidr  string
+		local bool
+		fails bool
+	}{
+		{"fd12:3456:789a:1::/64", true, false},
+		{"fc00::/7", true, false},
+		{"2001:db8::/32", false, false},
+		{"::/0", false, false},
+		{"192.168.0.0/24", false, true},
+		{"not a cidr", false, true},
+	}
+	for _, tt := range tests {
+		local, err := IsIPv6CIDRLocal(tt.cidr)
+		if (err != nil) != tt.fails {
+			t.Errorf("IsIPv6CIDRLocal(%s) error = %v", tt.cidr, err)
+		}
+		if local != tt.local {
+			t.Errorf("IsIPv6CIDRLocal(%s) = %v, want %v", tt.cidr, local, tt.local)
+		}
+	}
+}
+
+func TestGenerateULAPrefix(t *testing.T) {
+	prefix, err := GenerateULAPrefix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, err := IsIPv6CIDRLocal(prefix)
+	if err != nil || !local {
+		t.Errorf("GenerateULAPrefix() = %s, not a unique local network", prefix)
+	}
+	other, _ := GenerateULAPrefix()
+	if other == prefix {
+		t.Errorf("GenerateULAPrefix() returned twice %s", prefix)
+	}
+}